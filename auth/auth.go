@@ -0,0 +1,70 @@
+// Package auth authenticates connections and requests by the authentication metadata.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Security/Authentication.md
+package auth
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/extension"
+)
+
+// ErrUnsupported should be returned by an Authenticator which doesn't support the authentication type,
+// then the next one in Chain will be tried.
+var ErrUnsupported = errors.New("unsupported authentication type")
+
+type principalCtxKey struct{}
+
+// Principal is an authenticated identity.
+type Principal interface {
+	// Name returns the name of principal.
+	Name() string
+}
+
+// User is a Principal authenticated by username.
+type User string
+
+// Name returns the username.
+func (u User) Name() string {
+	return string(u)
+}
+
+// Authenticator verifies the credentials.
+type Authenticator interface {
+	// Authenticate returns the Principal if the credentials are valid.
+	Authenticate(ctx context.Context, auth *extension.Authentication) (Principal, error)
+}
+
+// AuthenticatorFunc is a function which implements Authenticator.
+type AuthenticatorFunc func(ctx context.Context, auth *extension.Authentication) (Principal, error)
+
+// Authenticate calls the function.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, auth *extension.Authentication) (Principal, error) {
+	return f(ctx, auth)
+}
+
+// Chain returns an Authenticator which tries authenticators in order,
+// until one of them doesn't return ErrUnsupported.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, auth *extension.Authentication) (Principal, error) {
+		for _, it := range authenticators {
+			principal, err := it.Authenticate(ctx, auth)
+			if err == ErrUnsupported {
+				continue
+			}
+			return principal, err
+		}
+		return nil, errors.Wrapf(ErrUnsupported, "authentication type %s", auth.Type())
+	})
+}
+
+// WithPrincipal returns a copy of ctx with the Principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+// PrincipalFromContext returns the Principal in the context.
+func PrincipalFromContext(ctx context.Context) (principal Principal, ok bool) {
+	principal, ok = ctx.Value(principalCtxKey{}).(Principal)
+	return
+}
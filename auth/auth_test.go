@@ -0,0 +1,177 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/auth"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jwtKey = []byte("secret")
+
+func signJWT(t *testing.T, key []byte, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	require.NoError(t, err)
+	body, err := json.Marshal(claims)
+	require.NoError(t, err)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func simple(t *testing.T, username, password string) *extension.Authentication {
+	a, err := extension.NewSimpleAuthentication(username, []byte(password))
+	require.NoError(t, err)
+	return a
+}
+
+func newAuthenticator() auth.Authenticator {
+	return auth.Chain(
+		auth.SimpleUsers(map[string]string{"alice": "123"}),
+		auth.Bearer(auth.NewJWTVerifier(jwtKey, auth.WithJWTIssuer("test"))),
+	)
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	authenticator := newAuthenticator()
+
+	principal, err := authenticator.Authenticate(ctx, simple(t, "alice", "123"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", principal.Name())
+	_, err = authenticator.Authenticate(ctx, simple(t, "alice", "456"))
+	assert.Error(t, err)
+	_, err = authenticator.Authenticate(ctx, simple(t, "bob", "123"))
+	assert.Error(t, err)
+
+	token := signJWT(t, jwtKey, map[string]interface{}{"sub": "bob", "iss": "test"})
+	principal, err = authenticator.Authenticate(ctx, extension.NewBearerAuthentication(token))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", principal.Name())
+	assert.Equal(t, "test", principal.(auth.Claims)["iss"])
+
+	_, err = authenticator.Authenticate(ctx, extension.MustNewAuthentication("custom", []byte("x")))
+	assert.ErrorIs(t, err, auth.ErrUnsupported)
+}
+
+func TestJWTVerifier(t *testing.T) {
+	ctx := context.Background()
+	verify := auth.NewJWTVerifier(jwtKey, auth.WithJWTAudience("api"), auth.WithJWTLeeway(time.Second))
+	now := time.Now().Unix()
+
+	principal, err := verify(ctx, signJWT(t, jwtKey, map[string]interface{}{"sub": "bob", "aud": []string{"web", "api"}, "exp": now + 60}))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", principal.Name())
+
+	for _, bad := range []string{
+		"",
+		"a.b",
+		"a.b.c",
+		signJWT(t, []byte("other"), map[string]interface{}{"sub": "bob", "aud": "api"}),
+		signJWT(t, jwtKey, map[string]interface{}{"sub": "bob", "aud": "api", "exp": now - 60}),
+		signJWT(t, jwtKey, map[string]interface{}{"sub": "bob", "aud": "api", "nbf": now + 60}),
+		signJWT(t, jwtKey, map[string]interface{}{"sub": "bob", "aud": "web"}),
+	} {
+		_, err = verify(ctx, bad)
+		assert.Error(t, err, "token %q should be invalid", bad)
+	}
+}
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	authenticator := newAuthenticator()
+	started := make(chan struct{})
+	go func() {
+		_ = rsocket.Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(auth.Acceptor(authenticator, func(ctx context.Context, setup payload.SetupPayload, socket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+				return auth.Responder(ctx, authenticator, func(ctx context.Context) rsocket.RSocket {
+					return rsocket.NewAbstractSocket(rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+						principal, _ := auth.PrincipalFromContext(ctx)
+						return mono.Just(payload.NewString(principal.Name(), ""))
+					}))
+				}), nil
+			})).
+			Transport(rsocket.TCPServer().SetHostAndPort("127.0.0.1", 9815).Build()).
+			Serve(ctx)
+	}()
+	<-started
+
+	connect := func(a *extension.Authentication) rsocket.Client {
+		metadata, err := extension.NewCompositeMetadataBuilder().
+			PushWellKnown(extension.MessageAuthentication, a.Bytes()).
+			Build()
+		require.NoError(t, err)
+		client, err := rsocket.Connect().
+			MetadataMimeType(extension.MessageCompositeMetadata.String()).
+			SetupPayload(payload.New(nil, metadata)).
+			Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", 9815).Build()).
+			Start(ctx)
+		require.NoError(t, err)
+		return client
+	}
+	withAuth := func(a *extension.Authentication) payload.Payload {
+		metadata, err := extension.NewCompositeMetadataBuilder().
+			PushWellKnown(extension.MessageAuthentication, a.Bytes()).
+			Build()
+		require.NoError(t, err)
+		return payload.New(nil, metadata)
+	}
+	assertErrorCode := func(code core.ErrorCode, err error) {
+		require.Error(t, err)
+		customErr, ok := err.(core.CustomError)
+		require.True(t, ok, "should be a custom error: %v", err)
+		assert.Equal(t, code, customErr.ErrorCode())
+	}
+
+	client := connect(simple(t, "alice", "123"))
+	defer client.Close()
+
+	res, err := client.RequestResponse(payload.New(nil, nil)).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", res.DataUTF8())
+
+	token := signJWT(t, jwtKey, map[string]interface{}{"sub": "bob", "iss": "test"})
+	res, err = client.RequestResponse(withAuth(extension.NewBearerAuthentication(token))).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", res.DataUTF8())
+
+	_, err = client.RequestResponse(withAuth(simple(t, "alice", "bad"))).Block(ctx)
+	assertErrorCode(core.ErrorCodeRejected, err)
+
+	rejected := connect(simple(t, "alice", "bad"))
+	defer rejected.Close()
+	_, err = rejected.RequestResponse(payload.New(nil, nil)).Block(ctx)
+	assertErrorCode(core.ErrorCodeRejectedSetup, err)
+}
+
+func TestResponder_NoPrincipal(t *testing.T) {
+	rs := auth.Responder(context.Background(), newAuthenticator(), func(ctx context.Context) rsocket.RSocket {
+		return rsocket.NewAbstractSocket(rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+			return mono.Just(msg)
+		}))
+	})
+	_, err := rs.RequestResponse(payload.New(nil, nil)).Block(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, core.ErrorCodeRejected, err.(core.CustomError).ErrorCode())
+	_, err = rs.RequestResponse(payload.New(nil, []byte{0x80})).Block(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, core.ErrorCodeRejected, err.(core.CustomError).ErrorCode())
+}
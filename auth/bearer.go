@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/extension"
+)
+
+var (
+	errBadToken     = errors.New("bad token")
+	errTokenExpired = errors.New("token is expired")
+)
+
+// BearerVerifier verifies a bearer token, it can be backed by any JWT library.
+type BearerVerifier func(ctx context.Context, token string) (Principal, error)
+
+// Bearer returns an Authenticator of the bearer type.
+func Bearer(verify BearerVerifier) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, auth *extension.Authentication) (Principal, error) {
+		if auth.Type() != "bearer" {
+			return nil, ErrUnsupported
+		}
+		return verify(ctx, string(auth.Payload()))
+	})
+}
+
+// Claims is a Principal of the claims in a JWT.
+type Claims map[string]interface{}
+
+// Name returns the subject.
+func (c Claims) Name() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// JWTOption configures the JWT verifier.
+type JWTOption func(*jwtOpts)
+
+type jwtOpts struct {
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// WithJWTIssuer requires the "iss" claim.
+func WithJWTIssuer(issuer string) JWTOption {
+	return func(o *jwtOpts) {
+		o.issuer = issuer
+	}
+}
+
+// WithJWTAudience requires the "aud" claim to contain the audience.
+func WithJWTAudience(audience string) JWTOption {
+	return func(o *jwtOpts) {
+		o.audience = audience
+	}
+}
+
+// WithJWTLeeway sets the leeway of checking "exp" and "nbf" claims.
+func WithJWTLeeway(leeway time.Duration) JWTOption {
+	return func(o *jwtOpts) {
+		o.leeway = leeway
+	}
+}
+
+// NewJWTVerifier returns a BearerVerifier of JWT signed by HMAC (HS256, HS384 or HS512) with the key.
+// The "exp" and "nbf" claims are checked if present, the Principal is the Claims.
+func NewJWTVerifier(key []byte, opts ...JWTOption) BearerVerifier {
+	o := &jwtOpts{
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(ctx context.Context, token string) (Principal, error) {
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			return nil, errBadToken
+		}
+		var header struct {
+			Alg string `json:"alg"`
+		}
+		if err := decodeSegment(parts[0], &header); err != nil {
+			return nil, err
+		}
+		var fn func() hash.Hash
+		switch header.Alg {
+		case "HS256":
+			fn = sha256.New
+		case "HS384":
+			fn = sha512.New384
+		case "HS512":
+			fn = sha512.New
+		default:
+			return nil, errors.Errorf("unsupported JWT algorithm %q", header.Alg)
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, errBadToken
+		}
+		mac := hmac.New(fn, key)
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, errors.Wrap(errBadToken, "signature mismatch")
+		}
+		var claims Claims
+		if err := decodeSegment(parts[1], &claims); err != nil {
+			return nil, err
+		}
+		if err := o.validate(claims); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
+}
+
+func (o *jwtOpts) validate(claims Claims) error {
+	now := o.now()
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(o.leeway)) {
+		return errTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0).Add(-o.leeway)) {
+		return errors.New("token is not valid yet")
+	}
+	if len(o.issuer) > 0 && claims["iss"] != o.issuer {
+		return errors.New("token issuer mismatch")
+	}
+	if len(o.audience) > 0 && !hasAudience(claims["aud"], o.audience) {
+		return errors.New("token audience mismatch")
+	}
+	return nil
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch it := aud.(type) {
+	case string:
+		return it == audience
+	case []interface{}:
+		for _, v := range it {
+			if v == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errBadToken
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errBadToken
+	}
+	return nil
+}
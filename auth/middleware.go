@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+var errMissingAuthentication = errors.New("missing authentication")
+
+// Acceptor wraps a ServerAcceptor which authenticates the SETUP before calling acceptor,
+// the Principal is stored in the context passed to acceptor.
+// The authentication is read from the MessageAuthentication entry if the MetadataMimeType is CompositeMetadata,
+// or the whole metadata if the MetadataMimeType is MessageAuthentication.
+// The SETUP is rejected with ErrorCodeRejectedSetup if the authentication is missing or invalid.
+func Acceptor(authenticator Authenticator, acceptor rsocket.ServerAcceptor) rsocket.ServerAcceptor {
+	return func(ctx context.Context, setup payload.SetupPayload, socket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+		metadata, _ := setup.Metadata()
+		var (
+			raw []byte
+			err error
+		)
+		switch setup.MetadataMimeType() {
+		case extension.MessageCompositeMetadata.String():
+			raw, err = lookup(metadata)
+		case extension.MessageAuthentication.String():
+			raw = metadata
+		}
+		if err == nil && len(raw) < 1 {
+			err = errMissingAuthentication
+		}
+		var principal Principal
+		if err == nil {
+			principal, err = authenticate(ctx, authenticator, raw)
+		}
+		if err != nil {
+			return nil, core.NewCustomError(core.ErrorCodeRejectedSetup, []byte(err.Error()))
+		}
+		return acceptor(WithPrincipal(ctx, principal), setup, socket)
+	}
+}
+
+// Responder returns a RSocket which authenticates each request by the MessageAuthentication entry of
+// CompositeMetadata, then dispatches it to the RSocket created by handlers with a context containing the Principal.
+// Requests without authentication use the Principal in ctx, such as the one authenticated by Acceptor.
+// Requests are rejected with ErrorCodeRejected if the authentication is invalid or there's no Principal,
+// FireAndForget and MetadataPush requests are dropped instead.
+func Responder(ctx context.Context, authenticator Authenticator, handlers func(ctx context.Context) rsocket.RSocket) rsocket.RSocket {
+	dispatch := func(msg payload.Payload) (rsocket.RSocket, error) {
+		metadata, _ := msg.Metadata()
+		raw, err := lookup(metadata)
+		if err != nil {
+			return nil, core.NewCustomError(core.ErrorCodeRejected, []byte(err.Error()))
+		}
+		if len(raw) < 1 {
+			if _, ok := PrincipalFromContext(ctx); !ok {
+				return nil, core.NewCustomError(core.ErrorCodeRejected, []byte(errMissingAuthentication.Error()))
+			}
+			return handlers(ctx), nil
+		}
+		principal, err := authenticate(ctx, authenticator, raw)
+		if err != nil {
+			return nil, core.NewCustomError(core.ErrorCodeRejected, []byte(err.Error()))
+		}
+		return handlers(WithPrincipal(ctx, principal)), nil
+	}
+	return rsocket.NewAbstractSocket(
+		rsocket.FireAndForget(func(msg payload.Payload) {
+			rs, err := dispatch(msg)
+			if err != nil {
+				logger.Warnf("authenticate FireAndForget failed: %v\n", err)
+				return
+			}
+			rs.FireAndForget(msg)
+		}),
+		rsocket.MetadataPush(func(msg payload.Payload) {
+			rs, err := dispatch(msg)
+			if err != nil {
+				logger.Warnf("authenticate MetadataPush failed: %v\n", err)
+				return
+			}
+			rs.MetadataPush(msg)
+		}),
+		rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+			rs, err := dispatch(msg)
+			if err != nil {
+				return mono.Error(err)
+			}
+			return rs.RequestResponse(msg)
+		}),
+		rsocket.RequestStream(func(msg payload.Payload) flux.Flux {
+			rs, err := dispatch(msg)
+			if err != nil {
+				return flux.Error(err)
+			}
+			return rs.RequestStream(msg)
+		}),
+		rsocket.RequestChannel(func(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+			rs, err := dispatch(initialMessage)
+			if err != nil {
+				return flux.Error(err)
+			}
+			return rs.RequestChannel(initialMessage, messages)
+		}),
+	)
+}
+
+func authenticate(ctx context.Context, authenticator Authenticator, raw []byte) (Principal, error) {
+	auth, err := extension.ParseAuthentication(raw)
+	if err != nil {
+		return nil, err
+	}
+	principal, err := authenticator.Authenticate(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, errBadCredentials
+	}
+	return principal, nil
+}
+
+// lookup returns the MessageAuthentication entry of CompositeMetadata.
+func lookup(metadata []byte) (raw []byte, err error) {
+	if len(metadata) < 1 {
+		return
+	}
+	raw, _, err = extension.CompositeMetadata(metadata).Lookup(extension.MessageAuthentication.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "bad composite metadata")
+	}
+	return
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/extension"
+)
+
+var errBadCredentials = errors.New("bad credentials")
+
+// Simple returns an Authenticator of the simple type, verify checks the username and password.
+func Simple(verify func(ctx context.Context, username string, password []byte) (Principal, error)) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, auth *extension.Authentication) (Principal, error) {
+		if auth.Type() != "simple" {
+			return nil, ErrUnsupported
+		}
+		username, password, err := auth.ParseSimple()
+		if err != nil {
+			return nil, err
+		}
+		return verify(ctx, username, password)
+	})
+}
+
+// SimpleUsers returns an Authenticator of the simple type which checks passwords mapped by usernames.
+// The Principal is a User.
+func SimpleUsers(users map[string]string) Authenticator {
+	return Simple(func(ctx context.Context, username string, password []byte) (Principal, error) {
+		expected, ok := users[username]
+		if !ok || subtle.ConstantTimeCompare([]byte(expected), password) != 1 {
+			return nil, errBadCredentials
+		}
+		return User(username), nil
+	})
+}
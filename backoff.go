@@ -0,0 +1,90 @@
+package rsocket
+
+import (
+	"math"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/common"
+)
+
+const (
+	_defaultInitialBackoff = 1 * time.Second
+	_defaultMaxBackoff     = 30 * time.Second
+	_defaultBackoffJitter  = 0.5
+)
+
+var (
+	_ Backoff = constantBackoff(0)
+	_ Backoff = (*exponentialBackoff)(nil)
+)
+
+// Backoff computes the delay before each reconnect attempt.
+type Backoff interface {
+	// Next returns the delay before the given attempt, the first attempt is 1.
+	Next(attempt int) time.Duration
+}
+
+// DefaultBackoff creates the Backoff used by default for resuming and reconnecting,
+// it's an exponential backoff from 1s to 30s with 0.5 jitter.
+func DefaultBackoff() Backoff {
+	return NewExponentialBackoff(_defaultInitialBackoff, _defaultMaxBackoff, _defaultBackoffJitter)
+}
+
+type constantBackoff time.Duration
+
+// NewConstantBackoff creates a Backoff which always waits for the same delay.
+func NewConstantBackoff(delay time.Duration) Backoff {
+	if delay < 0 {
+		delay = 0
+	}
+	return constantBackoff(delay)
+}
+
+func (c constantBackoff) Next(int) time.Duration {
+	return time.Duration(c)
+}
+
+type exponentialBackoff struct {
+	initial time.Duration
+	max     time.Duration
+	jitter  float64
+}
+
+// NewExponentialBackoff creates a Backoff which doubles the delay after each attempt, starting from initial
+// and never exceeding max. Each delay is randomized by a factor in [1-jitter, 1+jitter], jitter should be
+// between 0 and 1, so that clients won't reconnect at the same moment after a server restart.
+func NewExponentialBackoff(initial, max time.Duration, jitter float64) Backoff {
+	if initial < 1 {
+		initial = 1
+	}
+	if max < initial {
+		max = initial
+	}
+	if jitter < 0 {
+		jitter = 0
+	} else if jitter > 1 {
+		jitter = 1
+	}
+	return &exponentialBackoff{
+		initial: initial,
+		max:     max,
+		jitter:  jitter,
+	}
+}
+
+func (e *exponentialBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(e.initial) * math.Pow(2, float64(attempt-1))
+	if delay > float64(e.max) {
+		delay = float64(e.max)
+	}
+	if e.jitter > 0 {
+		delay *= 1 - e.jitter + 2*e.jitter*common.RandFloat64()
+	}
+	if delay > float64(e.max) {
+		delay = float64(e.max)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,46 @@
+package rsocket_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := NewConstantBackoff(time.Second)
+	for i := 1; i < 5; i++ {
+		assert.Equal(t, time.Second, b.Next(i))
+	}
+	assert.Equal(t, time.Duration(0), NewConstantBackoff(-time.Second).Next(1))
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := NewExponentialBackoff(100*time.Millisecond, time.Second, 0)
+	assert.Equal(t, 100*time.Millisecond, b.Next(0))
+	assert.Equal(t, 100*time.Millisecond, b.Next(1))
+	assert.Equal(t, 200*time.Millisecond, b.Next(2))
+	assert.Equal(t, 400*time.Millisecond, b.Next(3))
+	assert.Equal(t, 800*time.Millisecond, b.Next(4))
+	assert.Equal(t, time.Second, b.Next(5))
+	assert.Equal(t, time.Second, b.Next(100))
+}
+
+func TestExponentialBackoff_Jitter(t *testing.T) {
+	b := NewExponentialBackoff(100*time.Millisecond, time.Second, 0.5)
+	for i := 0; i < 100; i++ {
+		next := b.Next(3)
+		assert.True(t, next >= 200*time.Millisecond && next <= 600*time.Millisecond, "bad delay: %s", next)
+		assert.True(t, b.Next(10) <= time.Second)
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	b := DefaultBackoff()
+	for i := 0; i < 100; i++ {
+		next := b.Next(1)
+		assert.True(t, next >= 500*time.Millisecond && next <= 1500*time.Millisecond, "bad delay: %s", next)
+		assert.True(t, b.Next(100) <= 30*time.Second)
+	}
+}
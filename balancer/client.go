@@ -0,0 +1,416 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/internal/deferred"
+	"github.com/rsocket/rsocket-go/lease"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+const (
+	_clientMaxRetries    = 2
+	_clientPickTimeout   = 10 * time.Second
+	_retryBudgetRatio    = 0.2
+	_retryBudgetMin      = 10
+	_retryBudgetInterval = 10 * time.Second
+)
+
+var _ rsocket.Client = (*balancedClient)(nil)
+
+// ClientOption configures the client created by NewClient.
+type ClientOption func(*clientOpts)
+
+type clientOpts struct {
+	maxRetries  int
+	pickTimeout time.Duration
+	budget      *retryBudget
+}
+
+// WithClientMaxRetries sets the max number of retries of each request, default is 2.
+func WithClientMaxRetries(n int) ClientOption {
+	return func(opts *clientOpts) {
+		opts.maxRetries = n
+	}
+}
+
+// WithClientPickTimeout sets the max duration of waiting for an available client, default is 10s.
+func WithClientPickTimeout(timeout time.Duration) ClientOption {
+	return func(opts *clientOpts) {
+		opts.pickTimeout = timeout
+	}
+}
+
+// WithClientRetryBudget limits the retries of all requests, so that retrying won't overload backends.
+// In each interval, it allows minRetries retries plus ratio of the number of requests.
+// Default is 10 retries plus 20% of requests in every 10s.
+func WithClientRetryBudget(ratio float64, minRetries int, interval time.Duration) ClientOption {
+	return func(opts *clientOpts) {
+		opts.budget = newRetryBudget(ratio, minRetries, interval)
+	}
+}
+
+// retryBudget counts requests and retries in a fixed interval.
+type retryBudget struct {
+	mu       sync.Mutex
+	ratio    float64
+	min      int
+	interval time.Duration
+	since    time.Time
+	requests int
+	retries  int
+}
+
+func newRetryBudget(ratio float64, min int, interval time.Duration) *retryBudget {
+	if interval <= 0 {
+		interval = _retryBudgetInterval
+	}
+	return &retryBudget{
+		ratio:    ratio,
+		min:      min,
+		interval: interval,
+		since:    time.Now(),
+	}
+}
+
+// rotate should be called with lock held.
+func (r *retryBudget) rotate() {
+	if now := time.Now(); now.Sub(r.since) >= r.interval {
+		r.since = now
+		r.requests, r.retries = 0, 0
+	}
+}
+
+func (r *retryBudget) request() {
+	r.mu.Lock()
+	r.rotate()
+	r.requests++
+	r.mu.Unlock()
+}
+
+// tryRetry returns true if the budget allows one more retry.
+func (r *retryBudget) tryRetry() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate()
+	if float64(r.retries) >= float64(r.min)+r.ratio*float64(r.requests) {
+		return false
+	}
+	r.retries++
+	return true
+}
+
+// IsRetryable returns true if the error is a transport-level failure, the request can be sent to another client safely
+// if it's idempotent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if rsocket.IsSocketClosedError(err) ||
+		errors.Is(err, core.ErrConnectionLost) ||
+		errors.Is(err, core.ErrClientClosed) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	// requests are rejected locally without lease
+	if errors.Is(err, lease.ErrLeaseNotRcv) ||
+		errors.Is(err, lease.ErrLeaseExpired) ||
+		errors.Is(err, lease.ErrLeaseNoMoreRequests) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var customErr core.CustomError
+	if errors.As(err, &customErr) {
+		switch customErr.ErrorCode() {
+		case core.ErrorCodeConnectionError, core.ErrorCodeConnectionClose:
+			return true
+		}
+	}
+	return false
+}
+
+// balancedClient picks a client from Balancer for each request.
+type balancedClient struct {
+	b       Balancer
+	opts    *clientOpts
+	locker  sync.Mutex
+	closers []func(error)
+	once    sync.Once
+}
+
+// NewClient wraps the Balancer as a client which picks a backend for each request.
+// RequestResponse and RequestStream are treated as idempotent, they will be retried on a different backend
+// if failed by a transport-level error (see IsRetryable). A stream is only retried before any element is received.
+// FireAndForget, MetadataPush and RequestChannel are never retried.
+// Closing the client closes the Balancer too.
+func NewClient(b Balancer, options ...ClientOption) rsocket.Client {
+	opts := &clientOpts{
+		maxRetries:  _clientMaxRetries,
+		pickTimeout: _clientPickTimeout,
+	}
+	for _, fn := range options {
+		fn(opts)
+	}
+	if opts.budget == nil {
+		opts.budget = newRetryBudget(_retryBudgetRatio, _retryBudgetMin, _retryBudgetInterval)
+	}
+	return &balancedClient{
+		b:    b,
+		opts: opts,
+	}
+}
+
+// pick returns a client which has not been tried.
+// The context of message is passed to Balancer, so it can carry the hash key, see WithHashKey.
+func (c *balancedClient) pick(message payload.Payload, tried []rsocket.Client) (rsocket.Client, error) {
+	ctx, cancel := context.WithTimeout(rsocket.ContextOf(message), c.opts.pickTimeout)
+	defer cancel()
+	// balancer may return a tried client, pick again for a few times.
+	for i, n := 0, c.b.Len()+len(tried)+1; i < n; i++ {
+		next, ok := c.b.Next(ctx)
+		if !ok {
+			return nil, errNoAvailableClient
+		}
+		// the client of consistent hash is routed by each request, route it here to skip the tried clients.
+		if hc, ok := next.(*hashClient); ok {
+			return hc.b.route(ctx, message, tried)
+		}
+		if !containsClient(tried, next) {
+			return next, nil
+		}
+	}
+	return nil, errNoAvailableClient
+}
+
+// retry returns another client if the failed request can be retried.
+func (c *balancedClient) retry(message payload.Payload, err error, tried []rsocket.Client) (rsocket.Client, bool) {
+	if len(tried) > c.opts.maxRetries || !IsRetryable(err) {
+		return nil, false
+	}
+	next, e := c.pick(message, tried)
+	if e != nil || !c.opts.budget.tryRetry() {
+		return nil, false
+	}
+	logger.Warnf("request failed, retry on another client: %v\n", err)
+	return next, true
+}
+
+func (c *balancedClient) FireAndForget(message payload.Payload) {
+	next, err := c.pick(message, nil)
+	if err != nil {
+		logger.Warnf("request FireAndForget failed: %v\n", err)
+		return
+	}
+	next.FireAndForget(message)
+}
+
+func (c *balancedClient) MetadataPush(message payload.Payload) {
+	next, err := c.pick(message, nil)
+	if err != nil {
+		logger.Warnf("request MetadataPush failed: %v\n", err)
+		return
+	}
+	next.MetadataPush(message)
+}
+
+func (c *balancedClient) RequestResponse(message payload.Payload) mono.Mono {
+	// pick the client when subscribing, so building a request never blocks.
+	return deferred.Mono(func(ctx context.Context) mono.Mono {
+		c.opts.budget.request()
+		next, err := c.pick(message, nil)
+		if err != nil {
+			return mono.Error(err)
+		}
+		return c.requestResponse(message, next, nil)
+	})
+}
+
+func (c *balancedClient) requestResponse(message payload.Payload, next rsocket.Client, tried []rsocket.Client) mono.Mono {
+	tried = append(tried, next)
+	return next.RequestResponse(message).SwitchIfError(func(err error) mono.Mono {
+		another, ok := c.retry(message, err, tried)
+		if !ok {
+			return mono.Error(err)
+		}
+		return c.requestResponse(message, another, tried)
+	})
+}
+
+func (c *balancedClient) RequestStream(message payload.Payload) flux.Flux {
+	s := &retryStream{
+		c:       c,
+		message: message,
+	}
+	return flux.Create(s.start).
+		DoOnRequest(s.request).
+		DoFinally(func(sig rx.SignalType) {
+			if sig == rx.SignalCancel {
+				s.cancel()
+			}
+		})
+}
+
+func (c *balancedClient) RequestChannel(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+	return deferred.Flux(func(ctx context.Context) flux.Flux {
+		next, err := c.pick(initialMessage, nil)
+		if err != nil {
+			return flux.Error(err)
+		}
+		return next.RequestChannel(initialMessage, messages)
+	})
+}
+
+func (c *balancedClient) OnClose(fn func(error)) {
+	if fn == nil {
+		return
+	}
+	c.locker.Lock()
+	c.closers = append(c.closers, fn)
+	c.locker.Unlock()
+}
+
+func (c *balancedClient) Close() (err error) {
+	c.once.Do(func() {
+		err = c.b.Close()
+		c.locker.Lock()
+		closers := c.closers
+		c.locker.Unlock()
+		for i := len(closers); i > 0; i-- {
+			closers[i-1](err)
+		}
+	})
+	return
+}
+
+// retryStream forwards the requests of downstream to current stream, the stream will be retried
+// on another client if it fails before emitting any element.
+type retryStream struct {
+	c         *balancedClient
+	message   payload.Payload
+	mu        sync.Mutex
+	tried     []rsocket.Client
+	sub       rx.Subscription
+	requested int
+	emitted   bool
+	cancelled bool
+}
+
+func (s *retryStream) start(ctx context.Context, sink flux.Sink) {
+	s.c.opts.budget.request()
+	next, err := s.c.pick(s.message, nil)
+	if err != nil {
+		sink.Error(err)
+		return
+	}
+	s.subscribe(ctx, sink, next)
+}
+
+func (s *retryStream) subscribe(ctx context.Context, sink flux.Sink, next rsocket.Client) {
+	s.mu.Lock()
+	s.tried = append(s.tried, next)
+	s.mu.Unlock()
+	next.RequestStream(s.message).Subscribe(ctx,
+		rx.OnSubscribe(func(ctx context.Context, sub rx.Subscription) {
+			s.mu.Lock()
+			if s.cancelled {
+				s.mu.Unlock()
+				sub.Cancel()
+				return
+			}
+			s.sub = sub
+			n := s.requested
+			s.mu.Unlock()
+			if n > 0 {
+				sub.Request(n)
+			}
+		}),
+		rx.OnNext(func(input payload.Payload) error {
+			s.mu.Lock()
+			s.emitted = true
+			s.mu.Unlock()
+			sink.Next(payload.Clone(input))
+			return nil
+		}),
+		rx.OnComplete(func() {
+			sink.Complete()
+		}),
+		rx.OnError(func(e error) {
+			s.mu.Lock()
+			tried := s.tried
+			canRetry := !s.emitted && !s.cancelled
+			s.sub = nil
+			s.mu.Unlock()
+			if !canRetry {
+				sink.Error(e)
+				return
+			}
+			another, ok := s.c.retry(s.message, e, tried)
+			if !ok {
+				sink.Error(e)
+				return
+			}
+			s.subscribe(ctx, sink, another)
+		}),
+	)
+}
+
+func (s *retryStream) request(n int) {
+	s.mu.Lock()
+	if s.requested < rx.RequestMax-n {
+		s.requested += n
+	} else {
+		s.requested = rx.RequestMax
+	}
+	sub := s.sub
+	s.mu.Unlock()
+	if sub != nil {
+		sub.Request(n)
+	}
+}
+
+func (s *retryStream) cancel() {
+	s.mu.Lock()
+	s.cancelled = true
+	sub := s.sub
+	s.mu.Unlock()
+	if sub != nil {
+		sub.Cancel()
+	}
+}
+
+func containsClient(clients []rsocket.Client, target rsocket.Client) bool {
+	target = unwrapClient(target)
+	for _, it := range clients {
+		if unwrapClient(it) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrapClient returns the origin client wrapped by health checking.
+func unwrapClient(client rsocket.Client) rsocket.Client {
+	if cc, ok := client.(*checkedClient); ok {
+		return cc.Client
+	}
+	return client
+}
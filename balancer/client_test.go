@@ -0,0 +1,258 @@
+package balancer_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/balancer"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/lease"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// fakeRetryClient responds with its label, or the error if it's set.
+type fakeRetryClient struct {
+	fakeDialClient
+	label string
+	err   error
+	calls *atomic.Int32
+}
+
+func newFakeRetryClient(label string, err error) *fakeRetryClient {
+	return &fakeRetryClient{
+		label: label,
+		err:   err,
+		calls: atomic.NewInt32(0),
+	}
+}
+
+func (f *fakeRetryClient) RequestResponse(msg payload.Payload) mono.Mono {
+	f.calls.Inc()
+	if f.err != nil {
+		return mono.Error(f.err)
+	}
+	return mono.Just(payload.NewString(f.label, ""))
+}
+
+func (f *fakeRetryClient) RequestStream(msg payload.Payload) flux.Flux {
+	f.calls.Inc()
+	if f.err != nil {
+		return flux.Error(f.err)
+	}
+	return flux.Just(payload.NewString(f.label, "1"), payload.NewString(f.label, "2"), payload.NewString(f.label, "3"))
+}
+
+func newRetryBalancer(t *testing.T, clients ...*fakeRetryClient) Balancer {
+	b := NewRoundRobinBalancer()
+	for _, c := range clients {
+		require.NoError(t, b.PutLabel(c.label, c))
+	}
+	return b
+}
+
+func TestClient_RequestResponse(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broken, good := newFakeRetryClient("broken", core.ErrConnectionLost), newFakeRetryClient("good", nil)
+	c := NewClient(newRetryBalancer(t, broken, good))
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		res, err := c.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "good", res.DataUTF8())
+	}
+	assert.Greater(t, broken.calls.Load(), int32(0))
+	assert.Equal(t, int32(10), good.calls.Load())
+}
+
+func TestClient_NotRetryable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	appErr := errors.New("fake application error")
+	first, second := newFakeRetryClient("first", appErr), newFakeRetryClient("second", appErr)
+	c := NewClient(newRetryBalancer(t, first, second))
+	defer c.Close()
+
+	_, err := c.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+	assert.Equal(t, appErr, err)
+	assert.Equal(t, int32(1), first.calls.Load()+second.calls.Load())
+}
+
+func TestClient_MaxRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clients := []*fakeRetryClient{
+		newFakeRetryClient("a", io.EOF),
+		newFakeRetryClient("b", io.EOF),
+		newFakeRetryClient("c", io.EOF),
+	}
+	c := NewClient(newRetryBalancer(t, clients...), WithClientMaxRetries(1))
+	defer c.Close()
+
+	_, err := c.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+	assert.Equal(t, io.EOF, err)
+	var calls int32
+	for _, it := range clients {
+		assert.LessOrEqual(t, it.calls.Load(), int32(1), "should retry on a different client")
+		calls += it.calls.Load()
+	}
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestClient_RetryBudget(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broken, good := newFakeRetryClient("broken", io.EOF), newFakeRetryClient("good", nil)
+	b := NewConsistentHashBalancer(0, nil)
+	require.NoError(t, b.PutLabel(broken.label, broken))
+	require.NoError(t, b.PutLabel(good.label, good))
+	c := NewClient(b, WithClientRetryBudget(0, 1, time.Minute))
+	defer c.Close()
+
+	var failed int
+	for i := 0; i < 20; i++ {
+		if _, err := c.RequestResponse(payload.NewString("ping", "")).Block(ctx); err != nil {
+			assert.Equal(t, io.EOF, err)
+			failed++
+		}
+	}
+	assert.Equal(t, int32(20), broken.calls.Load()+good.calls.Load()-1, "only one retry is allowed")
+	assert.Equal(t, int(broken.calls.Load())-1, failed)
+}
+
+func TestClient_RequestStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broken := newFakeRetryClient("broken", framing.NewErrorFrame(0, core.ErrorCodeConnectionClose, []byte("fake")).ToError())
+	good := newFakeRetryClient("good", nil)
+	c := NewClient(newRetryBalancer(t, broken, good))
+	defer c.Close()
+
+	for i := 0; i < 4; i++ {
+		results, err := c.RequestStream(payload.NewString("ping", "")).BlockSlice(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		for j, it := range results {
+			assert.Equal(t, "good", it.DataUTF8())
+			m, _ := it.MetadataUTF8()
+			assert.Equal(t, string(rune('1'+j)), m)
+		}
+	}
+	assert.Greater(t, broken.calls.Load(), int32(0))
+
+	// take with backpressure
+	first, err := c.RequestStream(payload.NewString("ping", "")).Take(1).BlockSlice(ctx)
+	require.NoError(t, err)
+	assert.Len(t, first, 1)
+}
+
+func TestClient_Close(t *testing.T) {
+	good := newFakeRetryClient("good", nil)
+	c := NewClient(newRetryBalancer(t, good), WithClientPickTimeout(10*time.Millisecond))
+	closed := make(chan error, 1)
+	c.OnClose(func(err error) {
+		closed <- err
+	})
+	assert.NoError(t, c.Close())
+	assert.NoError(t, <-closed)
+	assert.True(t, good.isClosed())
+}
+
+func TestClient_NoAvailable(t *testing.T) {
+	c := NewClient(NewRoundRobinBalancer(), WithClientPickTimeout(10*time.Millisecond))
+	defer c.Close()
+	_, err := c.RequestResponse(payload.NewString("ping", "")).Block(context.Background())
+	assert.Error(t, err)
+	_, err = c.RequestStream(payload.NewString("ping", "")).BlockLast(context.Background())
+	assert.Error(t, err)
+	_, err = c.RequestChannel(payload.NewString("ping", ""), flux.Empty()).BlockLast(context.Background())
+	assert.Error(t, err)
+}
+
+func TestClient_HashKey(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b := NewConsistentHashBalancer(0, nil)
+	for i := 0; i < 4; i++ {
+		label := fmt.Sprintf("client-%d", i)
+		require.NoError(t, b.PutLabel(label, newFakeRetryClient(label, nil)))
+	}
+	c := NewClient(b)
+	defer c.Close()
+
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		keyCtx := WithHashKey(ctx, fmt.Sprintf("key-%d", i))
+		expect, ok := b.Next(keyCtx)
+		require.True(t, ok)
+		for j := 0; j < 3; j++ {
+			res, err := c.RequestResponse(rsocket.WithContext(keyCtx, payload.NewString("ping", ""))).Block(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, expect.(*fakeRetryClient).label, res.DataUTF8(), "same key should be sent to same client")
+			seen[res.DataUTF8()] = true
+		}
+	}
+	assert.Greater(t, len(seen), 1)
+}
+
+func TestClient_PickOnSubscribe(t *testing.T) {
+	b := NewRoundRobinBalancer()
+	c := NewClient(b, WithClientPickTimeout(time.Second))
+	defer c.Close()
+
+	begin := time.Now()
+	request := c.RequestResponse(payload.NewString("ping", ""))
+	stream := c.RequestStream(payload.NewString("ping", ""))
+	assert.Less(t, time.Since(begin), 100*time.Millisecond, "building requests should not wait for clients")
+
+	good := newFakeRetryClient("good", nil)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = b.PutLabel(good.label, good)
+	}()
+	res, err := request.Block(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "good", res.DataUTF8())
+	results, err := stream.BlockSlice(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, err := range []error{
+		core.ErrConnectionLost,
+		core.ErrClientClosed,
+		io.EOF,
+		errors.Wrap(io.ErrUnexpectedEOF, "fake"),
+		lease.ErrLeaseNoMoreRequests,
+		framing.NewErrorFrame(0, core.ErrorCodeConnectionError, nil).ToError(),
+	} {
+		assert.True(t, IsRetryable(err), "%v should be retryable", err)
+	}
+	for _, err := range []error{
+		nil,
+		errors.New("fake"),
+		framing.NewErrorFrame(0, core.ErrorCodeApplicationError, nil).ToError(),
+		framing.NewErrorFrame(0, core.ErrorCodeRejected, nil).ToError(),
+	} {
+		assert.False(t, IsRetryable(err), "%v should not be retryable", err)
+	}
+}
@@ -0,0 +1,310 @@
+package balancer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/deferred"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+// _hashReplicas is the default number of virtual nodes of each client.
+const _hashReplicas = 160
+
+var errNoAvailableClient = errors.New("no available client")
+
+var _ rsocket.Client = (*hashClient)(nil)
+
+type hashKeyCtxKey struct{}
+
+// HashKeyFunc extracts the hash key from the outgoing payload.
+type HashKeyFunc = func(msg payload.Payload) (key string, ok bool)
+
+// WithHashKey returns a copy of ctx with the hash key, the consistent hash Balancer picks client by it.
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyCtxKey{}, key)
+}
+
+// HashKey returns the hash key in the context.
+func HashKey(ctx context.Context) (key string, ok bool) {
+	key, ok = ctx.Value(hashKeyCtxKey{}).(string)
+	return
+}
+
+// hashOf returns the hash of s, md5 is used for an even distribution like ketama.
+func hashOf(s string) uint32 {
+	sum := md5.Sum([]byte(s))
+	return binary.LittleEndian.Uint32(sum[:4])
+}
+
+// hashRing is a consistent hash ring with virtual nodes.
+type hashRing struct {
+	mu       sync.RWMutex
+	replicas int
+	hashes   []uint32
+	labels   map[uint32]string
+}
+
+func newHashRing(replicas int) *hashRing {
+	return &hashRing{
+		replicas: replicas,
+		labels:   make(map[uint32]string),
+	}
+}
+
+func (r *hashRing) add(label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := 0; i < r.replicas; i++ {
+		h := hashOf(label + "#" + strconv.Itoa(i))
+		if _, ok := r.labels[h]; ok {
+			continue
+		}
+		r.labels[h] = label
+		r.hashes = append(r.hashes, h)
+	}
+	sort.Slice(r.hashes, func(i, j int) bool {
+		return r.hashes[i] < r.hashes[j]
+	})
+}
+
+func (r *hashRing) remove(label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hashes := r.hashes[:0]
+	for _, h := range r.hashes {
+		if r.labels[h] == label {
+			delete(r.labels, h)
+		} else {
+			hashes = append(hashes, h)
+		}
+	}
+	r.hashes = hashes
+}
+
+// get returns the first label clockwise from the key which is accepted by filter.
+func (r *hashRing) get(key string, filter func(label string) bool) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := len(r.hashes)
+	if n < 1 {
+		return "", false
+	}
+	h := hashOf(key)
+	start := sort.Search(n, func(i int) bool {
+		return r.hashes[i] >= h
+	})
+	for i := 0; i < n; i++ {
+		label := r.labels[r.hashes[(start+i)%n]]
+		if filter(label) {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+type balancerConsistentHash struct {
+	*pool
+	ring  *hashRing
+	keyFn HashKeyFunc
+}
+
+func (b *balancerConsistentHash) Put(client rsocket.Client) error {
+	return b.PutLabel(uuid.New().String(), client)
+}
+
+func (b *balancerConsistentHash) PutLabel(label string, client rsocket.Client) error {
+	if err := b.put(label, client, client); err != nil {
+		return err
+	}
+	b.ring.add(label)
+	return nil
+}
+
+func (b *balancerConsistentHash) Next(ctx context.Context) (rsocket.Client, bool) {
+	if key, ok := HashKey(ctx); ok {
+		return b.nextKey(ctx, key)
+	}
+	if b.keyFn == nil {
+		return b.next(ctx, func(_ []string, sockets []rsocket.Client) (rsocket.Client, bool) {
+			return sockets[common.RandIntn(len(sockets))], true
+		})
+	}
+	// wait for any client, the real one will be picked by each request.
+	if _, ok := b.next(ctx, func(_ []string, sockets []rsocket.Client) (rsocket.Client, bool) {
+		return nil, true
+	}); !ok {
+		return nil, false
+	}
+	return &hashClient{
+		b: b,
+	}, true
+}
+
+func (b *balancerConsistentHash) nextKey(ctx context.Context, key string) (rsocket.Client, bool) {
+	return b.next(ctx, func(keys []string, sockets []rsocket.Client) (rsocket.Client, bool) {
+		return b.pickKey(key, keys, sockets, nil)
+	})
+}
+
+// pickKey returns the first client clockwise from the key which is not excluded.
+func (b *balancerConsistentHash) pickKey(key string, keys []string, sockets []rsocket.Client, excluded []rsocket.Client) (rsocket.Client, bool) {
+	var found rsocket.Client
+	_, ok := b.ring.get(key, func(label string) bool {
+		for i := range keys {
+			if keys[i] == label {
+				if containsClient(excluded, sockets[i]) {
+					return false
+				}
+				found = sockets[i]
+				return true
+			}
+		}
+		return false
+	})
+	return found, ok
+}
+
+// route picks the client by the key of payload, it falls back to a random client if there's no key.
+// The excluded clients are skipped, it fails if all clients are excluded.
+func (b *balancerConsistentHash) route(ctx context.Context, msg payload.Payload, excluded []rsocket.Client) (rsocket.Client, error) {
+	key, hasKey := b.keyFn(msg)
+	client, ok := b.next(ctx, func(keys []string, sockets []rsocket.Client) (rsocket.Client, bool) {
+		var found rsocket.Client
+		if hasKey {
+			found, _ = b.pickKey(key, keys, sockets, excluded)
+		} else {
+			found = randomClient(sockets, excluded)
+		}
+		// stop waiting if all clients are excluded.
+		return found, true
+	})
+	if !ok || client == nil {
+		return nil, errNoAvailableClient
+	}
+	return client, nil
+}
+
+// randomClient returns a random client which is not excluded.
+func randomClient(sockets []rsocket.Client, excluded []rsocket.Client) rsocket.Client {
+	if len(excluded) < 1 {
+		return sockets[common.RandIntn(len(sockets))]
+	}
+	candidates := make([]rsocket.Client, 0, len(sockets))
+	for _, it := range sockets {
+		if !containsClient(excluded, it) {
+			candidates = append(candidates, it)
+		}
+	}
+	if len(candidates) < 1 {
+		return nil
+	}
+	return candidates[common.RandIntn(len(candidates))]
+}
+
+// NewConsistentHashBalancer returns a new Balancer which sends the requests of same key to same client.
+// The key is read from context by HashKey, or extracted from each outgoing payload by keyFn if it's not nil.
+// A random client will be picked if there's no key.
+// Each client has some virtual nodes on a hash ring, so only about 1/N of keys move when a client joins or leaves.
+// The replicas is the number of virtual nodes of each client, default is 160.
+func NewConsistentHashBalancer(replicas int, keyFn HashKeyFunc, opts ...Option) Balancer {
+	if replicas < 1 {
+		replicas = _hashReplicas
+	}
+	b := &balancerConsistentHash{
+		pool:  newPool(opts...),
+		ring:  newHashRing(replicas),
+		keyFn: keyFn,
+	}
+	b.OnLeave(func(label string) {
+		// the label may be put again before leaving
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		for _, k := range b.keys {
+			if k == label {
+				return
+			}
+		}
+		b.ring.remove(label)
+	})
+	return b
+}
+
+// hashClient picks the client by the key of payload on each request, the client is routed when
+// the request is subscribed. Closing it is a no-op, the clients are owned by the Balancer.
+type hashClient struct {
+	b *balancerConsistentHash
+}
+
+// routeNow routes the request without waiting for clients, it's used by the requests without stream.
+func (h *hashClient) routeNow(message payload.Payload) (rsocket.Client, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return h.b.route(ctx, message, nil)
+}
+
+func (h *hashClient) FireAndForget(message payload.Payload) {
+	client, err := h.routeNow(message)
+	if err != nil {
+		logger.Warnf("request FireAndForget failed: %v\n", err)
+		return
+	}
+	client.FireAndForget(message)
+}
+
+func (h *hashClient) MetadataPush(message payload.Payload) {
+	client, err := h.routeNow(message)
+	if err != nil {
+		logger.Warnf("request MetadataPush failed: %v\n", err)
+		return
+	}
+	client.MetadataPush(message)
+}
+
+func (h *hashClient) RequestResponse(message payload.Payload) mono.Mono {
+	return deferred.Mono(func(ctx context.Context) mono.Mono {
+		client, err := h.b.route(ctx, message, nil)
+		if err != nil {
+			return mono.Error(err)
+		}
+		return client.RequestResponse(message)
+	})
+}
+
+func (h *hashClient) RequestStream(message payload.Payload) flux.Flux {
+	return deferred.Flux(func(ctx context.Context) flux.Flux {
+		client, err := h.b.route(ctx, message, nil)
+		if err != nil {
+			return flux.Error(err)
+		}
+		return client.RequestStream(message)
+	})
+}
+
+func (h *hashClient) RequestChannel(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+	return deferred.Flux(func(ctx context.Context) flux.Flux {
+		client, err := h.b.route(ctx, initialMessage, nil)
+		if err != nil {
+			return flux.Error(err)
+		}
+		return client.RequestChannel(initialMessage, messages)
+	})
+}
+
+func (h *hashClient) OnClose(func(error)) {
+}
+
+func (h *hashClient) Close() error {
+	return nil
+}
@@ -0,0 +1,160 @@
+package balancer_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go/balancer"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashLabels(ctx context.Context, t *testing.T, b Balancer, n int) map[string]string {
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		label, err := requestLabel(WithHashKey(ctx, key), t, b)
+		require.NoError(t, err)
+		m[key] = label
+	}
+	return m
+}
+
+func TestConsistentHash(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	b := NewConsistentHashBalancer(0, nil)
+	defer b.Close()
+	left := make(chan string, 1)
+	b.OnLeave(func(label string) {
+		left <- label
+	})
+
+	clients := make(map[string]*fakeHealthClient)
+	for i := 0; i < 4; i++ {
+		label := fmt.Sprintf("client-%d", i)
+		clients[label] = newFakeHealthClient(label)
+		require.NoError(t, b.PutLabel(label, clients[label]))
+	}
+
+	const n = 1000
+	before := hashLabels(ctx, t, b, n)
+	assert.Equal(t, before, hashLabels(ctx, t, b, n), "same key should be sent to same client")
+	counts := make(map[string]int)
+	for _, label := range before {
+		counts[label]++
+	}
+	assert.Len(t, counts, 4)
+
+	// only keys of the left client move
+	_ = clients["client-1"].Close()
+	assert.Equal(t, "client-1", <-left)
+	after := hashLabels(ctx, t, b, n)
+	for key, label := range before {
+		if label == "client-1" {
+			assert.NotEqual(t, "client-1", after[key])
+		} else {
+			assert.Equal(t, label, after[key], "key should not move")
+		}
+	}
+
+	// about 1/N keys move to the joined client
+	require.NoError(t, b.PutLabel("client-4", newFakeHealthClient("client-4")))
+	moved := 0
+	for key, label := range hashLabels(ctx, t, b, n) {
+		if label != after[key] {
+			assert.Equal(t, "client-4", label)
+			moved++
+		}
+	}
+	assert.Greater(t, moved, n/10)
+	assert.Less(t, moved, n*4/10)
+}
+
+func TestConsistentHash_KeyFunc(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	b := NewConsistentHashBalancer(10, func(msg payload.Payload) (string, bool) {
+		return msg.MetadataUTF8()
+	})
+	defer b.Close()
+	for i := 0; i < 3; i++ {
+		label := fmt.Sprintf("client-%d", i)
+		require.NoError(t, b.PutLabel(label, newFakeHealthClient(label)))
+	}
+
+	c, ok := b.Next(ctx)
+	require.True(t, ok)
+	seen := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		res, err := c.RequestResponse(payload.NewString("ping", key)).Block(ctx)
+		require.NoError(t, err)
+		// same key should be sent to same client with context key
+		expect, err := requestLabel(WithHashKey(ctx, key), t, b)
+		require.NoError(t, err)
+		assert.Equal(t, expect, res.DataUTF8())
+		seen[expect] = true
+	}
+	assert.Len(t, seen, 3)
+
+	// no key
+	_, err := c.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+	assert.NoError(t, err)
+}
+
+func TestConsistentHash_Empty(t *testing.T) {
+	b := NewConsistentHashBalancer(0, nil)
+	defer b.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, ok := b.Next(WithHashKey(ctx, "foo"))
+	assert.False(t, ok)
+}
+
+func TestConsistentHash_Retry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b := NewConsistentHashBalancer(0, func(msg payload.Payload) (string, bool) {
+		return msg.MetadataUTF8()
+	})
+	broken, good := newFakeRetryClient("broken", core.ErrConnectionLost), newFakeRetryClient("good", nil)
+	require.NoError(t, b.PutLabel(broken.label, broken))
+	require.NoError(t, b.PutLabel(good.label, good))
+	c := NewClient(b)
+	defer c.Close()
+
+	// the concurrent requests are routed and retried independently.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			request := c.RequestResponse(payload.NewString("ping", fmt.Sprintf("key-%d", i)))
+			res, err := request.Block(ctx)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "good", res.DataUTF8(), "should retry on another client")
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Greater(t, broken.calls.Load(), int32(0))
+	assert.Equal(t, int32(20), good.calls.Load())
+
+	// the client is resolved when the request is subscribed.
+	next, ok := b.Next(ctx)
+	require.True(t, ok)
+	calls := good.calls.Load() + broken.calls.Load()
+	request := next.RequestResponse(payload.NewString("ping", "foo"))
+	assert.Equal(t, calls, good.calls.Load()+broken.calls.Load())
+	_, _ = request.Block(ctx)
+	assert.Equal(t, calls+1, good.calls.Load()+broken.calls.Load())
+}
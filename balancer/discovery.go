@@ -0,0 +1,206 @@
+package balancer
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+const (
+	_dnsDiscoveryInterval  = 30 * time.Second
+	_fileDiscoveryInterval = 5 * time.Second
+)
+
+var (
+	_ Discovery = (*pollingDiscovery)(nil)
+	_ Resolver  = (*net.Resolver)(nil)
+)
+
+// EventType is the type of discovery event.
+type EventType int8
+
+const (
+	// EventAdd means a new address is discovered.
+	EventAdd EventType = iota
+	// EventRemove means an address has gone.
+	EventRemove
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventAdd:
+		return "ADD"
+	case EventRemove:
+		return "REMOVE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Event represents a labeled address which is added or removed.
+type Event struct {
+	Type  EventType
+	Label string
+	Addr  string
+}
+
+// Discovery discovers the addresses of backends.
+type Discovery interface {
+	// Watch returns a channel which emits the changes of addresses.
+	// All current addresses will be emitted as EventAdd at first.
+	// The channel will be closed after the context is done or the Discovery is closed.
+	Watch(ctx context.Context) (<-chan Event, error)
+	// Close stops watching.
+	Close() error
+}
+
+// Resolver looks up DNS records, *net.Resolver implements it.
+type Resolver interface {
+	// LookupSRV looks up the SRV records of a service.
+	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+	// LookupHost looks up the addresses of a host.
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+}
+
+// DiscoveryOption configures a Discovery.
+type DiscoveryOption func(*discoveryOpts)
+
+type discoveryOpts struct {
+	interval time.Duration
+	resolver Resolver
+}
+
+// WithDiscoveryInterval sets the interval of polling addresses.
+// Default is 30s for DNS and 5s for file.
+func WithDiscoveryInterval(interval time.Duration) DiscoveryOption {
+	return func(opts *discoveryOpts) {
+		opts.interval = interval
+	}
+}
+
+// WithDiscoveryResolver sets the Resolver of DNS discovery, default is net.DefaultResolver.
+func WithDiscoveryResolver(resolver Resolver) DiscoveryOption {
+	return func(opts *discoveryOpts) {
+		opts.resolver = resolver
+	}
+}
+
+func newDiscoveryOpts(interval time.Duration, options []DiscoveryOption) *discoveryOpts {
+	opts := &discoveryOpts{
+		interval: interval,
+		resolver: net.DefaultResolver,
+	}
+	for _, fn := range options {
+		fn(opts)
+	}
+	return opts
+}
+
+// pollingDiscovery resolves the addresses periodically and emits the differences.
+type pollingDiscovery struct {
+	interval time.Duration
+	// resolve returns addresses mapped by labels.
+	resolve func(ctx context.Context) (map[string]string, error)
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newPollingDiscovery(interval time.Duration, resolve func(ctx context.Context) (map[string]string, error)) *pollingDiscovery {
+	return &pollingDiscovery{
+		interval: interval,
+		resolve:  resolve,
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *pollingDiscovery) Watch(ctx context.Context) (<-chan Event, error) {
+	first, err := p.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan Event)
+	go p.loop(ctx, first, events)
+	return events, nil
+}
+
+func (p *pollingDiscovery) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+	})
+	return nil
+}
+
+func (p *pollingDiscovery) loop(ctx context.Context, first map[string]string, events chan<- Event) {
+	defer close(events)
+	current := make(map[string]string)
+	if !p.emit(ctx, current, first, events) {
+		return
+	}
+	current = first
+	if p.interval <= 0 {
+		select {
+		case <-ctx.Done():
+		case <-p.done:
+		}
+		return
+	}
+	tk := time.NewTicker(p.interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.done:
+			return
+		case <-tk.C:
+		}
+		next, err := p.resolve(ctx)
+		if err != nil {
+			logger.Warnf("discover addresses failed: %v\n", err)
+			continue
+		}
+		if !p.emit(ctx, current, next, events) {
+			return
+		}
+		current = next
+	}
+}
+
+// emit sends the differences between prev and next, it returns false if watching is stopped.
+func (p *pollingDiscovery) emit(ctx context.Context, prev, next map[string]string, events chan<- Event) bool {
+	var diff []Event
+	for label, addr := range prev {
+		if next[label] != addr {
+			diff = append(diff, Event{Type: EventRemove, Label: label, Addr: addr})
+		}
+	}
+	for label, addr := range next {
+		if prev[label] != addr {
+			diff = append(diff, Event{Type: EventAdd, Label: label, Addr: addr})
+		}
+	}
+	for _, e := range diff {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-p.done:
+			return false
+		case events <- e:
+		}
+	}
+	return true
+}
+
+// NewStaticDiscovery returns a Discovery of fixed addresses, the addresses are also used as labels.
+func NewStaticDiscovery(addrs ...string) Discovery {
+	m := make(map[string]string, len(addrs))
+	for _, addr := range addrs {
+		m[addr] = addr
+	}
+	return newPollingDiscovery(0, func(context.Context) (map[string]string, error) {
+		return m, nil
+	})
+}
@@ -0,0 +1,45 @@
+package balancer
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// NewDNSDiscovery returns a Discovery which polls the A/AAAA records of host.
+// Each resolved IP is joined with port as an address, the address is also used as label.
+func NewDNSDiscovery(host string, port int, options ...DiscoveryOption) Discovery {
+	opts := newDiscoveryOpts(_dnsDiscoveryInterval, options)
+	p := strconv.Itoa(port)
+	return newPollingDiscovery(opts.interval, func(ctx context.Context) (map[string]string, error) {
+		ips, err := opts.resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]string, len(ips))
+		for _, ip := range ips {
+			addr := net.JoinHostPort(ip, p)
+			m[addr] = addr
+		}
+		return m, nil
+	})
+}
+
+// NewDNSSRVDiscovery returns a Discovery which polls the SRV records of _service._proto.name.
+// Each record is converted to an address of target and port, the address is also used as label.
+func NewDNSSRVDiscovery(service, proto, name string, options ...DiscoveryOption) Discovery {
+	opts := newDiscoveryOpts(_dnsDiscoveryInterval, options)
+	return newPollingDiscovery(opts.interval, func(ctx context.Context) (map[string]string, error) {
+		_, records, err := opts.resolver.LookupSRV(ctx, service, proto, name)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]string, len(records))
+		for _, it := range records {
+			addr := net.JoinHostPort(strings.TrimSuffix(it.Target, "."), strconv.Itoa(int(it.Port)))
+			m[addr] = addr
+		}
+		return m, nil
+	})
+}
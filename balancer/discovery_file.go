@@ -0,0 +1,63 @@
+package balancer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEntry is an entry of the file watched by file discovery.
+type FileEntry struct {
+	// Label is the label of address, the address will be used if it's empty.
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	// Addr is the address of backend.
+	Addr string `json:"addr" yaml:"addr"`
+}
+
+// NewFileDiscovery returns a Discovery which watches a JSON or YAML file.
+// The file should contain a list of FileEntry, it will be parsed as YAML if the extension is ".yaml" or ".yml",
+// otherwise as JSON. For example:
+//
+//	[{"label": "foo", "addr": "127.0.0.1:7878"}, {"addr": "127.0.0.1:7879"}]
+//
+// The file is reloaded periodically, the latest addresses are kept if it's broken.
+func NewFileDiscovery(path string, options ...DiscoveryOption) Discovery {
+	opts := newDiscoveryOpts(_fileDiscoveryInterval, options)
+	return newPollingDiscovery(opts.interval, func(context.Context) (map[string]string, error) {
+		return readFileEntries(path)
+	})
+}
+
+func readFileEntries(path string) (map[string]string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []FileEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bs, &entries)
+	default:
+		err = json.Unmarshal(bs, &entries)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse discovery file %s failed", path)
+	}
+	m := make(map[string]string, len(entries))
+	for _, it := range entries {
+		if it.Addr == "" {
+			return nil, errors.Errorf("parse discovery file %s failed: missing addr", path)
+		}
+		label := it.Label
+		if label == "" {
+			label = it.Addr
+		}
+		m[label] = it.Addr
+	}
+	return m, nil
+}
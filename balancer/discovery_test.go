@@ -0,0 +1,274 @@
+package balancer_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/balancer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+type fakeResolver struct {
+	mu    sync.Mutex
+	hosts []string
+	srv   []*net.SRV
+	err   error
+}
+
+func (f *fakeResolver) set(hosts []string, srv []*net.SRV, err error) {
+	f.mu.Lock()
+	f.hosts, f.srv, f.err = hosts, srv, err
+	f.mu.Unlock()
+}
+
+func (f *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "", f.srv, f.err
+}
+
+func (f *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hosts, f.err
+}
+
+// fakeDialClient is a client which only supports closing.
+type fakeDialClient struct {
+	rsocket.Client
+	addr    string
+	mu      sync.Mutex
+	closed  bool
+	closers []func(error)
+}
+
+func (f *fakeDialClient) OnClose(fn func(error)) {
+	f.mu.Lock()
+	f.closers = append(f.closers, fn)
+	f.mu.Unlock()
+}
+
+func (f *fakeDialClient) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	closers := f.closers
+	f.mu.Unlock()
+	for _, fn := range closers {
+		go fn(nil)
+	}
+	return nil
+}
+
+func (f *fakeDialClient) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func collectEvents(t *testing.T, events <-chan Event, n int) map[Event]bool {
+	m := make(map[Event]bool)
+	for i := 0; i < n; i++ {
+		select {
+		case e := <-events:
+			m[e] = true
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "wait for discovery event timeout")
+		}
+	}
+	return m
+}
+
+func TestStaticDiscovery(t *testing.T) {
+	d := NewStaticDiscovery("127.0.0.1:7878", "127.0.0.1:7879")
+	events, err := d.Watch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[Event]bool{
+		{Type: EventAdd, Label: "127.0.0.1:7878", Addr: "127.0.0.1:7878"}: true,
+		{Type: EventAdd, Label: "127.0.0.1:7879", Addr: "127.0.0.1:7879"}: true,
+	}, collectEvents(t, events, 2))
+	assert.NoError(t, d.Close())
+	_, ok := <-events
+	assert.False(t, ok, "events should be closed")
+}
+
+func TestDNSDiscovery(t *testing.T) {
+	resolver := &fakeResolver{}
+	resolver.set([]string{"10.0.0.1", "10.0.0.2"}, nil, nil)
+	d := NewDNSDiscovery("foo.local", 7878, WithDiscoveryResolver(resolver), WithDiscoveryInterval(10*time.Millisecond))
+	defer d.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := d.Watch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[Event]bool{
+		{Type: EventAdd, Label: "10.0.0.1:7878", Addr: "10.0.0.1:7878"}: true,
+		{Type: EventAdd, Label: "10.0.0.2:7878", Addr: "10.0.0.2:7878"}: true,
+	}, collectEvents(t, events, 2))
+
+	// broken resolver should keep current addresses
+	resolver.set(nil, nil, errors.New("fake error"))
+	time.Sleep(30 * time.Millisecond)
+	resolver.set([]string{"10.0.0.2", "10.0.0.3"}, nil, nil)
+	assert.Equal(t, map[Event]bool{
+		{Type: EventRemove, Label: "10.0.0.1:7878", Addr: "10.0.0.1:7878"}: true,
+		{Type: EventAdd, Label: "10.0.0.3:7878", Addr: "10.0.0.3:7878"}:    true,
+	}, collectEvents(t, events, 2))
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestDNSSRVDiscovery(t *testing.T) {
+	resolver := &fakeResolver{}
+	resolver.set(nil, []*net.SRV{
+		{Target: "a.foo.local.", Port: 7878},
+		{Target: "b.foo.local.", Port: 7879},
+	}, nil)
+	d := NewDNSSRVDiscovery("rsocket", "tcp", "foo.local", WithDiscoveryResolver(resolver))
+	defer d.Close()
+	events, err := d.Watch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[Event]bool{
+		{Type: EventAdd, Label: "a.foo.local:7878", Addr: "a.foo.local:7878"}: true,
+		{Type: EventAdd, Label: "b.foo.local:7879", Addr: "b.foo.local:7879"}: true,
+	}, collectEvents(t, events, 2))
+
+	resolver.set(nil, nil, errors.New("fake error"))
+	_, err = NewDNSSRVDiscovery("rsocket", "tcp", "foo.local", WithDiscoveryResolver(resolver)).Watch(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFileDiscovery(t *testing.T) {
+	for name, contents := range map[string][2]string{
+		"backends.json": {
+			`[{"label": "foo", "addr": "127.0.0.1:7878"}, {"addr": "127.0.0.1:7879"}]`,
+			`[{"label": "foo", "addr": "127.0.0.1:7880"}]`,
+		},
+		"backends.yaml": {
+			"- label: foo\n  addr: 127.0.0.1:7878\n- addr: 127.0.0.1:7879\n",
+			"- label: foo\n  addr: 127.0.0.1:7880\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(path, []byte(contents[0]), 0644))
+
+			d := NewFileDiscovery(path, WithDiscoveryInterval(10*time.Millisecond))
+			defer d.Close()
+			events, err := d.Watch(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, map[Event]bool{
+				{Type: EventAdd, Label: "foo", Addr: "127.0.0.1:7878"}:            true,
+				{Type: EventAdd, Label: "127.0.0.1:7879", Addr: "127.0.0.1:7879"}: true,
+			}, collectEvents(t, events, 2))
+
+			require.NoError(t, os.WriteFile(path, []byte(contents[1]), 0644))
+			assert.Equal(t, map[Event]bool{
+				{Type: EventRemove, Label: "foo", Addr: "127.0.0.1:7878"}:            true,
+				{Type: EventRemove, Label: "127.0.0.1:7879", Addr: "127.0.0.1:7879"}: true,
+				{Type: EventAdd, Label: "foo", Addr: "127.0.0.1:7880"}:               true,
+			}, collectEvents(t, events, 3))
+		})
+	}
+}
+
+func TestFileDiscovery_Broken(t *testing.T) {
+	_, err := NewFileDiscovery(filepath.Join(t.TempDir(), "not_exists.json")).Watch(context.Background())
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "broken.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"label": "foo"}]`), 0644))
+	_, err = NewFileDiscovery(path).Watch(context.Background())
+	assert.Error(t, err)
+}
+
+func TestGroup_Discover(t *testing.T) {
+	resolver := &fakeResolver{}
+	resolver.set([]string{"10.0.0.1", "10.0.0.2"}, nil, nil)
+	d := NewDNSDiscovery("foo.local", 7878, WithDiscoveryResolver(resolver), WithDiscoveryInterval(10*time.Millisecond))
+	defer d.Close()
+
+	var (
+		mu      sync.Mutex
+		clients = make(map[string][]*fakeDialClient)
+		failed  = atomic.NewBool(false)
+	)
+	dialer := func(ctx context.Context, addr string) (rsocket.Client, error) {
+		// first dialing of 10.0.0.2 fails
+		if addr == "10.0.0.2:7878" && failed.CAS(false, true) {
+			return nil, errors.New("fake dial error")
+		}
+		c := &fakeDialClient{addr: addr}
+		mu.Lock()
+		clients[addr] = append(clients[addr], c)
+		mu.Unlock()
+		return c, nil
+	}
+	dialed := func(addr string) []*fakeDialClient {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*fakeDialClient(nil), clients[addr]...)
+	}
+
+	g := NewGroup(func() Balancer {
+		return NewRoundRobinBalancer()
+	})
+	err := g.Discover(context.Background(), fakeGroupId, d, dialer, rsocket.NewConstantBackoff(10*time.Millisecond))
+	require.NoError(t, err)
+	b := g.Get(fakeGroupId)
+
+	require.Eventually(t, func() bool {
+		return b.Len() == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.True(t, failed.Load())
+
+	// redial after client closed
+	first := dialed("10.0.0.1:7878")
+	require.Len(t, first, 1)
+	_ = first[0].Close()
+	require.Eventually(t, func() bool {
+		return len(dialed("10.0.0.1:7878")) > 1 && b.Len() == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// close client after address removed
+	resolver.set([]string{"10.0.0.1"}, nil, nil)
+	require.Eventually(t, func() bool {
+		return b.Len() == 1
+	}, 3*time.Second, 10*time.Millisecond)
+	removed := dialed("10.0.0.2:7878")
+	require.Len(t, removed, 1)
+	assert.True(t, removed[0].isClosed())
+
+	_ = g.Close()
+	n := len(dialed("10.0.0.1:7878"))
+	time.Sleep(50 * time.Millisecond)
+	for _, c := range dialed("10.0.0.1:7878") {
+		assert.True(t, c.isClosed())
+	}
+	assert.Len(t, dialed("10.0.0.1:7878"), n, "should not redial after group closed")
+}
+
+func TestGroup_DiscoverBroken(t *testing.T) {
+	g := NewGroup(func() Balancer {
+		return NewRoundRobinBalancer()
+	})
+	defer g.Close()
+	d := NewFileDiscovery(filepath.Join(t.TempDir(), "not_exists.json"))
+	err := g.Discover(context.Background(), fakeGroupId, d, nil, nil)
+	assert.Error(t, err)
+}
@@ -1,9 +1,11 @@
 package balancer
 
 import (
+	"context"
 	"errors"
 	"sync"
 
+	"github.com/rsocket/rsocket-go"
 	"github.com/rsocket/rsocket-go/logger"
 )
 
@@ -12,9 +14,10 @@ var errGroupClosed = errors.New("balancer group has been closed")
 // Group manage a group of Balancer.
 // Group can be used to create a simple RSocket Broker.
 type Group struct {
-	g func() Balancer
-	l sync.Mutex
-	m map[string]Balancer
+	g       func() Balancer
+	l       sync.Mutex
+	m       map[string]Balancer
+	cancels []context.CancelFunc
 }
 
 // Close close current RSocket group.
@@ -22,6 +25,13 @@ func (p *Group) Close() (err error) {
 	if p.m == nil {
 		return
 	}
+	p.l.Lock()
+	for _, cancel := range p.cancels {
+		cancel()
+	}
+	p.cancels = nil
+	p.l.Unlock()
+
 	all := make(chan Balancer)
 	done := make(chan struct{})
 	go func(all chan Balancer, done chan struct{}) {
@@ -61,6 +71,27 @@ func (p *Group) Get(id string) Balancer {
 	return newborn
 }
 
+// Discover keeps the Balancer with custom id in sync with the discovery.
+// A client will be dialed for each new address, and be closed once the address is removed.
+// The client will be redialed by backoff if dialing failed or it's closed unexpectedly, nil backoff means
+// an exponential backoff from 1s to 30s.
+// Discovering stops when the context is done, the Discovery is closed or the Group is closed,
+// all clients dialed by it will be closed then.
+func (p *Group) Discover(ctx context.Context, id string, discovery Discovery, dialer Dialer, backoff rsocket.Backoff) error {
+	b := p.Get(id)
+	ctx, cancel := context.WithCancel(ctx)
+	events, err := discovery.Watch(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	p.l.Lock()
+	p.cancels = append(p.cancels, cancel)
+	p.l.Unlock()
+	go newSyncer(b, dialer, backoff).run(ctx, events)
+	return nil
+}
+
 // NewGroup returns a new Group.
 func NewGroup(gen func() Balancer) *Group {
 	return &Group{
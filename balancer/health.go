@@ -0,0 +1,411 @@
+package balancer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"go.uber.org/atomic"
+)
+
+const (
+	_healthProbeInterval    = 10 * time.Second
+	_healthProbeTimeout     = 3 * time.Second
+	_healthWindow           = 10 * time.Second
+	_healthMinRequests      = 10
+	_healthErrorRate        = 0.5
+	_healthCooldown         = 5 * time.Second
+	_healthHalfOpenRequests = 1
+	_healthRecheckInterval  = 10 * time.Millisecond
+)
+
+var (
+	errHealthProbe   = errors.New("health probe failed")
+	errHealthLatency = errors.New("health latency exceeded")
+)
+
+var (
+	_ rsocket.Client        = (*checkedClient)(nil)
+	_ rsocket.LeaseAware    = (*checkedClient)(nil)
+	_ rsocket.MimeTypeAware = (*checkedClient)(nil)
+)
+
+// HealthCheck configures the health checking of clients in a Balancer.
+// Unhealthy clients are ejected from rotation for a cooldown, then some trial requests are let through,
+// the client will be recovered if all of them succeed, otherwise it will be ejected again.
+type HealthCheck struct {
+	// Probe is the payload of active health checking, it will be sent as RequestResponse periodically.
+	// A failed probe ejects the client immediately. Nil disables active health checking.
+	Probe payload.Payload
+	// ProbeInterval is the interval of probing, default is 10s.
+	ProbeInterval time.Duration
+	// ProbeTimeout is the timeout of each probe, default is 3s.
+	ProbeTimeout time.Duration
+	// Window is the duration of counting requests for the error rate, default is 10s.
+	Window time.Duration
+	// MinRequests is the min number of requests in a window before the error rate is checked, default is 10.
+	MinRequests int
+	// ErrorRate is the rate of failed requests in a window which ejects the client, default is 0.5.
+	ErrorRate float64
+	// Latency is the max latency of a request, slower requests are treated as failed. Zero means no limit.
+	Latency time.Duration
+	// Cooldown is the duration of ejection before trial requests are allowed, default is 5s.
+	Cooldown time.Duration
+	// HalfOpenRequests is the number of trial requests after cooldown, default is 1.
+	HalfOpenRequests int
+	// IsFailure reports whether an error should be treated as a failure, default treats all errors as failures.
+	IsFailure func(err error) bool
+	// OnEject will be called when a client is ejected.
+	OnEject func(label string, cause error)
+	// OnRecover will be called when an ejected client is recovered.
+	OnRecover func(label string)
+}
+
+func (h HealthCheck) normalize() *HealthCheck {
+	if h.ProbeInterval <= 0 {
+		h.ProbeInterval = _healthProbeInterval
+	}
+	if h.ProbeTimeout <= 0 {
+		h.ProbeTimeout = _healthProbeTimeout
+	}
+	if h.Window <= 0 {
+		h.Window = _healthWindow
+	}
+	if h.MinRequests < 1 {
+		h.MinRequests = _healthMinRequests
+	}
+	if h.ErrorRate <= 0 {
+		h.ErrorRate = _healthErrorRate
+	}
+	if h.Cooldown <= 0 {
+		h.Cooldown = _healthCooldown
+	}
+	if h.HalfOpenRequests < 1 {
+		h.HalfOpenRequests = _healthHalfOpenRequests
+	}
+	if h.IsFailure == nil {
+		h.IsFailure = func(error) bool {
+			return true
+		}
+	}
+	return &h
+}
+
+// Option configures a Balancer.
+type Option func(*pool)
+
+// WithHealthCheck enables health checking of clients.
+func WithHealthCheck(hc HealthCheck) Option {
+	return func(p *pool) {
+		p.health = hc.normalize()
+		if p.recheck <= 0 {
+			p.recheck = _healthRecheckInterval
+		}
+	}
+}
+
+type breakerState int8
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// checker is a circuit breaker of a client.
+type checker struct {
+	label  string
+	client rsocket.Client
+	hc     *HealthCheck
+	mu     sync.Mutex
+	state  breakerState
+	// since is the start of current window when closed, or the time of ejection when open or half-open.
+	since    time.Time
+	total    int
+	failures int
+	// trials is the number of trial requests in flight when half-open.
+	trials    int
+	successes int
+	// epoch increases when trials are reset, so the results of stale trials are ignored.
+	epoch uint64
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newChecker(label string, client rsocket.Client, hc *HealthCheck) *checker {
+	return &checker{
+		label:  label,
+		client: client,
+		hc:     hc,
+		since:  time.Now(),
+		done:   make(chan struct{}),
+	}
+}
+
+// available returns true if the client can be picked, it doesn't change the state.
+func (c *checker) available(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.state {
+	case breakerOpen:
+		return now.Sub(c.since) >= c.hc.Cooldown
+	case breakerHalfOpen:
+		// trials may be lost, such as FireAndForget, allow new trials after another cooldown.
+		return now.Sub(c.since) >= c.hc.Cooldown || c.trials < c.hc.HalfOpenRequests
+	default:
+		return true
+	}
+}
+
+// acquire is called when the client is picked, it admits the request as a trial if the client is not closed.
+// It returns false if the request is not a trial.
+func (c *checker) acquire(now time.Time) (epoch uint64, trial bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.state {
+	case breakerOpen:
+		if now.Sub(c.since) < c.hc.Cooldown {
+			return
+		}
+		c.state = breakerHalfOpen
+		c.resetTrials(now)
+	case breakerHalfOpen:
+		if now.Sub(c.since) >= c.hc.Cooldown {
+			c.resetTrials(now)
+		} else if c.trials >= c.hc.HalfOpenRequests {
+			return
+		}
+	default:
+		return
+	}
+	c.trials++
+	return c.epoch, true
+}
+
+// resetTrials should be called with lock held.
+func (c *checker) resetTrials(now time.Time) {
+	c.since = now
+	c.trials, c.successes = 0, 0
+	c.epoch++
+}
+
+// record records the result of a request, trial is true if the request was admitted as a trial of epoch.
+func (c *checker) record(elapsed time.Duration, err error, epoch uint64, trial bool) {
+	if err == nil && c.hc.Latency > 0 && elapsed > c.hc.Latency {
+		err = errHealthLatency
+	}
+	failed := err != nil && (err == errHealthLatency || c.hc.IsFailure(err))
+	now := time.Now()
+
+	c.mu.Lock()
+	switch c.state {
+	case breakerClosed:
+		if now.Sub(c.since) >= c.hc.Window {
+			c.since = now
+			c.total, c.failures = 0, 0
+		}
+		c.total++
+		if failed {
+			c.failures++
+		}
+		if c.total >= c.hc.MinRequests && float64(c.failures) >= c.hc.ErrorRate*float64(c.total) {
+			c.eject(now, err)
+		}
+	case breakerHalfOpen:
+		// only the trials of current epoch can decide the state.
+		if !trial || epoch != c.epoch {
+			break
+		}
+		c.trials--
+		if failed {
+			c.eject(now, err)
+		} else if c.successes++; c.successes >= c.hc.HalfOpenRequests {
+			c.recover(now)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// probe records the result of active health checking.
+func (c *checker) probe(err error) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if c.state != breakerOpen {
+			c.eject(now, errors.Wrap(err, errHealthProbe.Error()))
+		}
+		return
+	}
+	switch c.state {
+	case breakerOpen:
+		if now.Sub(c.since) >= c.hc.Cooldown {
+			c.recover(now)
+		}
+	case breakerHalfOpen:
+		c.recover(now)
+	}
+}
+
+// eject should be called with lock held.
+func (c *checker) eject(now time.Time, cause error) {
+	c.state = breakerOpen
+	c.since = now
+	if fn := c.hc.OnEject; fn != nil {
+		go fn(c.label, cause)
+	}
+}
+
+// recover should be called with lock held.
+func (c *checker) recover(now time.Time) {
+	c.state = breakerClosed
+	c.since = now
+	c.total, c.failures = 0, 0
+	c.trials, c.successes = 0, 0
+	c.epoch++
+	if fn := c.hc.OnRecover; fn != nil {
+		go fn(c.label)
+	}
+}
+
+// start starts active health checking if probe is set.
+func (c *checker) start() {
+	if c.hc.Probe == nil {
+		return
+	}
+	go func() {
+		tk := time.NewTicker(c.hc.ProbeInterval)
+		defer tk.Stop()
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-tk.C:
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), c.hc.ProbeTimeout)
+			_, err := c.client.RequestResponse(c.hc.Probe).Block(ctx)
+			cancel()
+			if err != nil && logger.IsDebugEnabled() {
+				logger.Debugf("health probe of client %s failed: %v\n", c.label, err)
+			}
+			c.probe(err)
+		}
+	}()
+}
+
+func (c *checker) stop() {
+	c.once.Do(func() {
+		close(c.done)
+	})
+}
+
+// checkedClient records the results of requests into checker.
+type checkedClient struct {
+	rsocket.Client
+	c *checker
+	// trial is true if the client was picked as a trial of epoch when half-open.
+	trial bool
+	epoch uint64
+}
+
+func (cc *checkedClient) RemainingLease() (tickets int64, expiry time.Time, enabled bool) {
+	if leased, ok := cc.Client.(rsocket.LeaseAware); ok {
+		return leased.RemainingLease()
+	}
+	return
+}
+
+func (cc *checkedClient) DataMimeType() string {
+	if aware, ok := cc.Client.(rsocket.MimeTypeAware); ok {
+		return aware.DataMimeType()
+	}
+	return ""
+}
+
+func (cc *checkedClient) MetadataMimeType() string {
+	if aware, ok := cc.Client.(rsocket.MimeTypeAware); ok {
+		return aware.MetadataMimeType()
+	}
+	return ""
+}
+
+func (cc *checkedClient) record(elapsed time.Duration, err error) {
+	cc.c.record(elapsed, err, cc.epoch, cc.trial)
+}
+
+func (cc *checkedClient) RequestResponse(message payload.Payload) mono.Mono {
+	start := atomic.NewInt64(0)
+	return cc.Client.RequestResponse(message).
+		DoOnSubscribe(func(ctx context.Context, s rx.Subscription) {
+			start.Store(time.Now().UnixNano())
+		}).
+		DoOnSuccess(func(input payload.Payload) error {
+			cc.record(cc.since(start), nil)
+			return nil
+		}).
+		DoOnError(func(e error) {
+			cc.record(cc.since(start), e)
+		}).
+		DoOnCancel(func() {
+			cc.cancel(start)
+		})
+}
+
+func (cc *checkedClient) RequestStream(message payload.Payload) flux.Flux {
+	return cc.observe(cc.Client.RequestStream(message))
+}
+
+func (cc *checkedClient) RequestChannel(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+	return cc.observe(cc.Client.RequestChannel(initialMessage, messages))
+}
+
+// observe records the result of the first signal of a stream.
+func (cc *checkedClient) observe(f flux.Flux) flux.Flux {
+	var (
+		start = atomic.NewInt64(0)
+		first = atomic.NewBool(false)
+	)
+	return f.
+		DoOnSubscribe(func(ctx context.Context, s rx.Subscription) {
+			start.Store(time.Now().UnixNano())
+		}).
+		DoOnNext(func(input payload.Payload) error {
+			if first.CAS(false, true) {
+				cc.record(cc.since(start), nil)
+			}
+			return nil
+		}).
+		DoOnComplete(func() {
+			if first.CAS(false, true) {
+				cc.record(cc.since(start), nil)
+			}
+		}).
+		DoOnError(func(e error) {
+			if first.CAS(false, true) {
+				cc.record(cc.since(start), e)
+			}
+		}).
+		DoFinally(func(s rx.SignalType) {
+			if s == rx.SignalCancel && first.CAS(false, true) {
+				cc.cancel(start)
+			}
+		})
+}
+
+// cancel treats a cancelled request as timeout if it's slower than the latency limit.
+func (cc *checkedClient) cancel(start *atomic.Int64) {
+	if elapsed := cc.since(start); cc.c.hc.Latency > 0 && elapsed > cc.c.hc.Latency {
+		cc.record(elapsed, errHealthLatency)
+	}
+}
+
+func (cc *checkedClient) since(start *atomic.Int64) time.Duration {
+	return time.Duration(time.Now().UnixNano() - start.Load())
+}
@@ -0,0 +1,281 @@
+package balancer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/balancer"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// fakeHealthClient responds RequestResponse with its label, or an error if it's failing.
+type fakeHealthClient struct {
+	fakeDialClient
+	label   string
+	failing *atomic.Bool
+	delay   *atomic.Duration
+}
+
+func newFakeHealthClient(label string) *fakeHealthClient {
+	return &fakeHealthClient{
+		label:   label,
+		failing: atomic.NewBool(false),
+		delay:   atomic.NewDuration(0),
+	}
+}
+
+func (f *fakeHealthClient) RequestResponse(msg payload.Payload) mono.Mono {
+	return mono.Delay(f.delay.Load()).ToMono(func() (payload.Payload, error) {
+		if f.failing.Load() {
+			return nil, errors.New("fake error")
+		}
+		return payload.NewString(f.label, ""), nil
+	})
+}
+
+func requestLabel(ctx context.Context, t *testing.T, b Balancer) (string, error) {
+	c, ok := b.Next(ctx)
+	require.True(t, ok, "get next client failed")
+	res, err := c.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+	if err != nil {
+		return "", err
+	}
+	return res.DataUTF8(), nil
+}
+
+func waitLabel(t *testing.T, ch <-chan string) string {
+	select {
+	case label := <-ch:
+		return label
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "wait for health event timeout")
+		return ""
+	}
+}
+
+func TestHealthCheck_Breaker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ejected := make(chan string, 8)
+	recovered := make(chan string, 8)
+	b := NewRoundRobinBalancer(WithHealthCheck(HealthCheck{
+		MinRequests: 4,
+		ErrorRate:   0.5,
+		Cooldown:    100 * time.Millisecond,
+		OnEject: func(label string, cause error) {
+			assert.Error(t, cause)
+			ejected <- label
+		},
+		OnRecover: func(label string) {
+			recovered <- label
+		},
+	}))
+	defer b.Close()
+
+	bad, good := newFakeHealthClient("bad"), newFakeHealthClient("good")
+	bad.failing.Store(true)
+	require.NoError(t, b.PutLabel("bad", bad))
+	require.NoError(t, b.PutLabel("good", good))
+
+	// bad client will be ejected after 4 failed requests
+	for i := 0; i < 8; i++ {
+		_, _ = requestLabel(ctx, t, b)
+	}
+	assert.Equal(t, "bad", waitLabel(t, ejected))
+	for i := 0; i < 10; i++ {
+		label, err := requestLabel(ctx, t, b)
+		assert.NoError(t, err)
+		assert.Equal(t, "good", label)
+	}
+
+	// failed trial ejects it again
+	time.Sleep(150 * time.Millisecond)
+	var trialErr error
+	for i := 0; i < 2; i++ {
+		if _, err := requestLabel(ctx, t, b); err != nil {
+			trialErr = err
+		}
+	}
+	assert.Error(t, trialErr, "trial request should be sent to bad client")
+	assert.Equal(t, "bad", waitLabel(t, ejected))
+
+	// successful trial recovers it
+	bad.failing.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		label, err := requestLabel(ctx, t, b)
+		assert.NoError(t, err)
+		seen[label] = true
+	}
+	assert.Equal(t, "bad", waitLabel(t, recovered))
+	assert.True(t, seen["bad"])
+	assert.True(t, seen["good"])
+}
+
+func TestHealthCheck_IgnoreNonTrial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ejected := make(chan string, 8)
+	recovered := make(chan string, 8)
+	b := NewRoundRobinBalancer(WithHealthCheck(HealthCheck{
+		MinRequests: 2,
+		ErrorRate:   0.5,
+		Cooldown:    100 * time.Millisecond,
+		OnEject: func(label string, cause error) {
+			ejected <- label
+		},
+		OnRecover: func(label string) {
+			recovered <- label
+		},
+	}))
+	defer b.Close()
+
+	c := newFakeHealthClient("foo")
+	require.NoError(t, b.PutLabel("foo", c))
+
+	// picked before ejection, so it's not a trial.
+	picked, ok := b.Next(ctx)
+	require.True(t, ok)
+
+	c.failing.Store(true)
+	for i := 0; i < 2; i++ {
+		_, _ = requestLabel(ctx, t, b)
+	}
+	assert.Equal(t, "foo", waitLabel(t, ejected))
+
+	c.failing.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	trial, ok := b.Next(ctx)
+	require.True(t, ok)
+
+	_, err := picked.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+	assert.NoError(t, err)
+	select {
+	case <-recovered:
+		assert.Fail(t, "non-trial request should not recover the client")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = trial.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", waitLabel(t, recovered))
+}
+
+// fakeAwareClient implements rsocket.LeaseAware and rsocket.MimeTypeAware.
+type fakeAwareClient struct {
+	fakeDialClient
+}
+
+func (f *fakeAwareClient) RemainingLease() (int64, time.Time, bool) {
+	return 3, time.Time{}, true
+}
+
+func (f *fakeAwareClient) DataMimeType() string {
+	return "application/json"
+}
+
+func (f *fakeAwareClient) MetadataMimeType() string {
+	return "message/x.rsocket.composite-metadata.v0"
+}
+
+func TestHealthCheck_PassThrough(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	b := NewRoundRobinBalancer(WithHealthCheck(HealthCheck{}))
+	defer b.Close()
+	require.NoError(t, b.Put(&fakeAwareClient{}))
+
+	c, ok := b.Next(ctx)
+	require.True(t, ok)
+	leased, ok := c.(rsocket.LeaseAware)
+	require.True(t, ok, "should implement LeaseAware")
+	tickets, _, enabled := leased.RemainingLease()
+	assert.True(t, enabled)
+	assert.Equal(t, int64(3), tickets)
+	aware, ok := c.(rsocket.MimeTypeAware)
+	require.True(t, ok, "should implement MimeTypeAware")
+	assert.Equal(t, "application/json", aware.DataMimeType())
+	assert.Equal(t, "message/x.rsocket.composite-metadata.v0", aware.MetadataMimeType())
+}
+
+func TestHealthCheck_Latency(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ejected := make(chan string, 8)
+	b := NewWeightedBalancer(WithHealthCheck(HealthCheck{
+		MinRequests: 1,
+		Latency:     20 * time.Millisecond,
+		Cooldown:    time.Minute,
+		OnEject: func(label string, cause error) {
+			ejected <- label
+		},
+	}))
+	defer b.Close()
+
+	slow, fast := newFakeHealthClient("slow"), newFakeHealthClient("fast")
+	slow.delay.Store(50 * time.Millisecond)
+	require.NoError(t, b.PutLabel("slow", slow))
+	require.NoError(t, b.PutLabel("fast", fast))
+
+	for len(ejected) < 1 {
+		_, err := requestLabel(ctx, t, b)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "slow", waitLabel(t, ejected))
+	for i := 0; i < 10; i++ {
+		label, err := requestLabel(ctx, t, b)
+		assert.NoError(t, err)
+		assert.Equal(t, "fast", label)
+	}
+}
+
+func TestHealthCheck_Probe(t *testing.T) {
+	ejected := make(chan string, 8)
+	recovered := make(chan string, 8)
+	b := NewRoundRobinBalancer(WithHealthCheck(HealthCheck{
+		Probe:         payload.NewString("health", ""),
+		ProbeInterval: 10 * time.Millisecond,
+		Cooldown:      100 * time.Millisecond,
+		OnEject: func(label string, cause error) {
+			ejected <- label
+		},
+		OnRecover: func(label string) {
+			recovered <- label
+		},
+	}))
+	defer b.Close()
+
+	c := newFakeHealthClient("foo")
+	c.failing.Store(true)
+	require.NoError(t, b.PutLabel("foo", c))
+	assert.Equal(t, "foo", waitLabel(t, ejected))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	_, ok := b.Next(ctx)
+	cancel()
+	assert.False(t, ok, "should not pick ejected client")
+
+	c.failing.Store(false)
+	assert.Equal(t, "foo", waitLabel(t, recovered))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	next, ok := b.Next(ctx)
+	require.True(t, ok)
+	res, err := next.RequestResponse(payload.NewString("ping", "")).Block(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", res.DataUTF8())
+}
+
+var _ rsocket.Client = (*fakeHealthClient)(nil)
@@ -0,0 +1,66 @@
+package balancer
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsocket/rsocket-go"
+)
+
+// _leaseRecheckInterval is the interval of checking leases again when all clients are exhausted.
+const _leaseRecheckInterval = 10 * time.Millisecond
+
+type balancerLease struct {
+	*pool
+	seq uint32
+}
+
+func (b *balancerLease) Put(client rsocket.Client) error {
+	return b.PutLabel(uuid.New().String(), client)
+}
+
+func (b *balancerLease) PutLabel(label string, client rsocket.Client) error {
+	return b.put(label, client, client)
+}
+
+func (b *balancerLease) Next(ctx context.Context) (rsocket.Client, bool) {
+	return b.next(ctx, func(_ []string, sockets []rsocket.Client) (rsocket.Client, bool) {
+		n := len(sockets)
+		now := time.Now()
+		offset := int(atomic.AddUint32(&b.seq, 1) % uint32(n))
+		for i := 0; i < n; i++ {
+			next := sockets[(offset+i)%n]
+			if isLeaseAvailable(next, now) {
+				return next, true
+			}
+		}
+		return nil, false
+	})
+}
+
+// isLeaseAvailable returns true if the client has a valid lease or its lease is disabled.
+func isLeaseAvailable(client rsocket.Client, now time.Time) bool {
+	leased, ok := client.(rsocket.LeaseAware)
+	if !ok {
+		return true
+	}
+	tickets, expiry, enabled := leased.RemainingLease()
+	if !enabled {
+		return true
+	}
+	return tickets > 0 && now.Before(expiry)
+}
+
+// NewLeaseBalancer returns a new Balancer which respects the LEASE frames sent by servers.
+// Clients without any remaining lease are skipped, and the rest are picked in Round-Robin.
+// Clients with lease disabled are always available.
+// Next waits until any lease is refreshed if all clients are exhausted.
+func NewLeaseBalancer(opts ...Option) Balancer {
+	p := newPool(opts...)
+	p.recheck = _leaseRecheckInterval
+	return &balancerLease{
+		pool: p,
+	}
+}
@@ -0,0 +1,145 @@
+package balancer_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/balancer"
+	"github.com/rsocket/rsocket-go/lease"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func startLeaseServer(ctx context.Context, port int, factory lease.Factory, counter *sync.Map, started chan<- struct{}) {
+	sb := rsocket.Receive()
+	if factory != nil {
+		sb = sb.Lease(factory)
+	}
+	_ = sb.
+		OnStart(func() {
+			started <- struct{}{}
+		}).
+		Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+			return rsocket.NewAbstractSocket(
+				rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+					cur, _ := counter.LoadOrStore(port, atomic.NewInt32(0))
+					cur.(*atomic.Int32).Inc()
+					return mono.Just(msg)
+				}),
+			), nil
+		}).
+		Transport(rsocket.TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+		Serve(ctx)
+}
+
+func TestLease(t *testing.T) {
+	const leased, unleased = 7020, 7021
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	factory, err := lease.NewSimpleFactory(time.Minute, time.Minute, 10*time.Millisecond, 2)
+	require.NoError(t, err)
+
+	counter := &sync.Map{}
+	started := make(chan struct{}, 2)
+	go startLeaseServer(ctx, leased, factory, counter, started)
+	go startLeaseServer(ctx, unleased, nil, counter, started)
+	<-started
+	<-started
+
+	leasedClient, err := rsocket.Connect().
+		Lease().
+		Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", leased).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	unleasedClient, err := rsocket.Connect().
+		Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", unleased).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+
+	// wait for the first lease
+	require.Eventually(t, func() bool {
+		tickets, _, _ := leasedClient.(rsocket.LeaseAware).RemainingLease()
+		return tickets > 0
+	}, 3*time.Second, 10*time.Millisecond)
+	tickets, expiry, _ := leasedClient.(rsocket.LeaseAware).RemainingLease()
+	assert.Equal(t, int64(2), tickets)
+	assert.True(t, expiry.After(time.Now()))
+
+	_, _, enabled := unleasedClient.(rsocket.LeaseAware).RemainingLease()
+	assert.False(t, enabled)
+
+	b := NewLeaseBalancer()
+	defer b.Close()
+	require.NoError(t, b.Put(leasedClient))
+	require.NoError(t, b.Put(unleasedClient))
+
+	const total = 10
+	for i := 0; i < total; i++ {
+		next, ok := b.Next(ctx)
+		require.True(t, ok)
+		_, err := next.RequestResponse(payload.NewString("hello", "world")).Block(ctx)
+		require.NoError(t, err)
+	}
+
+	tickets, _, _ = leasedClient.(rsocket.LeaseAware).RemainingLease()
+	assert.Equal(t, int64(0), tickets)
+	cnt, _ := counter.Load(leased)
+	assert.Equal(t, int32(2), cnt.(*atomic.Int32).Load())
+	cnt, _ = counter.Load(unleased)
+	assert.Equal(t, int32(total-2), cnt.(*atomic.Int32).Load())
+}
+
+func TestLease_WaitForLease(t *testing.T) {
+	b := NewLeaseBalancer()
+	defer b.Close()
+	exhausted := &fakeLeaseClient{}
+	require.NoError(t, b.Put(exhausted))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, ok := b.Next(ctx)
+	assert.False(t, ok, "should not pick client without lease")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		exhausted.grant(1, time.Minute)
+	}()
+	ctx, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	next, ok := b.Next(ctx)
+	assert.True(t, ok, "should pick client after lease refreshed")
+	assert.Equal(t, exhausted, next)
+}
+
+type fakeLeaseClient struct {
+	rsocket.Client
+	mu      sync.Mutex
+	tickets int64
+	expiry  time.Time
+}
+
+func (f *fakeLeaseClient) grant(tickets int64, ttl time.Duration) {
+	f.mu.Lock()
+	f.tickets, f.expiry = tickets, time.Now().Add(ttl)
+	f.mu.Unlock()
+}
+
+func (f *fakeLeaseClient) RemainingLease() (int64, time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tickets, f.expiry, true
+}
+
+func (f *fakeLeaseClient) OnClose(func(error)) {
+}
+
+func (f *fakeLeaseClient) Close() error {
+	return nil
+}
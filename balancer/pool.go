@@ -0,0 +1,202 @@
+package balancer
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+var errConflictSocket = errors.New("socket exists already")
+
+// pool keeps labeled clients, it's the common part of balancers.
+type pool struct {
+	mu      sync.RWMutex
+	keys    []string
+	sockets []rsocket.Client
+	done    chan struct{}
+	once    sync.Once
+	onLeave []func(string)
+	c       *common.Cond
+	// recheck is the interval of picking again when no client is available, zero means waiting for new clients.
+	recheck time.Duration
+	// health enables health checking, checkers are kept in the same order as sockets.
+	health   *HealthCheck
+	checkers []*checker
+}
+
+func newPool(opts ...Option) *pool {
+	p := &pool{
+		done: make(chan struct{}),
+	}
+	p.c = common.NewCond(p.mu.RLocker())
+	for _, fn := range opts {
+		fn(p)
+	}
+	return p
+}
+
+func (p *pool) OnLeave(fn func(label string)) {
+	if fn != nil {
+		p.onLeave = append(p.onLeave, fn)
+	}
+}
+
+func (p *pool) Len() int {
+	p.mu.RLock()
+	l := len(p.sockets)
+	p.mu.RUnlock()
+	return l
+}
+
+// put adds a client with label, the client will be removed once the origin client is closed.
+func (p *pool) put(label string, client rsocket.Client, origin rsocket.Client) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if k == label {
+			return errConflictSocket
+		}
+	}
+	p.keys = append(p.keys, label)
+	p.sockets = append(p.sockets, client)
+	if p.health != nil {
+		ck := newChecker(label, client, p.health)
+		p.checkers = append(p.checkers, ck)
+		ck.start()
+	}
+	if n := len(p.sockets); n == 1 {
+		p.c.Broadcast()
+	}
+	origin.OnClose(func(err error) {
+		p.remove(client)
+	})
+	return nil
+}
+
+// next waits until pick returns a client or the context is done.
+// pick will be called with the read lock held.
+func (p *pool) next(ctx context.Context, pick func(keys []string, sockets []rsocket.Client) (rsocket.Client, bool)) (client rsocket.Client, ok bool) {
+	p.mu.RLock()
+	for {
+		if len(p.keys) > 0 {
+			if client, ok = p.pick(pick); ok {
+				break
+			}
+		}
+		if p.wait(ctx) {
+			break
+		}
+
+		p.mu.RUnlock()
+		runtime.Gosched()
+		p.mu.RLock()
+	}
+	p.mu.RUnlock()
+	return
+}
+
+// pick skips unhealthy clients if health checking is enabled.
+func (p *pool) pick(pick func(keys []string, sockets []rsocket.Client) (rsocket.Client, bool)) (rsocket.Client, bool) {
+	if p.health == nil {
+		return pick(p.keys, p.sockets)
+	}
+	now := time.Now()
+	keys := make([]string, 0, len(p.keys))
+	sockets := make([]rsocket.Client, 0, len(p.sockets))
+	checkers := make([]*checker, 0, len(p.checkers))
+	for i, ck := range p.checkers {
+		if ck.available(now) {
+			keys = append(keys, p.keys[i])
+			sockets = append(sockets, p.sockets[i])
+			checkers = append(checkers, ck)
+		}
+	}
+	if len(keys) < 1 {
+		return nil, false
+	}
+	client, ok := pick(keys, sockets)
+	if !ok {
+		return nil, false
+	}
+	for i := range sockets {
+		if sockets[i] == client {
+			epoch, trial := checkers[i].acquire(now)
+			return &checkedClient{Client: client, c: checkers[i], trial: trial, epoch: epoch}, true
+		}
+	}
+	return client, true
+}
+
+// wait waits for new clients, it returns true if the context is done.
+func (p *pool) wait(ctx context.Context) bool {
+	if p.recheck <= 0 || len(p.keys) < 1 {
+		return p.c.Wait(ctx)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, p.recheck)
+	defer cancel()
+	p.c.Wait(waitCtx)
+	return ctx.Err() != nil
+}
+
+func (p *pool) Close() (err error) {
+	p.once.Do(func() {
+		p.mu.RLock()
+		clone := append([]rsocket.Client(nil), p.sockets...)
+		for _, ck := range p.checkers {
+			ck.stop()
+		}
+		p.mu.RUnlock()
+		if len(clone) < 1 {
+			return
+		}
+		close(p.done)
+		wg := &sync.WaitGroup{}
+		wg.Add(len(clone))
+		for i := 0; i < len(clone); i++ {
+			go func(c rsocket.Client, wg *sync.WaitGroup) {
+				defer wg.Done()
+				if err := c.Close(); err != nil {
+					logger.Warnf("close client failed: %s\n", err)
+				}
+			}(clone[i], wg)
+		}
+		wg.Wait()
+	})
+	return
+}
+
+func (p *pool) remove(client rsocket.Client) (label string, ok bool) {
+	p.mu.Lock()
+	j := -1
+	for i, l := 0, len(p.sockets); i < l; i++ {
+		if p.sockets[i] == client {
+			j = i
+			break
+		}
+	}
+	ok = j > -1
+	if ok {
+		label = p.keys[j]
+		p.keys = append(p.keys[:j], p.keys[j+1:]...)
+		p.sockets = append(p.sockets[:j], p.sockets[j+1:]...)
+		if p.health != nil {
+			p.checkers[j].stop()
+			p.checkers = append(p.checkers[:j], p.checkers[j+1:]...)
+		}
+	}
+	p.mu.Unlock()
+	if ok && len(p.onLeave) > 0 {
+		go func(label string) {
+			for _, fn := range p.onLeave {
+				fn(label)
+			}
+		}(label)
+	}
+	return
+}
@@ -0,0 +1,159 @@
+package balancer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+// Dialer creates a client which connects to the address.
+type Dialer = func(ctx context.Context, addr string) (rsocket.Client, error)
+
+// syncer dials and closes clients of a Balancer by the events of discovery.
+type syncer struct {
+	b       Balancer
+	dialer  Dialer
+	backoff rsocket.Backoff
+	mu      sync.Mutex
+	targets map[string]*target
+}
+
+// target is a discovered address, the client will be redialed until the target is removed.
+type target struct {
+	label  string
+	addr   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	client rsocket.Client
+}
+
+func newSyncer(b Balancer, dialer Dialer, backoff rsocket.Backoff) *syncer {
+	if backoff == nil {
+		backoff = rsocket.DefaultBackoff()
+	}
+	return &syncer{
+		b:       b,
+		dialer:  dialer,
+		backoff: backoff,
+		targets: make(map[string]*target),
+	}
+}
+
+func (s *syncer) run(ctx context.Context, events <-chan Event) {
+	defer s.removeAll()
+	for e := range events {
+		switch e.Type {
+		case EventAdd:
+			s.add(ctx, e.Label, e.Addr)
+		case EventRemove:
+			s.remove(e.Label)
+		}
+	}
+}
+
+func (s *syncer) add(ctx context.Context, label, addr string) {
+	s.mu.Lock()
+	if exist, ok := s.targets[label]; ok {
+		if exist.addr == addr {
+			s.mu.Unlock()
+			return
+		}
+		delete(s.targets, label)
+		exist.stop()
+	}
+	t := &target{
+		label: label,
+		addr:  addr,
+	}
+	t.ctx, t.cancel = context.WithCancel(ctx)
+	s.targets[label] = t
+	s.mu.Unlock()
+	go s.connect(t)
+}
+
+func (s *syncer) remove(label string) {
+	s.mu.Lock()
+	t, ok := s.targets[label]
+	if ok {
+		delete(s.targets, label)
+	}
+	s.mu.Unlock()
+	if ok {
+		t.stop()
+	}
+}
+
+func (s *syncer) removeAll() {
+	s.mu.Lock()
+	targets := s.targets
+	s.targets = make(map[string]*target)
+	s.mu.Unlock()
+	for _, t := range targets {
+		t.stop()
+	}
+}
+
+// connect dials the target until success or the target is removed.
+func (s *syncer) connect(t *target) {
+	for attempt := 1; ; attempt++ {
+		client, err := s.dialer(t.ctx, t.addr)
+		if err == nil {
+			// the label may still be occupied by the closed client, try again later
+			if err = s.bind(t, client); err == nil {
+				return
+			}
+		}
+		logger.Warnf("connect %s failed: %v\n", t.addr, err)
+		timer := time.NewTimer(s.backoff.Next(attempt))
+		select {
+		case <-t.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// bind puts the client into balancer, and redials once the client is closed unexpectedly.
+func (s *syncer) bind(t *target, client rsocket.Client) error {
+	t.mu.Lock()
+	if t.ctx.Err() != nil {
+		t.mu.Unlock()
+		_ = client.Close()
+		return nil
+	}
+	t.client = client
+	t.mu.Unlock()
+
+	if err := s.b.PutLabel(t.label, client); err != nil {
+		t.mu.Lock()
+		t.client = nil
+		t.mu.Unlock()
+		_ = client.Close()
+		return err
+	}
+	client.OnClose(func(error) {
+		if t.ctx.Err() != nil {
+			return
+		}
+		logger.Warnf("client %s closed, start redialing %s\n", t.label, t.addr)
+		go s.connect(t)
+	})
+	return nil
+}
+
+// stop stops redialing and closes current client.
+func (t *target) stop() {
+	t.mu.Lock()
+	t.cancel()
+	client := t.client
+	t.client = nil
+	t.mu.Unlock()
+	if client != nil {
+		_ = client.Close()
+	}
+}
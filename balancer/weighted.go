@@ -0,0 +1,183 @@
+package balancer
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"go.uber.org/atomic"
+)
+
+// _weightedHalfLife is the half-life of latency samples.
+const _weightedHalfLife = 5 * time.Second
+
+var _ rsocket.Client = (*weightedClient)(nil)
+
+// ewma is an exponentially weighted moving average which decays with time.
+type ewma struct {
+	mu    sync.Mutex
+	tau   float64
+	value float64
+	stamp time.Time
+}
+
+func newEwma(halfLife time.Duration) *ewma {
+	return &ewma{
+		tau: float64(halfLife) / math.Ln2,
+	}
+}
+
+func (e *ewma) insert(x float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	if e.stamp.IsZero() {
+		e.value = x
+	} else {
+		w := math.Exp(-float64(now.Sub(e.stamp)) / e.tau)
+		e.value = w*e.value + (1-w)*x
+	}
+	e.stamp = now
+}
+
+func (e *ewma) get() (v float64) {
+	e.mu.Lock()
+	v = e.value
+	e.mu.Unlock()
+	return
+}
+
+// weightedClient records the latency and the outstanding requests of a client.
+type weightedClient struct {
+	rsocket.Client
+	latency     *ewma
+	outstanding *atomic.Int64
+}
+
+func newWeightedClient(client rsocket.Client) *weightedClient {
+	return &weightedClient{
+		Client:      client,
+		latency:     newEwma(_weightedHalfLife),
+		outstanding: atomic.NewInt64(0),
+	}
+}
+
+// cost returns the predicted cost of next request, lower is better.
+func (w *weightedClient) cost() float64 {
+	latency := w.latency.get() / float64(time.Microsecond)
+	return (latency + 1) * float64(w.outstanding.Load()+1)
+}
+
+func (w *weightedClient) start(start *atomic.Int64) {
+	w.outstanding.Inc()
+	start.Store(time.Now().UnixNano())
+}
+
+func (w *weightedClient) record(start *atomic.Int64) {
+	w.latency.insert(float64(time.Now().UnixNano() - start.Load()))
+}
+
+func (w *weightedClient) RemainingLease() (tickets int64, expiry time.Time, enabled bool) {
+	if leased, ok := w.Client.(rsocket.LeaseAware); ok {
+		return leased.RemainingLease()
+	}
+	return
+}
+
+func (w *weightedClient) RequestResponse(message payload.Payload) mono.Mono {
+	start := atomic.NewInt64(0)
+	return w.Client.RequestResponse(message).
+		DoOnSubscribe(func(ctx context.Context, s rx.Subscription) {
+			w.start(start)
+		}).
+		DoFinally(func(s rx.SignalType) {
+			w.outstanding.Dec()
+			if s != rx.SignalCancel {
+				w.record(start)
+			}
+		})
+}
+
+func (w *weightedClient) RequestStream(message payload.Payload) flux.Flux {
+	return w.observe(w.Client.RequestStream(message))
+}
+
+func (w *weightedClient) RequestChannel(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+	return w.observe(w.Client.RequestChannel(initialMessage, messages))
+}
+
+// observe records the latency of the first signal of a stream.
+func (w *weightedClient) observe(f flux.Flux) flux.Flux {
+	var (
+		start = atomic.NewInt64(0)
+		first = atomic.NewBool(false)
+	)
+	fire := func() {
+		if first.CAS(false, true) {
+			w.record(start)
+		}
+	}
+	return f.
+		DoOnSubscribe(func(ctx context.Context, s rx.Subscription) {
+			w.start(start)
+		}).
+		DoOnNext(func(input payload.Payload) error {
+			fire()
+			return nil
+		}).
+		DoFinally(func(s rx.SignalType) {
+			w.outstanding.Dec()
+			if s != rx.SignalCancel {
+				fire()
+			}
+		})
+}
+
+type balancerWeighted struct {
+	*pool
+}
+
+func (b *balancerWeighted) Put(client rsocket.Client) error {
+	return b.PutLabel(uuid.New().String(), client)
+}
+
+func (b *balancerWeighted) PutLabel(label string, client rsocket.Client) error {
+	return b.put(label, newWeightedClient(client), client)
+}
+
+func (b *balancerWeighted) Next(ctx context.Context) (rsocket.Client, bool) {
+	return b.next(ctx, func(_ []string, sockets []rsocket.Client) (rsocket.Client, bool) {
+		n := len(sockets)
+		if n == 1 {
+			return sockets[0], true
+		}
+		// power of two choices
+		i := common.RandIntn(n)
+		j := common.RandIntn(n - 1)
+		if j >= i {
+			j++
+		}
+		first, second := sockets[i].(*weightedClient), sockets[j].(*weightedClient)
+		if second.cost() < first.cost() {
+			return second, true
+		}
+		return first, true
+	})
+}
+
+// NewWeightedBalancer returns a new Balancer which prefers the least loaded client.
+// It tracks the latency of each client as an EWMA and counts the outstanding requests,
+// then picks the cheaper one of two random clients (power of two choices).
+func NewWeightedBalancer(opts ...Option) Balancer {
+	return &balancerWeighted{
+		pool: newPool(opts...),
+	}
+}
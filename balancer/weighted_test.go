@@ -0,0 +1,106 @@
+package balancer_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/balancer"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func startDelayServer(ctx context.Context, port int, delay time.Duration, counter *sync.Map, started chan<- struct{}) {
+	_ = rsocket.Receive().
+		OnStart(func() {
+			started <- struct{}{}
+		}).
+		Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+			return rsocket.NewAbstractSocket(
+				rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+					cur, _ := counter.LoadOrStore(port, atomic.NewInt32(0))
+					cur.(*atomic.Int32).Inc()
+					return mono.Delay(delay).ToMono(func() (payload.Payload, error) {
+						return payload.Clone(msg), nil
+					})
+				}),
+			), nil
+		}).
+		Transport(rsocket.TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+		Serve(ctx)
+}
+
+func TestWeighted(t *testing.T) {
+	const slow, fast = 7010, 7011
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	counter := &sync.Map{}
+	started := make(chan struct{}, 2)
+	go startDelayServer(ctx, slow, 50*time.Millisecond, counter, started)
+	go startDelayServer(ctx, fast, 0, counter, started)
+	<-started
+	<-started
+
+	b := NewWeightedBalancer()
+	defer b.Close()
+
+	left := make(chan string, 2)
+	b.OnLeave(func(label string) {
+		left <- label
+	})
+
+	clients := make(map[int]rsocket.Client)
+	for _, port := range []int{slow, fast} {
+		client, err := rsocket.Connect().
+			Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+			Start(ctx)
+		require.NoError(t, err)
+		clients[port] = client
+		assert.NoError(t, b.PutLabel(fmt.Sprintf("%d", port), client))
+	}
+	assert.Equal(t, 2, b.Len())
+	assert.Error(t, b.PutLabel(fmt.Sprintf("%d", fast), clients[fast]), "should reject duplicated label")
+
+	req := payload.NewString("foo", "bar")
+	const n = 50
+	for i := 0; i < n; i++ {
+		c, ok := b.Next(ctx)
+		require.True(t, ok)
+		res, err := c.RequestResponse(req).Block(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", res.DataUTF8())
+	}
+
+	count := func(port int) int32 {
+		v, ok := counter.Load(port)
+		if !ok {
+			return 0
+		}
+		return v.(*atomic.Int32).Load()
+	}
+	assert.Equal(t, int32(n), count(slow)+count(fast))
+	assert.Greater(t, count(fast), 4*count(slow), "fast server should serve most requests")
+
+	// remove client after closing
+	_ = clients[fast].Close()
+	select {
+	case label := <-left:
+		assert.Equal(t, fmt.Sprintf("%d", fast), label)
+	case <-time.After(time.Second):
+		assert.Fail(t, "client should leave")
+	}
+	assert.Equal(t, 1, b.Len())
+	c, ok := b.Next(ctx)
+	require.True(t, ok)
+	before := count(slow)
+	_, err := c.RequestResponse(req).Block(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, count(slow))
+}
@@ -7,11 +7,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jjeffcaii/reactor-go/scheduler"
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/compression"
 	"github.com/rsocket/rsocket-go/core"
 	"github.com/rsocket/rsocket-go/core/transport"
 	"github.com/rsocket/rsocket-go/internal/common"
 	"github.com/rsocket/rsocket-go/internal/fragmentation"
 	"github.com/rsocket/rsocket-go/internal/socket"
+	"github.com/rsocket/rsocket-go/metrics"
 	"github.com/rsocket/rsocket-go/payload"
 )
 
@@ -30,6 +33,22 @@ type Client interface {
 	CloseableRSocket
 }
 
+// LeaseAware can report the lease sent by server, all clients created by ClientBuilder implement it.
+// The lease is only available when ClientBuilder.Lease is enabled.
+type LeaseAware interface {
+	// RemainingLease returns the remaining requests and the expiry of current lease.
+	// Both of them are zero if no lease has been received yet, and enabled is false if lease is disabled.
+	RemainingLease() (tickets int64, expiry time.Time, enabled bool)
+}
+
+// MimeTypeAware can report the MIME types sent in SETUP, all clients created by ClientBuilder implement it.
+type MimeTypeAware interface {
+	// DataMimeType returns the MIME type of data.
+	DataMimeType() string
+	// MetadataMimeType returns the MIME type of metadata.
+	MetadataMimeType() string
+}
+
 // ClientSocketAcceptor is alias for RSocket handler function.
 type ClientSocketAcceptor = func(ctx context.Context, socket RSocket) RSocket
 
@@ -52,6 +71,10 @@ type ClientBuilder interface {
 	KeepAlive(tickPeriod, ackTimeout time.Duration, missedAcks int) ClientBuilder
 	// Resume enable the functionality of resume.
 	Resume(opts ...ClientResumeOptions) ClientBuilder
+	// Reconnect enable the functionality of reconnecting for a non-resumable client.
+	// SETUP will be sent by a new transport after the connection is lost, and in-flight requests will fail with
+	// core.ErrConnectionLost. It will be ignored if resume is enabled.
+	Reconnect(policy ReconnectPolicy) ClientBuilder
 	// Lease enable the functionality of lease.
 	Lease() ClientBuilder
 	// DataMimeType is used to set payload data MIME type.
@@ -68,6 +91,16 @@ type ClientBuilder interface {
 	OnClose(func(error)) ClientBuilder
 	// OnConnect register handler when client socket connected.
 	OnConnect(func(Client, error)) ClientBuilder
+	// Interceptors adds interceptors of requester, responder and connection.
+	// The started client is intercepted by requester interceptors, and the responder returned by acceptor is
+	// intercepted by responder interceptors. Connection interceptors are called once after the client is started.
+	Interceptors(opts ...InterceptorOption) ClientBuilder
+	// Metrics enables recording the metrics of connection, the connection label is the address of server.
+	Metrics(m *metrics.Metrics) ClientBuilder
+	// Compression requests the payload compression with the registered algorithm in SETUP, eg: compression.Gzip.
+	// The payload data larger than threshold will be compressed, zero threshold means compression.DefaultThreshold.
+	// It requires the composite metadata MIME type, and the server rejects the SETUP if it doesn't support the algorithm.
+	Compression(name string, threshold int) ClientBuilder
 	// Acceptor set acceptor for RSocket client.
 	Acceptor(acceptor ClientSocketAcceptor) ToClientStarter
 }
@@ -97,6 +130,7 @@ var (
 type clientBuilder struct {
 	reqSche, resSche scheduler.Scheduler
 	resume           *resumeOpts
+	reconnect        *ReconnectPolicy
 	fragment         int
 	tpGen            transport.ClientTransporter
 	setup            *socket.SetupInfo
@@ -104,6 +138,11 @@ type clientBuilder struct {
 	onCloses         []func(error)
 	onConnects       []func(Client, error)
 	connectTimeout   time.Duration
+	interceptors     *interceptorChains
+	metrics          *metrics.Metrics
+	compression      string
+	compressor       compression.Compressor
+	compressLimit    int
 }
 
 func (cb *clientBuilder) Scheduler(req, res scheduler.Scheduler) ClientBuilder {
@@ -127,6 +166,11 @@ func (cb *clientBuilder) Resume(opts ...ClientResumeOptions) ClientBuilder {
 	return cb
 }
 
+func (cb *clientBuilder) Reconnect(policy ReconnectPolicy) ClientBuilder {
+	cb.reconnect = &policy
+	return cb
+}
+
 func (cb *clientBuilder) Fragment(mtu int) ClientBuilder {
 	if mtu == 0 {
 		cb.fragment = fragmentation.MaxFragment
@@ -182,6 +226,74 @@ func (cb *clientBuilder) ConnectTimeout(timeout time.Duration) ClientBuilder {
 	return cb
 }
 
+func (cb *clientBuilder) Interceptors(opts ...InterceptorOption) ClientBuilder {
+	cb.interceptors = newInterceptorChains(cb.interceptors, opts)
+	return cb
+}
+
+func (cb *clientBuilder) Metrics(m *metrics.Metrics) ClientBuilder {
+	cb.metrics = m
+	return cb
+}
+
+func (cb *clientBuilder) Compression(name string, threshold int) ClientBuilder {
+	cb.compression = name
+	cb.compressLimit = threshold
+	return cb
+}
+
+// prepareCompression requests the compression in the metadata of SETUP.
+func (cb *clientBuilder) prepareCompression() (err error) {
+	if cb.compression == "" {
+		return
+	}
+	c, ok := compression.Lookup(cb.compression)
+	if !ok {
+		err = errors.Errorf("rsocket: no such compression: %s", cb.compression)
+		return
+	}
+	metadata, err := compression.AppendSetupMetadata(string(cb.setup.MetadataMimeType), cb.setup.Metadata, c)
+	if err != nil {
+		err = errors.Wrap(err, "rsocket: request compression failed")
+		return
+	}
+	cb.setup.Metadata = metadata
+	cb.compressor = c
+	return
+}
+
+// newConnection creates a new client-side duplex connection.
+func (cb *clientBuilder) newConnection(ctx context.Context) *socket.DuplexConnection {
+	conn := socket.NewClientDuplexConnection(
+		ctx,
+		cb.reqSche,
+		cb.resSche,
+		cb.fragment,
+		cb.setup.KeepaliveInterval,
+	)
+	if cb.compressor != nil {
+		conn.SetCompression(cb.compressor, cb.compressLimit)
+	}
+	return conn
+}
+
+// responder creates the responder with interceptors.
+func (cb *clientBuilder) responder(ctx context.Context, interceptors *interceptorChains, requester RSocket) RSocket {
+	if cb.acceptor == nil {
+		return _noopSocket
+	}
+	return interceptors.wrapResponder(cb.acceptor(ctx, requester))
+}
+
+// connect calls connection interceptors with the started client, the client will be closed if any of them fails.
+func (cb *clientBuilder) connect(ctx context.Context, client Client) (Client, error) {
+	if err := cb.interceptors.connect(ctx, cb.setup.Payload(), client); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
 func (cb *clientBuilder) Acceptor(acceptor ClientSocketAcceptor) ToClientStarter {
 	cb.acceptor = acceptor
 	return cb
@@ -202,26 +314,52 @@ func (cb *clientBuilder) Start(ctx context.Context) (client Client, err error) {
 		return
 	}
 
-	conn := socket.NewClientDuplexConnection(
-		ctx,
-		cb.reqSche,
-		cb.resSche,
-		cb.fragment,
-		cb.setup.KeepaliveInterval,
-	)
+	if err = cb.prepareCompression(); err != nil {
+		return
+	}
+
+	cm := newConnectionMetrics(cb.metrics, string(cb.setup.MetadataMimeType))
+
+	if cb.reconnect != nil && cb.resume == nil {
+		rc := newReconnectClient(cb, *cb.reconnect, cm)
+		for _, closer := range cb.onCloses {
+			rc.OnClose(closer)
+		}
+		if cm != nil {
+			rc.OnClose(cm.close)
+		}
+		// requesters and OnConnect handlers should see the intercepted client.
+		rc.self = rc.interceptors.wrapClient(rc)
+		if err = rc.start(ctx); err != nil {
+			return
+		}
+		return cb.connect(ctx, rc.self)
+	}
+
+	conn := cb.newConnection(ctx)
 	// create a client.
 	var cs setupClientSocket
+	tpGen := cm.transporter(cb.tpGen)
 	if cb.resume != nil {
 		cb.setup.Token = cb.resume.tokenGen()
-		cs = socket.NewResumableClientSocket(cb.tpGen, conn)
-	} else {
-		cs = socket.NewClient(cb.tpGen, conn)
-	}
-	if cb.acceptor != nil {
-		conn.SetResponder(cb.acceptor(ctx, cs))
+		opts := cb.resume.toSocketOptions()
+		if cm != nil {
+			onAttempt := opts.OnAttempt
+			opts.OnAttempt = func(attempt int) {
+				cm.resumeAttempt()
+				if onAttempt != nil {
+					onAttempt(attempt)
+				}
+			}
+		}
+		cs = socket.NewResumableClientSocket(tpGen, conn, opts)
+		conn.SetResumeBufferSize(cb.resume.bufferSize)
 	} else {
-		conn.SetResponder(_noopSocket)
+		cs = socket.NewClient(tpGen, conn)
 	}
+	interceptors := cm.intercept(cb.interceptors)
+	intercepted := interceptors.wrapClient(cs)
+	conn.SetResponder(cb.responder(ctx, interceptors, intercepted))
 
 	// bind closers.
 	if len(cb.onCloses) > 0 {
@@ -229,6 +367,9 @@ func (cb *clientBuilder) Start(ctx context.Context) (client Client, err error) {
 			cs.OnClose(closer)
 		}
 	}
+	if cm != nil {
+		cs.OnClose(cm.close)
+	}
 
 	// setup client.
 	err = cs.Setup(ctx, cb.connectTimeout, cb.setup)
@@ -238,7 +379,9 @@ func (cb *clientBuilder) Start(ctx context.Context) (client Client, err error) {
 		return
 	}
 
-	client = cs
+	if client, err = cb.connect(ctx, intercepted); err != nil {
+		return
+	}
 
 	// trigger OnConnect
 	if len(cb.onConnects) > 0 {
@@ -255,15 +398,59 @@ func (cb *clientBuilder) Start(ctx context.Context) (client Client, err error) {
 }
 
 type resumeOpts struct {
-	tokenGen func() []byte
+	tokenGen    func() []byte
+	bufferSize  int
+	backoff     Backoff
+	maxAttempts int
+	maxWindow   time.Duration
+	timeout     time.Duration
+	onAttempt   []func(attempt int)
+	onResumed   []func(attempt int)
+	onRejected  []func(err error)
 }
 
 func newResumeOpts() *resumeOpts {
 	return &resumeOpts{
-		tokenGen: getPresetResumeTokenGen,
+		tokenGen:   getPresetResumeTokenGen,
+		bufferSize: socket.DefaultResumeBufferSize,
+		backoff:    DefaultBackoff(),
 	}
 }
 
+func (r *resumeOpts) toSocketOptions() *socket.ResumeOptions {
+	opts := &socket.ResumeOptions{
+		Backoff:     r.backoff.Next,
+		MaxAttempts: r.maxAttempts,
+		MaxWindow:   r.maxWindow,
+		Timeout:     r.timeout,
+	}
+	if len(r.onAttempt) > 0 {
+		onAttempt := r.onAttempt
+		opts.OnAttempt = func(attempt int) {
+			for _, fn := range onAttempt {
+				fn(attempt)
+			}
+		}
+	}
+	if len(r.onResumed) > 0 {
+		onResumed := r.onResumed
+		opts.OnResumed = func(attempt int) {
+			for _, fn := range onResumed {
+				fn(attempt)
+			}
+		}
+	}
+	if len(r.onRejected) > 0 {
+		onRejected := r.onRejected
+		opts.OnRejected = func(err error) {
+			for _, fn := range onRejected {
+				fn(err)
+			}
+		}
+	}
+	return opts
+}
+
 func getPresetResumeTokenGen() (token []byte) {
 	token, _ = uuid.New().MarshalBinary()
 	return
@@ -276,6 +463,75 @@ func WithClientResumeToken(gen func() []byte) ClientResumeOptions {
 	}
 }
 
+// WithClientResumeBufferSize sets the max bytes of sent frames which will be kept for resume.
+// Resume will be rejected if frames which have not been received by server were discarded.
+func WithClientResumeBufferSize(size int) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.bufferSize = size
+	}
+}
+
+// WithClientResumeBackoff sets the backoff strategy of reconnecting after the connection is lost.
+// Default is an exponential backoff starting from 1s to 30s with jitter.
+func WithClientResumeBackoff(backoff Backoff) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		if backoff != nil {
+			opts.backoff = backoff
+		}
+	}
+}
+
+// WithClientResumeMaxAttempts sets the max number of reconnect attempts after the connection is lost.
+// Client will be closed once all attempts failed. Zero means no limit.
+func WithClientResumeMaxAttempts(n int) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.maxAttempts = n
+	}
+}
+
+// WithClientResumeWindow sets the max duration of reconnecting since the connection is lost.
+// Client will be closed if the session cannot be resumed in time. Zero means no limit.
+func WithClientResumeWindow(window time.Duration) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.maxWindow = window
+	}
+}
+
+// WithClientResumeTimeout sets the timeout of waiting for RESUME_OK after sending RESUME.
+func WithClientResumeTimeout(timeout time.Duration) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.timeout = timeout
+	}
+}
+
+// WithClientResumeOnAttempt registers handler which will be called before each reconnect attempt.
+func WithClientResumeOnAttempt(fn func(attempt int)) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		if fn != nil {
+			opts.onAttempt = append(opts.onAttempt, fn)
+		}
+	}
+}
+
+// WithClientResumeOnSuccess registers handler which will be called after the session is resumed.
+func WithClientResumeOnSuccess(fn func(attempt int)) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		if fn != nil {
+			opts.onResumed = append(opts.onResumed, fn)
+		}
+	}
+}
+
+// WithClientResumeOnRejected registers handler which will be called when the server rejects resuming.
+// Client will be closed after a rejection.
+func WithClientResumeOnRejected(fn func(err error)) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		if fn != nil {
+			opts.onRejected = append(opts.onRejected, fn)
+		}
+	}
+}
+
 // Connect create a new RSocket client builder with default settings.
 func Connect() ClientBuilder {
 	return &clientBuilder{
@@ -0,0 +1,86 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// Built-in codecs.
+var (
+	// JSON encodes values as JSON.
+	JSON Codec = jsonCodec{}
+	// CBOR encodes values as CBOR.
+	CBOR Codec = cborCodec{}
+	// Protobuf encodes values which implement proto.Message.
+	Protobuf Codec = protobufCodec{}
+	// Raw passes bytes and strings through.
+	Raw Codec = rawCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type cborCodec struct{}
+
+func (cborCodec) Encode(v interface{}) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (cborCodec) Decode(data []byte, v interface{}) error {
+	return cbor.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Encode(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec cannot encode %T", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Decode(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf codec cannot decode into %T", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Encode(v interface{}) ([]byte, error) {
+	switch it := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return it, nil
+	case string:
+		return []byte(it), nil
+	default:
+		return nil, fmt.Errorf("raw codec cannot encode %T", v)
+	}
+}
+
+func (rawCodec) Decode(data []byte, v interface{}) error {
+	switch it := v.(type) {
+	case *[]byte:
+		*it = append((*it)[:0], data...)
+	case *string:
+		*it = string(data)
+	default:
+		return fmt.Errorf("raw codec cannot decode into %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Package codec encodes and decodes the data of payloads by MIME types.
+package codec
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+// DefaultRegistry is the Registry with built-in codecs.
+var DefaultRegistry = NewRegistry()
+
+// Codec encodes values into payload data and decodes them back.
+type Codec interface {
+	// Encode encodes a value.
+	Encode(v interface{}) ([]byte, error)
+	// Decode decodes data into the value pointed to by v.
+	Decode(data []byte, v interface{}) error
+}
+
+// Selection is the codecs selected for a request.
+type Selection struct {
+	// Request decodes the request data.
+	Request Codec
+	// Response encodes the response data.
+	Response Codec
+	// ResponseMimeType is the MIME type of response data if it's different from the request one,
+	// it should be sent as the MessageMimeType entry of response metadata.
+	ResponseMimeType string
+}
+
+// ResponseMetadata returns the metadata of responses, it's nil if the MIME type of response data is not changed.
+func (s Selection) ResponseMetadata() ([]byte, error) {
+	if len(s.ResponseMimeType) < 1 {
+		return nil, nil
+	}
+	raw, err := extension.EncodeMimeType(s.ResponseMimeType)
+	if err != nil {
+		return nil, err
+	}
+	return extension.NewCompositeMetadataBuilder().PushWellKnown(extension.MessageMimeType, raw).Build()
+}
+
+// Selector selects the codecs of a request.
+type Selector func(msg payload.Payload) (Selection, error)
+
+// Registry maps MIME types to codecs.
+type Registry struct {
+	mu     sync.RWMutex
+	codecs map[string]Codec
+}
+
+// NewRegistry creates a new Registry with built-in codecs:
+//   - JSON: application/json
+//   - CBOR: application/cbor
+//   - Protobuf: application/vnd.google.protobuf, application/protobuf and application/x-protobuf
+//   - Raw: application/octet-stream, application/binary and text/plain
+func NewRegistry() *Registry {
+	r := &Registry{
+		codecs: make(map[string]Codec),
+	}
+	r.RegisterMIME(extension.ApplicationJSON, JSON)
+	r.RegisterMIME(extension.ApplicationCBOR, CBOR)
+	r.RegisterMIME(extension.ApplicationProtobuf, Protobuf)
+	r.Register("application/protobuf", Protobuf)
+	r.Register("application/x-protobuf", Protobuf)
+	r.RegisterMIME(extension.ApplicationOctetStream, Raw)
+	r.RegisterMIME(extension.TextPlain, Raw)
+	r.Register("application/binary", Raw)
+	return r
+}
+
+// Register registers a Codec of the MIME type, it replaces the existing one.
+func (r *Registry) Register(mimeType string, codec Codec) {
+	r.mu.Lock()
+	r.codecs[mimeType] = codec
+	r.mu.Unlock()
+}
+
+// RegisterMIME registers a Codec of the well-known MIME type, it replaces the existing one.
+func (r *Registry) RegisterMIME(mimeType extension.MIME, codec Codec) {
+	r.Register(mimeType.String(), codec)
+}
+
+// Get returns the Codec of the MIME type.
+func (r *Registry) Get(mimeType string) (codec Codec, ok bool) {
+	r.mu.RLock()
+	codec, ok = r.codecs[mimeType]
+	r.mu.RUnlock()
+	return
+}
+
+// GetMIME returns the Codec of the well-known MIME type.
+func (r *Registry) GetMIME(mimeType extension.MIME) (Codec, bool) {
+	return r.Get(mimeType.String())
+}
+
+// Of returns the Codec of a message, the MIME type is read from the MessageMimeType entry of
+// CompositeMetadata if present, otherwise the DataMimeType of setup is used.
+func (r *Registry) Of(setup payload.SetupPayload, msg payload.Payload) (Codec, error) {
+	mimeType, err := DataMimeType(setup, msg)
+	if err != nil {
+		return nil, err
+	}
+	codec, ok := r.Get(mimeType)
+	if !ok {
+		return nil, errors.Errorf("no codec for MIME type %q", mimeType)
+	}
+	return codec, nil
+}
+
+// Selector returns a Selector which negotiates the codecs of each request.
+// The request codec follows the MIME type of request data, see DataMimeType.
+// The response codec is the first acceptable one in the MessageAcceptMimeTypes entry of CompositeMetadata,
+// or the request codec if there's no such entry.
+func (r *Registry) Selector(setup payload.SetupPayload) Selector {
+	return func(msg payload.Payload) (sel Selection, err error) {
+		mimeType, err := DataMimeType(setup, msg)
+		if err != nil {
+			return
+		}
+		var ok bool
+		if sel.Request, ok = r.Get(mimeType); !ok {
+			err = errors.Errorf("no codec for MIME type %q", mimeType)
+			return
+		}
+		accepts, err := AcceptMimeTypes(setup, msg)
+		if err != nil {
+			return
+		}
+		if len(accepts) < 1 {
+			sel.Response = sel.Request
+			return
+		}
+		for _, accept := range accepts {
+			if accept == mimeType {
+				sel.Response = sel.Request
+				return
+			}
+			if sel.Response, ok = r.Get(accept); ok {
+				sel.ResponseMimeType = accept
+				return
+			}
+		}
+		err = errors.Errorf("no acceptable codec for MIME types %v", accepts)
+		return
+	}
+}
+
+// DataMimeType returns the MIME type of message data, the MessageMimeType entry of CompositeMetadata
+// overrides the DataMimeType of setup.
+func DataMimeType(setup payload.SetupPayload, msg payload.Payload) (mimeType string, err error) {
+	mimeType = setup.DataMimeType()
+	if setup.MetadataMimeType() != extension.MessageCompositeMetadata.String() {
+		return
+	}
+	metadata, _ := msg.Metadata()
+	if found, ok, e := MessageMimeType(metadata); e != nil {
+		err = e
+	} else if ok {
+		mimeType = found
+	}
+	return
+}
+
+// AcceptMimeTypes returns the acceptable MIME types of response data in the MessageAcceptMimeTypes entry
+// of CompositeMetadata, it returns nil if there's no such entry.
+func AcceptMimeTypes(setup payload.SetupPayload, msg payload.Payload) ([]string, error) {
+	if setup.MetadataMimeType() != extension.MessageCompositeMetadata.String() {
+		return nil, nil
+	}
+	metadata, _ := msg.Metadata()
+	raw, ok, err := lookup(metadata, extension.MessageAcceptMimeTypes)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return extension.ParseAcceptMimeTypes(raw)
+}
+
+// MessageMimeType returns the MIME type in the MessageMimeType entry of CompositeMetadata.
+func MessageMimeType(compositeMetadata []byte) (mimeType string, ok bool, err error) {
+	raw, ok, err := lookup(compositeMetadata, extension.MessageMimeType)
+	if err != nil || !ok {
+		return
+	}
+	if mimeType, err = extension.ParseMimeType(raw); err != nil {
+		ok = false
+	}
+	return
+}
+
+// lookup returns the first entry of the MIME type in CompositeMetadata.
+func lookup(compositeMetadata []byte, mimeType extension.MIME) (data []byte, ok bool, err error) {
+	if len(compositeMetadata) < 1 {
+		return
+	}
+	data, ok, err = extension.CompositeMetadata(compositeMetadata).Lookup(mimeType.String())
+	if err != nil {
+		return nil, false, errors.Wrap(err, "bad composite metadata")
+	}
+	return
+}
@@ -0,0 +1,270 @@
+package codec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/codec"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type fakeSetup struct {
+	payload.Payload
+	dataMimeType     string
+	metadataMimeType string
+}
+
+func (f fakeSetup) DataMimeType() string {
+	return f.dataMimeType
+}
+
+func (f fakeSetup) MetadataMimeType() string {
+	return f.metadataMimeType
+}
+
+func (f fakeSetup) TimeBetweenKeepalive() time.Duration {
+	return 0
+}
+
+func (f fakeSetup) MaxLifetime() time.Duration {
+	return 0
+}
+
+func (f fakeSetup) Version() core.Version {
+	return core.DefaultVersion
+}
+
+func newSetup(dataMimeType string) payload.SetupPayload {
+	return fakeSetup{
+		Payload:          payload.New(nil, nil),
+		dataMimeType:     dataMimeType,
+		metadataMimeType: extension.MessageCompositeMetadata.String(),
+	}
+}
+
+type user struct {
+	Name string `json:"name" cbor:"name"`
+	Age  int    `json:"age" cbor:"age"`
+}
+
+func TestCodecs(t *testing.T) {
+	for _, mimeType := range []extension.MIME{extension.ApplicationJSON, extension.ApplicationCBOR} {
+		c, ok := codec.DefaultRegistry.GetMIME(mimeType)
+		require.True(t, ok)
+		data, err := c.Encode(user{Name: "foo", Age: 18})
+		require.NoError(t, err)
+		u, err := codec.Decode[user](c, data)
+		require.NoError(t, err, "decode %s failed", mimeType)
+		assert.Equal(t, user{Name: "foo", Age: 18}, u)
+		pu, err := codec.Decode[*user](c, data)
+		require.NoError(t, err)
+		assert.Equal(t, &user{Name: "foo", Age: 18}, pu)
+	}
+
+	for _, mimeType := range []string{"application/vnd.google.protobuf", "application/protobuf", "application/x-protobuf"} {
+		c, ok := codec.DefaultRegistry.Get(mimeType)
+		require.True(t, ok)
+		data, err := c.Encode(wrapperspb.String("foo"))
+		require.NoError(t, err)
+		v, err := codec.Decode[*wrapperspb.StringValue](c, data)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", v.GetValue())
+		_, err = c.Encode(user{})
+		assert.Error(t, err)
+	}
+
+	for _, mimeType := range []string{"application/octet-stream", "application/binary", "text/plain"} {
+		c, ok := codec.DefaultRegistry.Get(mimeType)
+		require.True(t, ok)
+		data, err := c.Encode("foo")
+		require.NoError(t, err)
+		s, err := codec.Decode[string](c, data)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", s)
+		b, err := codec.Decode[[]byte](c, data)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("foo"), b)
+		_, err = c.Encode(1)
+		assert.Error(t, err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := codec.NewRegistry()
+	_, ok := r.Get("application/x.unknown")
+	assert.False(t, ok)
+	r.Register("application/x.unknown", codec.JSON)
+	c, ok := r.Get("application/x.unknown")
+	assert.True(t, ok)
+	assert.Equal(t, codec.JSON, c)
+	_, ok = codec.DefaultRegistry.Get("application/x.unknown")
+	assert.False(t, ok, "registries should be isolated")
+
+	_, err := r.Of(newSetup("application/x.missing"), payload.New(nil, nil))
+	assert.Error(t, err)
+}
+
+func TestDataMimeType(t *testing.T) {
+	setup := newSetup(extension.ApplicationJSON.String())
+
+	mimeType, err := codec.DataMimeType(setup, payload.New(nil, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", mimeType)
+
+	// well-known id
+	metadata, _ := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageMimeType, []byte{0x80 | byte(extension.ApplicationCBOR)}).
+		Build()
+	mimeType, err = codec.DataMimeType(setup, payload.New(nil, metadata))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/cbor", mimeType)
+
+	// string
+	custom := "application/x.custom"
+	metadata, _ = extension.NewCompositeMetadataBuilder().
+		PushWellKnownString(extension.MessageRouting, "\x03foo").
+		PushWellKnown(extension.MessageMimeType, append([]byte{byte(len(custom) - 1)}, custom...)).
+		Build()
+	mimeType, err = codec.DataMimeType(setup, payload.New(nil, metadata))
+	assert.NoError(t, err)
+	assert.Equal(t, custom, mimeType)
+
+	// ignored if metadata is not composite
+	mimeType, err = codec.DataMimeType(fakeSetup{dataMimeType: "text/plain"}, payload.New(nil, metadata))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", mimeType)
+
+	for _, bad := range [][]byte{{}, {0x80 | 0x70}, {0x10, 'a'}} {
+		metadata, _ = extension.NewCompositeMetadataBuilder().PushWellKnown(extension.MessageMimeType, bad).Build()
+		_, err = codec.DataMimeType(setup, payload.New(nil, metadata))
+		assert.Error(t, err)
+	}
+	_, err = codec.DataMimeType(setup, payload.New(nil, []byte{0x80}))
+	assert.Error(t, err, "should fail with broken composite metadata")
+}
+
+func TestRequestResponse(t *testing.T) {
+	ctx := context.Background()
+	sel := codec.DefaultRegistry.Selector(newSetup("application/json"))
+	handler := codec.RequestResponse(sel, func(ctx context.Context, req user) (string, error) {
+		if req.Age < 0 {
+			return "", errors.New("bad age")
+		}
+		return req.Name, nil
+	})
+
+	reqCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	req, err := codec.EncodeWithContext(reqCtx, codec.JSON, user{Name: "foo"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, reqCtx, rsocket.ContextOf(req), "request should carry the context")
+	name, err := codec.BlockMono[string](ctx, codec.JSON, handler(req).DoOnSuccess(func(res payload.Payload) error {
+		assert.Equal(t, reqCtx, rsocket.ContextOf(res), "response should carry the context of request")
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", name)
+
+	// per-message MIME type
+	metadata, _ := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageMimeType, []byte{0x80 | byte(extension.ApplicationCBOR)}).
+		Build()
+	data, _ := codec.CBOR.Encode(user{Name: "bar"})
+	name, err = codec.BlockMono[string](ctx, codec.CBOR, handler(payload.New(data, metadata)))
+	require.NoError(t, err)
+	assert.Equal(t, "bar", name)
+
+	req, _ = codec.Encode(codec.JSON, user{Age: -1}, nil)
+	_, err = handler(req).Block(ctx)
+	assert.EqualError(t, err, "bad age")
+	_, err = handler(payload.NewString("{", "")).Block(ctx)
+	assert.Error(t, err)
+}
+
+func TestRequestStream(t *testing.T) {
+	ctx := context.Background()
+	sel := codec.DefaultRegistry.Selector(newSetup("application/json"))
+	handler := codec.RequestStream(sel, func(ctx context.Context, n int, next func(user) error) error {
+		if n < 0 {
+			return errors.New("bad n")
+		}
+		for i := 0; i < n; i++ {
+			if err := next(user{Age: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	users, err := codec.BlockFlux[user](ctx, codec.JSON, handler(payload.NewString("3", "")))
+	require.NoError(t, err)
+	assert.Equal(t, []user{{Age: 0}, {Age: 1}, {Age: 2}}, users)
+
+	_, err = codec.BlockFlux[user](ctx, codec.JSON, handler(payload.NewString("-1", "")))
+	assert.EqualError(t, err, "bad n")
+	_, err = codec.BlockFlux[string](ctx, codec.JSON, handler(payload.NewString("1", "")))
+	assert.Error(t, err, "should fail to decode user as string")
+}
+
+func TestSelector(t *testing.T) {
+	sel := codec.DefaultRegistry.Selector(newSetup("application/json"))
+	accept := func(mimeTypes ...string) payload.Payload {
+		raw, err := extension.EncodeAcceptMimeTypes(mimeTypes[0], mimeTypes[1:]...)
+		require.NoError(t, err)
+		metadata, err := extension.NewCompositeMetadataBuilder().
+			PushWellKnown(extension.MessageAcceptMimeTypes, raw).
+			Build()
+		require.NoError(t, err)
+		return payload.New(nil, metadata)
+	}
+
+	selection, err := sel(payload.New(nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, codec.JSON, selection.Request)
+	assert.Equal(t, codec.JSON, selection.Response)
+	assert.Empty(t, selection.ResponseMimeType)
+	metadata, err := selection.ResponseMetadata()
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+
+	selection, err = sel(accept("application/x.unknown", "application/cbor", "application/json"))
+	require.NoError(t, err)
+	assert.Equal(t, codec.JSON, selection.Request)
+	assert.Equal(t, codec.CBOR, selection.Response)
+	assert.Equal(t, "application/cbor", selection.ResponseMimeType)
+	metadata, err = selection.ResponseMetadata()
+	require.NoError(t, err)
+	mimeType, ok, err := codec.MessageMimeType(metadata)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "application/cbor", mimeType)
+
+	selection, err = sel(accept("application/json", "application/cbor"))
+	require.NoError(t, err)
+	assert.Equal(t, codec.JSON, selection.Response)
+	assert.Empty(t, selection.ResponseMimeType)
+
+	_, err = sel(accept("application/x.unknown"))
+	assert.Error(t, err)
+
+	handler := codec.RequestResponse(sel, func(ctx context.Context, req user) (user, error) {
+		return req, nil
+	})
+	reqMetadata, _ := accept("application/cbor").Metadata()
+	res, err := handler(payload.New([]byte(`{"name":"foo"}`), reqMetadata)).Block(context.Background())
+	require.NoError(t, err)
+	resMetadata, _ := res.Metadata()
+	mimeType, _, _ = codec.MessageMimeType(resMetadata)
+	assert.Equal(t, "application/cbor", mimeType)
+	u, err := codec.Decode[user](codec.CBOR, res.Data())
+	require.NoError(t, err)
+	assert.Equal(t, "foo", u.Name)
+}
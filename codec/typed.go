@@ -0,0 +1,149 @@
+package codec
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+// Decode decodes data into a T, pointer types such as *pb.Message are allocated before decoding.
+func Decode[T any](c Codec, data []byte) (v T, err error) {
+	if t := reflect.TypeOf(v); t != nil && t.Kind() == reflect.Ptr {
+		v = reflect.New(t.Elem()).Interface().(T)
+		err = c.Decode(data, v)
+		return
+	}
+	err = c.Decode(data, &v)
+	return
+}
+
+// Encode encodes v into a payload with metadata.
+func Encode(c Codec, v interface{}, metadata []byte) (payload.Payload, error) {
+	data, err := c.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return payload.New(data, metadata), nil
+}
+
+// EncodeWithContext encodes v into a payload with metadata, the payload carries ctx, see rsocket.WithContext.
+// Pass the context of received message when forwarding, so its deadline is propagated.
+func EncodeWithContext(ctx context.Context, c Codec, v interface{}, metadata []byte) (payload.Payload, error) {
+	out, err := Encode(c, v, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return rsocket.WithContext(ctx, out), nil
+}
+
+// BlockMono blocks and decodes the result of a Mono.
+func BlockMono[T any](ctx context.Context, c Codec, m mono.Mono) (v T, err error) {
+	res, err := m.Block(ctx)
+	if err != nil {
+		return
+	}
+	if res == nil {
+		err = errors.New("empty result")
+		return
+	}
+	return Decode[T](c, res.Data())
+}
+
+// BlockFlux blocks and decodes all elements of a Flux.
+func BlockFlux[T any](ctx context.Context, c Codec, f flux.Flux) ([]T, error) {
+	var (
+		results []T
+		decErr  error
+	)
+	_, err := f.
+		DoOnNext(func(input payload.Payload) error {
+			v, err := Decode[T](c, input.Data())
+			if err != nil {
+				decErr = err
+				return err
+			}
+			results = append(results, v)
+			return nil
+		}).
+		BlockLast(ctx)
+	if decErr != nil {
+		return nil, decErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// RequestResponse adapts a typed function into a RequestResponse handler.
+// The request is decoded and the response is encoded by the codecs selected by sel,
+// the negotiated MIME type of response is sent in the response metadata.
+func RequestResponse[Req, Res any](sel Selector, fn func(ctx context.Context, req Req) (Res, error)) func(msg payload.Payload) mono.Mono {
+	return func(msg payload.Payload) mono.Mono {
+		selection, err := sel(msg)
+		if err != nil {
+			return mono.Error(err)
+		}
+		metadata, err := selection.ResponseMetadata()
+		if err != nil {
+			return mono.Error(err)
+		}
+		req, err := Decode[Req](selection.Request, msg.Data())
+		if err != nil {
+			return mono.Error(errors.Wrap(err, "decode request failed"))
+		}
+		return mono.Create(func(ctx context.Context, sink mono.Sink) {
+			res, err := fn(ctx, req)
+			if err != nil {
+				sink.Error(err)
+				return
+			}
+			out, err := EncodeWithContext(rsocket.ContextOf(msg), selection.Response, res, metadata)
+			if err != nil {
+				sink.Error(errors.Wrap(err, "encode response failed"))
+				return
+			}
+			sink.Success(out)
+		})
+	}
+}
+
+// RequestStream adapts a typed function into a RequestStream handler, fn emits the responses by next.
+// The request is decoded and the responses are encoded by the codecs selected by sel,
+// the negotiated MIME type of responses is sent in the response metadata.
+func RequestStream[Req, Res any](sel Selector, fn func(ctx context.Context, req Req, next func(Res) error) error) func(msg payload.Payload) flux.Flux {
+	return func(msg payload.Payload) flux.Flux {
+		selection, err := sel(msg)
+		if err != nil {
+			return flux.Error(err)
+		}
+		metadata, err := selection.ResponseMetadata()
+		if err != nil {
+			return flux.Error(err)
+		}
+		req, err := Decode[Req](selection.Request, msg.Data())
+		if err != nil {
+			return flux.Error(errors.Wrap(err, "decode request failed"))
+		}
+		return flux.Create(func(ctx context.Context, sink flux.Sink) {
+			err := fn(ctx, req, func(res Res) error {
+				out, err := EncodeWithContext(rsocket.ContextOf(msg), selection.Response, res, metadata)
+				if err != nil {
+					return errors.Wrap(err, "encode response failed")
+				}
+				sink.Next(out)
+				return nil
+			})
+			if err != nil {
+				sink.Error(err)
+				return
+			}
+			sink.Complete()
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package compression provides the payload compression of RSocket connections.
+//
+// The compression is negotiated in SETUP: the client sends the name of algorithm as a composite metadata entry
+// with MimeType, and the server enables the same algorithm if it is registered, otherwise the SETUP is rejected.
+// After negotiation, the payload data larger than the threshold is compressed before fragmentation, and the first
+// fragment is marked with core.FlagCompressed. The metadata is never compressed.
+package compression
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/rsocket/rsocket-go/extension"
+)
+
+// MimeType is the MIME type of composite metadata entry in SETUP which carries the name of algorithm.
+const MimeType = "message/x.rsocket-go.compression.v0"
+
+// DefaultThreshold is the default size in bytes, the payload data smaller than it won't be compressed.
+const DefaultThreshold = 1024
+
+var errSetupNotComposite = errors.New("compression requires the composite metadata MIME type")
+
+// Compressor compresses and decompresses the payload data.
+type Compressor interface {
+	// Name returns the name of algorithm which is used in negotiation, eg: gzip.
+	Name() string
+	// Compress compresses the data.
+	Compress(data []byte) ([]byte, error)
+	// Decompress decompresses the data.
+	Decompress(data []byte) ([]byte, error)
+}
+
+var registry sync.Map // key=name, value=Compressor
+
+// Register registers a compressor, it replaces the compressor with the same name.
+func Register(c Compressor) {
+	registry.Store(c.Name(), c)
+}
+
+// Lookup returns the registered compressor with given name.
+func Lookup(name string) (Compressor, bool) {
+	if v, ok := registry.Load(name); ok {
+		return v.(Compressor), true
+	}
+	return nil, false
+}
+
+// AppendSetupMetadata returns a new composite metadata of SETUP which requests the compressor.
+// The entries of compression in the metadata are replaced.
+func AppendSetupMetadata(metadataMimeType string, metadata []byte, c Compressor) ([]byte, error) {
+	if metadataMimeType != extension.MessageCompositeMetadata.String() {
+		return nil, errSetupNotComposite
+	}
+	entries, err := extension.NewCompositeMetadataBytes(metadata).Entries()
+	if err != nil {
+		return nil, err
+	}
+	builder := extension.NewCompositeMetadataBuilder()
+	for _, it := range entries {
+		if it.MimeType != MimeType {
+			builder.Push(it.MimeType, it.Metadata)
+		}
+	}
+	builder.Push(MimeType, []byte(c.Name()))
+	return builder.Build()
+}
+
+// FromSetupMetadata returns the name of algorithm requested in the composite metadata of SETUP.
+// It returns false if there's no compression requested.
+func FromSetupMetadata(metadataMimeType string, metadata []byte) (string, bool) {
+	if metadataMimeType != extension.MessageCompositeMetadata.String() || len(metadata) < 1 {
+		return "", false
+	}
+	entries, err := extension.NewCompositeMetadataBytes(metadata).Entries()
+	if err != nil {
+		return "", false
+	}
+	for _, it := range entries {
+		if it.MimeType == MimeType {
+			return string(it.Metadata), true
+		}
+	}
+	return "", false
+}
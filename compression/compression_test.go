@@ -0,0 +1,109 @@
+package compression_test
+
+import (
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/rsocket/rsocket-go/compression"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCompressor struct{}
+
+func (fakeCompressor) Name() string {
+	return "fake"
+}
+
+func (fakeCompressor) Compress(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (fakeCompressor) Decompress(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func TestGzip(t *testing.T) {
+	c, ok := compression.Lookup(compression.Gzip)
+	require.True(t, ok, "gzip should be registered")
+	assert.Equal(t, compression.Gzip, c.Name())
+
+	for _, it := range []compression.Compressor{c, compression.NewGzip(gzip.BestSpeed), compression.NewGzip(999)} {
+		data := []byte(strings.Repeat("hello world", 1000))
+		compressed, err := it.Compress(data)
+		assert.NoError(t, err)
+		assert.Less(t, len(compressed), len(data))
+		decompressed, err := it.Decompress(compressed)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+
+	_, err := c.Decompress([]byte("not gzip"))
+	assert.Error(t, err)
+}
+
+func TestGzip_MaxDecompressedSize(t *testing.T) {
+	c := compression.NewGzip(gzip.DefaultCompression, compression.WithMaxDecompressedSize(1024))
+	compressed, err := c.Compress([]byte(strings.Repeat("a", 1024)))
+	require.NoError(t, err)
+	decompressed, err := c.Decompress(compressed)
+	assert.NoError(t, err, "should decompress data of max size")
+	assert.Len(t, decompressed, 1024)
+
+	oversized, err := c.Compress([]byte(strings.Repeat("a", 1025)))
+	require.NoError(t, err)
+	_, err = c.Decompress(oversized)
+	assert.Equal(t, compression.ErrDecompressedTooLarge, err)
+
+	// the reader can be reused after failure.
+	decompressed, err = c.Decompress(compressed)
+	assert.NoError(t, err)
+	assert.Len(t, decompressed, 1024)
+}
+
+func TestRegister(t *testing.T) {
+	_, ok := compression.Lookup("fake")
+	assert.False(t, ok)
+	compression.Register(fakeCompressor{})
+	c, ok := compression.Lookup("fake")
+	assert.True(t, ok)
+	assert.Equal(t, "fake", c.Name())
+}
+
+func TestSetupMetadata(t *testing.T) {
+	composite := extension.MessageCompositeMetadata.String()
+	gzipCompressor, _ := compression.Lookup(compression.Gzip)
+
+	_, err := compression.AppendSetupMetadata(extension.ApplicationJSON.String(), nil, gzipCompressor)
+	assert.Error(t, err, "should fail without composite metadata")
+
+	_, ok := compression.FromSetupMetadata(composite, nil)
+	assert.False(t, ok)
+
+	origin, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnownString(extension.ApplicationJSON, "{}").
+		Build()
+	require.NoError(t, err)
+
+	metadata, err := compression.AppendSetupMetadata(composite, origin, fakeCompressor{})
+	require.NoError(t, err)
+	name, ok := compression.FromSetupMetadata(composite, metadata)
+	assert.True(t, ok)
+	assert.Equal(t, "fake", name)
+
+	// replace the existing one
+	metadata, err = compression.AppendSetupMetadata(composite, metadata, gzipCompressor)
+	require.NoError(t, err)
+	name, ok = compression.FromSetupMetadata(composite, metadata)
+	assert.True(t, ok)
+	assert.Equal(t, compression.Gzip, name)
+	entries, err := extension.NewCompositeMetadataBytes(metadata).Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, extension.ApplicationJSON.String(), entries[0].MimeType)
+
+	_, ok = compression.FromSetupMetadata(extension.ApplicationJSON.String(), metadata)
+	assert.False(t, ok)
+}
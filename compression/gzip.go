@@ -0,0 +1,107 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Gzip is the name of gzip compressor, it is registered by default.
+const Gzip = "gzip"
+
+// DefaultMaxDecompressedSize is the default max size in bytes of decompressed data, see WithMaxDecompressedSize.
+const DefaultMaxDecompressedSize = 16 * 1024 * 1024
+
+// ErrDecompressedTooLarge is returned when the decompressed data exceeds the max size.
+var ErrDecompressedTooLarge = errors.New("decompressed data is too large")
+
+// GzipOption configures the gzip compressor.
+type GzipOption func(*gzipCompressor)
+
+// WithMaxDecompressedSize sets the max size in bytes of decompressed data, default is DefaultMaxDecompressedSize.
+// It protects the receiver from the small payloads which expand to huge data.
+func WithMaxDecompressedSize(n int) GzipOption {
+	return func(g *gzipCompressor) {
+		if n > 0 {
+			g.maxSize = n
+		}
+	}
+}
+
+func init() {
+	Register(NewGzip(gzip.DefaultCompression))
+}
+
+type gzipCompressor struct {
+	writers sync.Pool
+	readers sync.Pool
+	maxSize int
+}
+
+// NewGzip returns a gzip compressor with given compression level.
+// You can register it to replace the default gzip compressor.
+func NewGzip(level int, opts ...GzipOption) Compressor {
+	g := &gzipCompressor{
+		writers: sync.Pool{
+			New: func() interface{} {
+				w, err := gzip.NewWriterLevel(nil, level)
+				if err != nil {
+					w = gzip.NewWriter(nil)
+				}
+				return w
+			},
+		},
+		maxSize: DefaultMaxDecompressedSize,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+func (g *gzipCompressor) Name() string {
+	return Gzip
+}
+
+func (g *gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := g.writers.Get().(*gzip.Writer)
+	defer g.writers.Put(w)
+	w.Reset(&b)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (g *gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	var (
+		r   *gzip.Reader
+		err error
+	)
+	if v := g.readers.Get(); v != nil {
+		r = v.(*gzip.Reader)
+		err = r.Reset(bytes.NewReader(data))
+	} else {
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer g.readers.Put(r)
+	// read one more byte to find out whether the data exceeds the max size.
+	out, err := io.ReadAll(io.LimitReader(r, int64(g.maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > g.maxSize {
+		return nil, ErrDecompressedTooLarge
+	}
+	return out, nil
+}
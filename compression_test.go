@@ -0,0 +1,153 @@
+package rsocket_test
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/compression"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+func TestCompression(t *testing.T) {
+	const port = 9823
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startEchoServer(ctx, t, port, func(b ServerBuilder) ServerBuilder {
+		return b.Fragment(128)
+	})
+
+	cli, err := Connect().
+		Fragment(128).
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Compression(compression.Gzip, 64).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err, "connect failed")
+	defer cli.Close()
+
+	random := make([]byte, 4096)
+	_, err = rand.Read(random)
+	require.NoError(t, err)
+
+	for name, data := range map[string][]byte{
+		"small":          []byte("small data"),
+		"compressible":   []byte(strings.Repeat("hello world ", 1000)),
+		"uncompressible": random,
+	} {
+		res, err := cli.RequestResponse(payload.New(data, []byte(fakeMetadata))).Block(ctx)
+		require.NoError(t, err, name)
+		assert.Equal(t, data, res.Data(), name)
+		m, _ := res.MetadataUTF8()
+		assert.Equal(t, fakeMetadata, m, name)
+	}
+
+	data := strings.Repeat("hello world ", 1000)
+	var seq int
+	_, err = cli.RequestStream(payload.NewString(data, "")).
+		DoOnNext(func(elem payload.Payload) error {
+			assert.Equal(t, data, elem.DataUTF8())
+			m, _ := elem.MetadataUTF8()
+			assert.Equal(t, fmt.Sprintf("%d", seq), m)
+			seq++
+			return nil
+		}).
+		BlockLast(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int(streamElements), seq)
+}
+
+// countingCompressor counts the data compressed by gzip.
+type countingCompressor struct {
+	compression.Compressor
+	compressed *atomic.Int32
+}
+
+func (c countingCompressor) Name() string {
+	return "counting-gzip"
+}
+
+func (c countingCompressor) Compress(data []byte) ([]byte, error) {
+	c.compressed.Inc()
+	return c.Compressor.Compress(data)
+}
+
+func TestCompression_ServerThreshold(t *testing.T) {
+	const port = 9826
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gzip, _ := compression.Lookup(compression.Gzip)
+	counting := countingCompressor{Compressor: gzip, compressed: atomic.NewInt32(0)}
+	compression.Register(counting)
+
+	data := strings.Repeat("hello world ", 1000)
+	startEchoServer(ctx, t, port, func(b ServerBuilder) ServerBuilder {
+		return b.CompressionThreshold(len(data) + 1)
+	})
+
+	cli, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Compression(counting.Name(), 64).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err, "connect failed")
+	defer cli.Close()
+
+	res, err := cli.RequestResponse(payload.NewString(data, "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, data, res.DataUTF8())
+	assert.Equal(t, int32(1), counting.compressed.Load(), "response smaller than threshold of server should not be compressed")
+}
+
+func TestCompression_BadArgs(t *testing.T) {
+	_, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Compression("not-exists", 0).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", 9824).Build()).
+		Start(context.Background())
+	assert.Error(t, err, "should fail with unknown compression")
+
+	_, err = Connect().
+		Compression(compression.Gzip, 0).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", 9824).Build()).
+		Start(context.Background())
+	assert.Error(t, err, "should fail without composite metadata")
+}
+
+func TestCompression_Unsupported(t *testing.T) {
+	const port = 9825
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startEchoServer(ctx, t, port)
+
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnownString(extension.ApplicationJSON, "{}").
+		Push(compression.MimeType, []byte("not-exists")).
+		Build()
+	require.NoError(t, err)
+
+	cli, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		SetupPayload(payload.New(nil, metadata)).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer cli.Close()
+	_, err = cli.RequestResponse(payload.NewString("rr", "")).Block(ctx)
+	require.Error(t, err)
+	customErr, ok := err.(core.CustomError)
+	require.True(t, ok, "should be a custom error: %v", err)
+	assert.Equal(t, core.ErrorCodeUnsupportedSetup, customErr.ErrorCode())
+}
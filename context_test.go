@@ -0,0 +1,187 @@
+package rsocket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerContext(t *testing.T) {
+	const port = 9821
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type requestInfo struct {
+		sid      uint32
+		setup    payload.SetupPayload
+		addr     string
+		metadata []extension.CompositeMetadataEntry
+		done     <-chan struct{}
+	}
+	infoOf := func(ctx context.Context) (info requestInfo) {
+		info.sid, _ = StreamIDFromContext(ctx)
+		info.setup, _ = SetupPayloadFromContext(ctx)
+		info.addr, _ = PeerAddrFromContext(ctx)
+		info.metadata, _ = CompositeMetadataFromContext(ctx)
+		info.done = ctx.Done()
+		return
+	}
+
+	infos := make(chan requestInfo, 2)
+	started := make(chan struct{})
+	go func() {
+		err := Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					FireAndForgetWithContext(func(ctx context.Context, request payload.Payload) {
+						infos <- infoOf(ctx)
+					}),
+					RequestResponseWithContext(func(ctx context.Context, request payload.Payload) mono.Mono {
+						infos <- infoOf(ctx)
+						return mono.Just(payload.Clone(request))
+					}),
+					RequestStreamWithContext(func(ctx context.Context, request payload.Payload) flux.Flux {
+						infos <- infoOf(ctx)
+						return flux.Just(payload.Clone(request))
+					}),
+				), nil
+			}).
+			Transport(TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+			Serve(ctx)
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	cli, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		SetupPayload(payload.NewString("setup", "")).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer cli.Close()
+
+	metadata, err := extension.NewCompositeMetadataBuilder().PushWellKnownString(extension.TextPlain, "hello").Build()
+	require.NoError(t, err)
+
+	_, err = cli.RequestResponse(payload.New([]byte("hello"), metadata)).Block(ctx)
+	require.NoError(t, err)
+	info := <-infos
+	assert.NotZero(t, info.sid)
+	require.NotNil(t, info.setup)
+	assert.Equal(t, "setup", info.setup.DataUTF8())
+	assert.Equal(t, extension.MessageCompositeMetadata.String(), info.setup.MetadataMimeType())
+	assert.NotEmpty(t, info.addr)
+	assert.Equal(t, []extension.CompositeMetadataEntry{{MimeType: extension.TextPlain.String(), Metadata: []byte("hello")}}, info.metadata)
+	select {
+	case <-info.done:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "context should be cancelled after the stream is done")
+	}
+
+	cli.FireAndForget(payload.NewString("hello", ""))
+	select {
+	case info = <-infos:
+		assert.NotZero(t, info.sid)
+		assert.Empty(t, info.metadata)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "fire-and-forget timeout")
+	}
+
+	_, err = cli.RequestStream(payload.New([]byte("hello"), metadata)).BlockSlice(ctx)
+	require.NoError(t, err)
+	info = <-infos
+	assert.NotZero(t, info.sid)
+	assert.Len(t, info.metadata, 1)
+}
+
+func TestRequestDeadline(t *testing.T) {
+	const port = 9822
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handlerErrors := make(chan error, 2)
+	started := make(chan struct{})
+	go func() {
+		err := Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponseWithContext(func(ctx context.Context, request payload.Payload) mono.Mono {
+						return mono.Create(func(_ context.Context, sink mono.Sink) {
+							<-ctx.Done()
+							handlerErrors <- ctx.Err()
+						})
+					}),
+					RequestStreamWithContext(func(ctx context.Context, request payload.Payload) flux.Flux {
+						return flux.Create(func(_ context.Context, sink flux.Sink) {
+							sink.Next(payload.NewString("first", ""))
+							<-ctx.Done()
+							handlerErrors <- ctx.Err()
+						})
+					}),
+				), nil
+			}).
+			Transport(TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+			Serve(ctx)
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	cli, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer cli.Close()
+
+	assertHandlerDeadline := func() {
+		select {
+		case err := <-handlerErrors:
+			assert.Equal(t, context.DeadlineExceeded, err)
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "handler context should be cancelled")
+		}
+	}
+	assertCanceled := func(err error) {
+		require.Error(t, err)
+		ce, ok := err.(Error)
+		require.True(t, ok, "should be rsocket error: %v", err)
+		assert.Equal(t, ErrorCodeCanceled, ce.ErrorCode())
+		assertHandlerDeadline()
+	}
+
+	requestCtx, requestCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer requestCancel()
+	_, err = cli.RequestResponse(WithContext(requestCtx, payload.NewString("hello", ""))).Block(ctx)
+	assertCanceled(err)
+
+	requestCtx, requestCancel = context.WithTimeout(ctx, 200*time.Millisecond)
+	defer requestCancel()
+	results, err := cli.RequestStream(WithContext(requestCtx, payload.NewString("hello", ""))).BlockSlice(ctx)
+	assert.Len(t, results, 1)
+	assertCanceled(err)
+
+	// the deadline of subscriber context is sent without wrapping the request.
+	requestCtx, requestCancel = context.WithTimeout(ctx, 200*time.Millisecond)
+	defer requestCancel()
+	_, err = cli.RequestResponse(payload.NewString("hello", "")).Block(requestCtx)
+	assert.Error(t, err)
+	assertHandlerDeadline()
+
+	_, err = cli.RequestResponse(payload.NewString("hello", "")).Timeout(200 * time.Millisecond).Block(ctx)
+	assert.Error(t, err)
+	assertHandlerDeadline()
+}
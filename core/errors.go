@@ -64,6 +64,32 @@ type CustomError interface {
 	ErrorData() []byte
 }
 
+// customError is a CustomError with fixed code and data.
+type customError struct {
+	code ErrorCode
+	data []byte
+}
+
+func (c customError) Error() string {
+	return c.code.String() + ": " + string(c.data)
+}
+
+func (c customError) ErrorCode() ErrorCode {
+	return c.code
+}
+
+func (c customError) ErrorData() []byte {
+	return c.data
+}
+
+// NewCustomError creates a CustomError, the responder will send it as an ERROR frame with the code and data.
+func NewCustomError(code ErrorCode, data []byte) CustomError {
+	return customError{
+		code: code,
+		data: data,
+	}
+}
+
 // Error defines.
 var (
 	ErrFrameLengthExceed  = errors.New("rsocket: frame length is greater than 24bits")
@@ -76,4 +102,6 @@ var (
 	ErrHandlerNil         = errors.New("rsocket: handler cannot be nil")
 	ErrHandlerExist       = errors.New("rsocket: handler exists already")
 	ErrSendFull           = errors.New("rsocket: frame send channel is full")
+	ErrConnectionLost     = errors.New("rsocket: connection lost")
+	ErrClientClosed       = errors.New("rsocket: client closed")
 )
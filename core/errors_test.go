@@ -26,3 +26,10 @@ func TestErrorCode_String(t *testing.T) {
 	}
 	assert.Equal(t, "UNKNOWN", core.ErrorCode(math.MaxUint32).String())
 }
+
+func TestNewCustomError(t *testing.T) {
+	err := core.NewCustomError(core.ErrorCodeRejected, []byte("foobar"))
+	assert.Equal(t, core.ErrorCodeRejected, err.ErrorCode())
+	assert.Equal(t, []byte("foobar"), err.ErrorData())
+	assert.Equal(t, "REJECTED: foobar", err.Error())
+}
@@ -28,6 +28,27 @@ func FromBytes(b []byte) (f core.BufferedFrame, err error) {
 	return
 }
 
+// FromWriteable creates a buffered frame from a writeable frame.
+// The frame bytes are written into a borrowed buffer directly, without any length prefix.
+func FromWriteable(frame core.WriteableFrame) (f core.BufferedFrame, err error) {
+	bb := common.BorrowByteBuff()
+	_, err = frame.WriteTo(bb)
+	if err != nil {
+		common.ReturnByteBuff(bb)
+		return
+	}
+	if bb.Len() < core.FrameHeaderLen {
+		common.ReturnByteBuff(bb)
+		err = errIncompleteFrame
+		return
+	}
+	f, err = convert(newBufferedFrame(bb))
+	if err != nil {
+		common.ReturnByteBuff(bb)
+	}
+	return
+}
+
 // CalcPayloadFrameSize returns payload frame size.
 func CalcPayloadFrameSize(data, metadata []byte) int {
 	size := core.FrameHeaderLen + len(data)
@@ -29,6 +29,18 @@ func TestFromBytes(t *testing.T) {
 	assert.True(t, frameActual.HasFlag(core.FlagNext))
 }
 
+func TestFromWriteable(t *testing.T) {
+	frame := NewWriteableRequestResponseFrame(42, []byte("fake-data"), []byte("fake-metadata"), core.FlagNext)
+	frameActual, err := FromWriteable(frame)
+	assert.NoError(t, err, "should not be error")
+	defer frameActual.Release()
+	assert.IsType(t, &RequestResponseFrame{}, frameActual)
+	assert.Equal(t, frame.Header(), frameActual.Header(), "header does not match")
+	assert.Equal(t, frame.Len(), frameActual.Len())
+	assert.Equal(t, "fake-data", frameActual.(*RequestResponseFrame).DataUTF8())
+	assert.NoError(t, frameActual.Validate())
+}
+
 func TestFrameCancel(t *testing.T) {
 	f := NewCancelFrame(_sid)
 	defer f.Release()
@@ -109,6 +121,7 @@ func TestFrameLease(t *testing.T) {
 	assert.Equal(t, n, f.NumberOfRequests())
 	assert.Equal(t, metadata, f.Metadata())
 	f2 := NewWriteableLeaseFrame(time.Second, n, metadata)
+	assert.Equal(t, n, f2.NumberOfRequests())
 	checkBytes(t, f, f2)
 }
 
@@ -301,3 +314,57 @@ func checkBytes(t *testing.T, a core.BufferedFrame, b core.WriteableFrame) {
 	_, err = convert(raw)
 	assert.NoError(t, err, "create from raw failed")
 }
+
+func TestFrameView(t *testing.T) {
+	_, ok := NewFrameView(NewWriteableCancelFrame(_sid))
+	assert.False(t, ok, "should not view frames without payload")
+
+	data, metadata := []byte("fake-data"), []byte("fake-metadata")
+	for _, frame := range []core.WriteableFrame{
+		NewWriteableRequestStreamFrame(_sid, 42, data, metadata, 0),
+		NewWriteableRequestChannelFrame(_sid, 42, data, metadata, 0),
+	} {
+		var done int
+		frame.HandleDone(func() {
+			done++
+		})
+		view, ok := NewFrameView(frame)
+		assert.True(t, ok)
+		assert.Equal(t, frame.Header(), view.Header())
+		assert.Equal(t, frame.Len(), view.Len())
+		assert.Equal(t, _sid, view.StreamID())
+		assert.True(t, view.HasFlag(core.FlagMetadata))
+		assert.NoError(t, view.Validate())
+		assert.Equal(t, uint32(42), view.InitialRequestN())
+		assert.Equal(t, "fake-data", view.DataUTF8())
+		m, ok := view.MetadataUTF8()
+		assert.True(t, ok)
+		assert.Equal(t, "fake-metadata", m)
+
+		// the view is written as same as the writeable frame.
+		expect := &bytes.Buffer{}
+		_, err := frame.WriteTo(expect)
+		assert.NoError(t, err)
+		actual := &bytes.Buffer{}
+		_, err = view.WriteTo(actual)
+		assert.NoError(t, err)
+		assert.Equal(t, expect.Bytes(), actual.Bytes())
+
+		view.IncRef()
+		assert.Equal(t, int32(2), view.RefCnt())
+		view.Release()
+		assert.Zero(t, done)
+		view.Release()
+		assert.Equal(t, 1, done)
+		view.Release()
+		assert.Equal(t, 1, done, "frame should be done only once")
+	}
+
+	view, ok := NewFrameView(NewWriteableMetadataPushFrame(metadata))
+	assert.True(t, ok)
+	assert.Nil(t, view.Data())
+	m, ok := view.Metadata()
+	assert.True(t, ok)
+	assert.Equal(t, metadata, m)
+	view.Release()
+}
@@ -88,6 +88,9 @@ func PrintFrame(f core.Frame) string {
 		reqN = it.N()
 	case *WriteableMetadataPushFrame:
 		metadata = it.metadata
+	case *FrameView:
+		metadata, data = it.metadata, it.data
+		initN = it.n
 	case *WriteableFireAndForgetFrame:
 		metadata, data = it.metadata, it.data
 	case *WriteableRequestResponseFrame:
@@ -0,0 +1,159 @@
+package framing
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/internal/bytesconv"
+	uberatomic "go.uber.org/atomic"
+)
+
+// FrameView is a buffered frame which shares the bytes of a writeable frame, so it can be read without copying.
+// The writeable frame is marked done when the view is released.
+// Only the frames carrying payload are supported, see NewFrameView.
+type FrameView struct {
+	frame    core.WriteableFrame
+	header   core.FrameHeader
+	size     int
+	n        uint32
+	metadata []byte
+	data     []byte
+	refs     uberatomic.Int32
+	released uberatomic.Bool
+}
+
+// CanView returns true if the frame is one of RequestResponse, RequestStream, RequestChannel,
+// FireAndForget, MetadataPush and Payload.
+func CanView(frame core.WriteableFrame) bool {
+	switch frame.(type) {
+	case *WriteablePayloadFrame,
+		*WriteableRequestResponseFrame,
+		*WriteableRequestStreamFrame,
+		*WriteableRequestChannelFrame,
+		*WriteableFireAndForgetFrame,
+		*WriteableMetadataPushFrame:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewFrameView returns a view of the writeable frame, it returns false if the frame cannot be viewed, see CanView.
+func NewFrameView(frame core.WriteableFrame) (*FrameView, bool) {
+	if !CanView(frame) {
+		return nil, false
+	}
+	view := &FrameView{
+		frame:  frame,
+		header: frame.Header(),
+		size:   frame.Len(),
+	}
+	switch it := frame.(type) {
+	case *WriteablePayloadFrame:
+		view.metadata, view.data = it.metadata, it.data
+	case *WriteableRequestResponseFrame:
+		view.metadata, view.data = it.metadata, it.data
+	case *WriteableRequestStreamFrame:
+		view.metadata, view.data = it.metadata, it.data
+		view.n = binary.BigEndian.Uint32(it.n[:])
+	case *WriteableRequestChannelFrame:
+		view.metadata, view.data = it.metadata, it.data
+		view.n = binary.BigEndian.Uint32(it.n[:])
+	case *WriteableFireAndForgetFrame:
+		view.metadata, view.data = it.metadata, it.data
+	case *WriteableMetadataPushFrame:
+		view.metadata = it.metadata
+	}
+	view.refs.Store(1)
+	return view, true
+}
+
+// Header returns frame FrameHeader.
+func (f *FrameView) Header() core.FrameHeader {
+	return f.header
+}
+
+// Len returns length of frame.
+func (f *FrameView) Len() int {
+	return f.size
+}
+
+// WriteTo write frame to writer.
+func (f *FrameView) WriteTo(w io.Writer) (n int64, err error) {
+	if f.released.Load() {
+		return
+	}
+	return f.frame.WriteTo(w)
+}
+
+// IncRef increases the reference count.
+func (f *FrameView) IncRef() int32 {
+	return f.refs.Add(1)
+}
+
+// RefCnt returns the reference count.
+func (f *FrameView) RefCnt() int32 {
+	return f.refs.Load()
+}
+
+// Release releases the view, the writeable frame is marked done when no one refers to it.
+func (f *FrameView) Release() {
+	if f.refs.Add(-1) > 0 {
+		return
+	}
+	if f.released.CAS(false, true) {
+		f.frame.Done()
+	}
+}
+
+// Validate returns error if frame is invalid.
+func (f *FrameView) Validate() error {
+	return nil
+}
+
+// HasFlag returns true if target frame flag is enabled.
+func (f *FrameView) HasFlag(flag core.FrameFlag) bool {
+	return f.header.Flag().Check(flag)
+}
+
+// StreamID returns the stream id of current frame.
+func (f *FrameView) StreamID() uint32 {
+	return f.header.StreamID()
+}
+
+// InitialRequestN returns the initial request N of RequestStream and RequestChannel, it returns zero for others.
+func (f *FrameView) InitialRequestN() uint32 {
+	return f.n
+}
+
+// Metadata returns metadata bytes.
+func (f *FrameView) Metadata() (metadata []byte, ok bool) {
+	ok = f.header.Flag().Check(core.FlagMetadata)
+	if ok {
+		metadata = f.metadata
+	}
+	return
+}
+
+// Data returns data bytes.
+func (f *FrameView) Data() []byte {
+	return f.data
+}
+
+// MetadataUTF8 returns metadata as UTF8 string.
+func (f *FrameView) MetadataUTF8() (metadata string, ok bool) {
+	raw, ok := f.Metadata()
+	if ok {
+		metadata = string(raw)
+	}
+	return
+}
+
+// DataUTF8 returns data as UTF8 string.
+func (f *FrameView) DataUTF8() (data string) {
+	if len(f.data) > 0 {
+		data = bytesconv.BytesToString(f.data)
+	}
+	return
+}
@@ -37,6 +37,11 @@ func NewWriteableLeaseFrame(ttl time.Duration, n uint32, metadata []byte) *Write
 	}
 }
 
+// NumberOfRequests returns number of requests.
+func (l WriteableLeaseFrame) NumberOfRequests() uint32 {
+	return binary.BigEndian.Uint32(l.n[:])
+}
+
 // WriteTo writes frame to writer.
 func (l WriteableLeaseFrame) WriteTo(w io.Writer) (n int64, err error) {
 	var wrote int64
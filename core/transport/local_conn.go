@@ -0,0 +1,230 @@
+package transport
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+var errLocalConnClosed = errors.New("local conn closed")
+
+// localPipe is an in-memory queue of frames in one direction.
+type localPipe struct {
+	mu     sync.Mutex
+	frames []core.BufferedFrame
+	closed bool
+	notify chan struct{}
+}
+
+func newLocalPipe() *localPipe {
+	return &localPipe{
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (p *localPipe) wakeup() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (p *localPipe) put(frame core.BufferedFrame) bool {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return false
+	}
+	p.frames = append(p.frames, frame)
+	p.mu.Unlock()
+	p.wakeup()
+	return true
+}
+
+// poll returns the next frame, it returns false if the pipe is closed and drained.
+func (p *localPipe) poll() (frame core.BufferedFrame, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.frames) > 0 {
+		frame = p.frames[0]
+		p.frames[0] = nil
+		p.frames = p.frames[1:]
+		ok = true
+		return
+	}
+	ok = !p.closed
+	return
+}
+
+// close stops accepting frames, the frames left can still be read.
+func (p *localPipe) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.wakeup()
+}
+
+// dispose closes the pipe and releases the frames left.
+func (p *localPipe) dispose() {
+	p.mu.Lock()
+	p.closed = true
+	frames := p.frames
+	p.frames = nil
+	p.mu.Unlock()
+	for _, it := range frames {
+		it.Release()
+	}
+	p.wakeup()
+}
+
+// LocalConn is RSocket connection for in-process transport.
+// Frames are passed to the peer through memory, without length prefix and any socket.
+type LocalConn struct {
+	addr     string
+	in       *localPipe
+	out      *localPipe
+	mu       sync.Mutex
+	deadline time.Time
+	counter  *core.TrafficCounter
+	once     sync.Once
+}
+
+// NewLocalConnPair creates a pair of connected local connections.
+func NewLocalConnPair(addr string) (*LocalConn, *LocalConn) {
+	a, b := newLocalPipe(), newLocalPipe()
+	return &LocalConn{addr: addr, in: a, out: b}, &LocalConn{addr: addr, in: b, out: a}
+}
+
+// Addr returns the address info.
+func (p *LocalConn) Addr() string {
+	return p.addr
+}
+
+// SetCounter bind a counter which can count r/w bytes.
+func (p *LocalConn) SetCounter(c *core.TrafficCounter) {
+	p.mu.Lock()
+	p.counter = c
+	p.mu.Unlock()
+}
+
+// SetDeadline set deadline for current connection.
+// After this deadline, connection will be closed.
+func (p *LocalConn) SetDeadline(deadline time.Time) error {
+	p.mu.Lock()
+	p.deadline = deadline
+	p.mu.Unlock()
+	// wake up the pending reader to check the new deadline.
+	p.in.wakeup()
+	return nil
+}
+
+func (p *LocalConn) getDeadline() (deadline time.Time) {
+	p.mu.Lock()
+	deadline = p.deadline
+	p.mu.Unlock()
+	return
+}
+
+func (p *LocalConn) getCounter() (counter *core.TrafficCounter) {
+	p.mu.Lock()
+	counter = p.counter
+	p.mu.Unlock()
+	return
+}
+
+// Read reads next frame from Conn.
+func (p *LocalConn) Read() (f core.BufferedFrame, err error) {
+	for {
+		var ok bool
+		f, ok = p.in.poll()
+		if !ok {
+			err = io.EOF
+			return
+		}
+		if f != nil {
+			break
+		}
+		deadline := p.getDeadline()
+		if deadline.IsZero() {
+			<-p.in.notify
+			continue
+		}
+		d := time.Until(deadline)
+		if d <= 0 {
+			err = errors.Wrap(os.ErrDeadlineExceeded, "read frame failed")
+			return
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-p.in.notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+	if counter := p.getCounter(); counter != nil && f.Header().Resumable() {
+		counter.IncReadBytes(f.Len())
+	}
+	if logger.IsDebugEnabled() {
+		logger.Debugf("%s\n", framing.PrintFrame(f))
+	}
+	return
+}
+
+// Flush flush data.
+func (p *LocalConn) Flush() (err error) {
+	return
+}
+
+// Write writes a frame.
+// The frames carrying payload are passed to the peer as views without copying, see framing.FrameView.
+// They are retained after writing successfully, and marked done once the peer releases them.
+// Other frames are copied as buffered frames, so they can be marked done by the writer after writing.
+func (p *LocalConn) Write(frame core.WriteableFrame) (err error) {
+	// the frame may be consumed by peer once it is put, so read it before.
+	header, size := frame.Header(), frame.Len()
+	if logger.IsDebugEnabled() {
+		logger.Debugf("%s\n", framing.PrintFrame(frame))
+	}
+	var f core.BufferedFrame
+	view, retained := framing.NewFrameView(frame)
+	if retained {
+		f = view
+	} else if f, err = framing.FromWriteable(frame); err != nil {
+		err = errors.Wrap(err, "write frame failed")
+		return
+	}
+	if !p.out.put(f) {
+		// the writer keeps the ownership of frame if it's not written.
+		if !retained {
+			f.Release()
+		}
+		err = errors.Wrap(errLocalConnClosed, "write frame failed")
+		return
+	}
+	if counter := p.getCounter(); counter != nil && header.Resumable() {
+		counter.IncWriteBytes(size)
+	}
+	return
+}
+
+// retains returns true if the frame will be marked done by LocalConn after writing successfully.
+func (p *LocalConn) retains(frame core.WriteableFrame) bool {
+	return framing.CanView(frame)
+}
+
+// Close closes current connection.
+// The peer can still read the frames which have been written, then it will get an io.EOF.
+func (p *LocalConn) Close() error {
+	p.once.Do(func() {
+		p.in.dispose()
+		p.out.close()
+	})
+	return nil
+}
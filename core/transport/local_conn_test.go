@@ -0,0 +1,141 @@
+package transport_test
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalConn_ReadWrite(t *testing.T) {
+	borrowed := common.CountBorrowed()
+
+	c, s := transport.NewLocalConnPair("local://fake")
+	assert.Equal(t, "local://fake", c.Addr())
+
+	cc, sc := core.NewTrafficCounter(), core.NewTrafficCounter()
+	c.SetCounter(cc)
+	s.SetCounter(sc)
+
+	data := []byte("fake-data")
+	frame := framing.NewWriteableRequestResponseFrame(1, data, []byte("fake-metadata"), 0)
+	done := make(chan struct{})
+	frame.HandleDone(func() {
+		close(done)
+	})
+	size := frame.Len()
+	err := c.Write(frame)
+	require.NoError(t, err)
+	assert.NoError(t, c.Flush())
+
+	// the frame is passed to peer without copying, and it's marked done after peer releases it.
+	next, err := s.Read()
+	require.NoError(t, err)
+	assert.Equal(t, core.FrameTypeRequestResponse, next.Header().Type())
+	view, ok := next.(*framing.FrameView)
+	require.True(t, ok, "should be a view of writeable frame")
+	assert.Equal(t, "fake-data", view.DataUTF8())
+	metadata, _ := view.MetadataUTF8()
+	assert.Equal(t, "fake-metadata", metadata)
+	assert.Equal(t, &data[0], &view.Data()[0], "should share the bytes of writeable frame")
+	select {
+	case <-done:
+		assert.Fail(t, "frame should not be done before released")
+	default:
+	}
+	next.Release()
+	<-done
+
+	assert.Equal(t, uint64(size), cc.WriteBytes())
+	assert.Equal(t, uint64(size), sc.ReadBytes())
+
+	// keepalive is not resumable.
+	err = s.Write(framing.NewWriteableKeepaliveFrame(0, nil, true))
+	require.NoError(t, err)
+	next, err = c.Read()
+	require.NoError(t, err)
+	assert.Equal(t, core.FrameTypeKeepalive, next.Header().Type())
+	next.Release()
+	assert.Zero(t, sc.WriteBytes())
+	assert.Zero(t, cc.ReadBytes())
+
+	// peer can read the frames written before closing.
+	err = c.Write(framing.NewWriteableCancelFrame(1))
+	require.NoError(t, err)
+	// frames which are not read should be released when closing.
+	err = s.Write(framing.NewWriteableCancelFrame(1))
+	require.NoError(t, err)
+	assert.NoError(t, c.Close())
+	assert.Error(t, c.Write(framing.NewWriteableCancelFrame(1)))
+	assert.Error(t, s.Write(framing.NewWriteableCancelFrame(1)))
+
+	next, err = s.Read()
+	require.NoError(t, err)
+	assert.Equal(t, core.FrameTypeCancel, next.Header().Type())
+	next.Release()
+	_, err = s.Read()
+	assert.Equal(t, io.EOF, err)
+	_, err = c.Read()
+	assert.Equal(t, io.EOF, err)
+	assert.NoError(t, s.Close())
+
+	assert.Equal(t, borrowed, common.CountBorrowed())
+}
+
+func TestLocalConn_SetDeadline(t *testing.T) {
+	c, s := transport.NewLocalConnPair("local://fake")
+	defer c.Close()
+	defer s.Close()
+
+	err := s.SetDeadline(time.Now().Add(100 * time.Millisecond))
+	assert.NoError(t, err)
+	start := time.Now()
+	_, err = s.Read()
+	assert.True(t, errors.Is(err, os.ErrDeadlineExceeded), "should be deadline exceeded: %v", err)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	// extend the deadline while reading.
+	_ = s.SetDeadline(time.Now().Add(100 * time.Millisecond))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = s.SetDeadline(time.Now().Add(time.Hour))
+		time.Sleep(100 * time.Millisecond)
+		_ = c.Write(framing.NewWriteableCancelFrame(1))
+	}()
+	next, err := s.Read()
+	require.NoError(t, err)
+	next.Release()
+}
+
+func TestLocalConn_ReleaseUnread(t *testing.T) {
+	c, s := transport.NewLocalConnPair("local://fake")
+
+	var done int
+	unread := framing.NewWriteablePayloadFrame(1, []byte("fake-data"), nil, core.FlagNext)
+	unread.HandleDone(func() {
+		done++
+	})
+	require.NoError(t, c.Write(unread))
+	// frames which are not read should be marked done when closing.
+	assert.NoError(t, s.Close())
+	assert.Equal(t, 1, done)
+
+	// the writer keeps the ownership of frame if writing failed.
+	failed := framing.NewWriteablePayloadFrame(1, []byte("fake-data"), nil, core.FlagNext)
+	failed.HandleDone(func() {
+		done++
+	})
+	assert.NoError(t, c.Close())
+	assert.Error(t, c.Write(failed))
+	assert.Equal(t, 1, done)
+	failed.Done()
+	assert.Equal(t, 2, done)
+}
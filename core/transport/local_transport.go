@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	localServersMu sync.Mutex
+	localServers   = make(map[string]*localServerTransport)
+)
+
+type localServerTransport struct {
+	name     string
+	mu       sync.Mutex
+	m        map[*Transport]struct{}
+	ctx      context.Context
+	acceptor ServerTransportAcceptor
+	done     chan struct{}
+}
+
+func (t *localServerTransport) Accept(acceptor ServerTransportAcceptor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.acceptor = acceptor
+}
+
+func (t *localServerTransport) Close() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		// already closed
+		break
+	default:
+		close(t.done)
+		localServersMu.Lock()
+		if localServers[t.name] == t {
+			delete(localServers, t.name)
+		}
+		localServersMu.Unlock()
+		for k := range t.m {
+			_ = k.Close()
+		}
+		t.m = nil
+	}
+	return
+}
+
+func (t *localServerTransport) Listen(ctx context.Context, notifier chan<- bool) (err error) {
+	t.mu.Lock()
+	t.ctx = ctx
+	t.mu.Unlock()
+
+	localServersMu.Lock()
+	if _, ok := localServers[t.name]; ok {
+		localServersMu.Unlock()
+		notifier <- false
+		err = errors.Errorf("listen local server failed: %s is in use", t.name)
+		return
+	}
+	localServers[t.name] = t
+	localServersMu.Unlock()
+
+	defer func() {
+		_ = t.Close()
+	}()
+
+	notifier <- true
+
+	select {
+	case <-ctx.Done():
+	case <-t.done:
+	}
+	return
+}
+
+// connect creates a new connection pair, and dispatches the server-side one to acceptor.
+func (t *localServerTransport) connect() (*LocalConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return nil, errors.Errorf("local server %s is closed", t.name)
+	default:
+	}
+	if t.acceptor == nil {
+		return nil, errNoHandler
+	}
+	c, s := NewLocalConnPair(localAddr(t.name))
+	tp := NewTransport(s)
+	t.m[tp] = struct{}{}
+	go t.acceptor(t.ctx, tp, func(tp *Transport) {
+		t.removeTransport(tp)
+	})
+	return c, nil
+}
+
+func (t *localServerTransport) removeTransport(tp *Transport) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.m, tp)
+}
+
+func localAddr(name string) string {
+	return fmt.Sprintf("local://%s", name)
+}
+
+// NewLocalServerTransport creates a new server-side transport which can be connected in current process by name.
+func NewLocalServerTransport(name string) ServerTransport {
+	return &localServerTransport{
+		name: name,
+		m:    make(map[*Transport]struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// NewLocalClientTransport creates a new transport which connects to the local server with given name.
+func NewLocalClientTransport(ctx context.Context, name string) (*Transport, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	localServersMu.Lock()
+	server, ok := localServers[name]
+	localServersMu.Unlock()
+	if !ok {
+		return nil, errors.Errorf("dial local server failed: no server named %s", name)
+	}
+	c, err := server.connect()
+	if err != nil {
+		return nil, errors.Wrap(err, "dial local server failed")
+	}
+	return NewTransport(c), nil
+}
@@ -0,0 +1,65 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalTransport(t *testing.T) {
+	const name = "fake-local-transport"
+
+	_, err := transport.NewLocalClientTransport(context.Background(), name)
+	assert.Error(t, err, "should fail without server")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accepted := make(chan *transport.Transport, 1)
+	tp := transport.NewLocalServerTransport(name)
+	tp.Accept(func(ctx context.Context, tp *transport.Transport, onClose func(*transport.Transport)) {
+		accepted <- tp
+	})
+
+	done := make(chan struct{})
+	notifier := make(chan bool)
+	go func() {
+		defer close(done)
+		assert.NoError(t, tp.Listen(ctx, notifier))
+	}()
+	require.True(t, <-notifier)
+
+	// name is in use.
+	dup := make(chan bool, 1)
+	err = transport.NewLocalServerTransport(name).Listen(ctx, dup)
+	assert.Error(t, err)
+	assert.False(t, <-dup)
+
+	client, err := transport.NewLocalClientTransport(ctx, name)
+	require.NoError(t, err)
+	server := <-accepted
+	addr, ok := client.Addr()
+	assert.True(t, ok)
+	assert.Equal(t, "local://"+name, addr)
+
+	err = client.Send(framing.NewWriteableMetadataPushFrame([]byte("fake-metadata")), true)
+	require.NoError(t, err)
+	frame, err := server.ReadFirst(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, core.FrameTypeMetadataPush, frame.Header().Type())
+	frame.Release()
+
+	cancel()
+	<-done
+
+	// connections should be closed with the server.
+	_, err = client.Connection().Read()
+	assert.Error(t, err)
+	_, err = transport.NewLocalClientTransport(context.Background(), name)
+	assert.Error(t, err, "should fail after server closed")
+}
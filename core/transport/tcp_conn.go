@@ -24,6 +24,10 @@ type TCPConn struct {
 
 func (p TCPConn) Addr() string {
 	addr := p.conn.RemoteAddr()
+	// RemoteAddr of custom connections may be nil.
+	if addr == nil {
+		return ""
+	}
 	return addr.String()
 }
 
@@ -76,7 +76,8 @@ func (t *tcpServerTransport) Listen(ctx context.Context, notifier chan<- bool) (
 	var c net.Conn
 	for {
 		c, err = t.l.Accept()
-		if err == io.EOF || isClosedErr(err) {
+		// Custom listeners may return any error after closing.
+		if err == io.EOF || isClosedErr(err) || t.isClosed() {
 			err = nil
 			break
 		}
@@ -98,6 +99,15 @@ func (t *tcpServerTransport) Listen(ctx context.Context, notifier chan<- bool) (
 	return
 }
 
+func (t *tcpServerTransport) isClosed() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
 func (t *tcpServerTransport) removeTransport(tp *Transport) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
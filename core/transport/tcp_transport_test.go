@@ -163,3 +163,35 @@ func TestNewTcpServerTransportWithAddr(t *testing.T) {
 		assert.NotNil(t, tp)
 	})
 }
+
+func TestTcpServerTransport_ListenClosedWithCustomError(t *testing.T) {
+	ctrl, listener, tp := InitTcpServerTransport(t)
+	defer ctrl.Finish()
+
+	closed := make(chan struct{})
+	listener.EXPECT().
+		Accept().
+		DoAndReturn(func() (net.Conn, error) {
+			<-closed
+			return nil, fakeErr
+		}).
+		AnyTimes()
+	listener.EXPECT().
+		Close().
+		DoAndReturn(func() error {
+			close(closed)
+			return nil
+		}).
+		Times(1)
+
+	done := make(chan struct{})
+	notifier := make(chan bool)
+	go func() {
+		defer close(done)
+		err := tp.Listen(context.Background(), notifier)
+		assert.NoError(t, err, "should ignore the error after closing")
+	}()
+	assert.True(t, <-notifier)
+	_ = tp.Close()
+	<-done
+}
@@ -70,6 +70,7 @@ type Transport struct {
 	lastRcvPos  uint64
 	once        sync.Once
 	handlers    [handlerLen]FrameHandler
+	observer    FrameObserver
 }
 
 // NewTransport creates new transport.
@@ -100,6 +101,20 @@ func (p *Transport) Handle(event EventType, handler FrameHandler) {
 	p.mu.Unlock()
 }
 
+// Observe binds an observer of frames.
+func (p *Transport) Observe(observer FrameObserver) {
+	p.mu.Lock()
+	p.observer = observer
+	p.mu.Unlock()
+}
+
+func (p *Transport) frameObserver() (observer FrameObserver) {
+	p.mu.RLock()
+	observer = p.observer
+	p.mu.RUnlock()
+	return
+}
+
 // Connection returns current connection.
 func (p *Transport) Connection() Conn {
 	return p.conn
@@ -115,20 +130,31 @@ func (p *Transport) SetLifetime(lifetime time.Duration) {
 
 // Send send a frame.
 func (p *Transport) Send(frame core.WriteableFrame, flush bool) (err error) {
+	if p == nil || p.conn == nil {
+		err = errTransportClosed
+		return
+	}
+	retainer, ok := p.conn.(frameRetainer)
+	retained := ok && retainer.retains(frame)
 	defer func() {
-		// ensure frame done when send success.
-		if err == nil {
+		// ensure frame done when send success, unless it's retained by conn.
+		if err == nil && !retained {
 			frame.Done()
 		}
 	}()
-	if p == nil || p.conn == nil {
-		err = errTransportClosed
-		return
+	observer := p.frameObserver()
+	var sent core.Frame = frame
+	if retained && observer != nil {
+		// the retained frame may be consumed by peer once it's written.
+		sent = sentFrame{header: frame.Header(), size: frame.Len()}
 	}
 	err = p.conn.Write(frame)
 	if err != nil {
 		return
 	}
+	if observer != nil {
+		observer.FrameSent(sent)
+	}
 	if !flush {
 		return
 	}
@@ -136,6 +162,20 @@ func (p *Transport) Send(frame core.WriteableFrame, flush bool) (err error) {
 	return
 }
 
+// sentFrame keeps the header and length of a frame for FrameObserver.
+type sentFrame struct {
+	header core.FrameHeader
+	size   int
+}
+
+func (f sentFrame) Header() core.FrameHeader {
+	return f.header
+}
+
+func (f sentFrame) Len() int {
+	return f.size
+}
+
 // Flush flush all bytes in current connection.
 func (p *Transport) Flush() (err error) {
 	if p == nil || p.conn == nil {
@@ -163,6 +203,8 @@ func (p *Transport) ReadFirst(ctx context.Context) (frame core.BufferedFrame, er
 		frame, err = p.conn.Read()
 		if err != nil {
 			err = errors.Wrap(err, "read first frame failed")
+		} else if observer := p.frameObserver(); observer != nil {
+			observer.FrameReceived(frame)
 		}
 	}
 	if err != nil {
@@ -229,6 +271,9 @@ func (p *Transport) Start(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
+			if observer := p.frameObserver(); observer != nil {
+				observer.FrameReceived(f)
+			}
 
 			sid := f.StreamID()
 
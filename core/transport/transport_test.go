@@ -185,6 +185,67 @@ func TestTransport_Send(t *testing.T) {
 	assert.NoError(t, err, "send failed")
 }
 
+type frameRecorder struct {
+	sent, received []core.FrameType
+}
+
+func (r *frameRecorder) FrameSent(frame core.Frame) {
+	r.sent = append(r.sent, frame.Header().Type())
+}
+
+func (r *frameRecorder) FrameReceived(frame core.Frame) {
+	r.received = append(r.received, frame.Header().Type())
+}
+
+func TestTransport_Observe(t *testing.T) {
+	ctrl, conn, tp := Init(t)
+	defer ctrl.Finish()
+
+	recorder := &frameRecorder{}
+	tp.Observe(recorder)
+
+	conn.EXPECT().Close().AnyTimes()
+	conn.EXPECT().SetDeadline(gomock.Any()).AnyTimes()
+	conn.EXPECT().Write(gomock.Any()).Return(nil).Times(1)
+	conn.EXPECT().Write(gomock.Any()).Return(fakeErr).Times(1)
+	conn.EXPECT().Flush().Times(1)
+
+	assert.NoError(t, tp.Send(framing.NewWriteableCancelFrame(1), true))
+	assert.Error(t, tp.Send(framing.NewWriteableRequestNFrame(1, 1, 0), true))
+	assert.Equal(t, []core.FrameType{core.FrameTypeCancel}, recorder.sent)
+
+	conn.EXPECT().Read().Return(framing.NewSetupFrame(core.DefaultVersion, time.Second, time.Second, nil, nil, nil, nil, nil, false), nil).Times(1)
+	first, err := tp.ReadFirst(context.Background())
+	assert.NoError(t, err)
+	first.Release()
+
+	tp.Handle(transport.OnKeepalive, func(frame core.BufferedFrame) error {
+		frame.Release()
+		return nil
+	})
+	conn.EXPECT().Read().Return(framing.NewKeepaliveFrame(0, nil, false), nil).Times(1)
+	conn.EXPECT().Read().Return(nil, io.EOF).Times(1)
+	assert.NoError(t, tp.Start(context.Background()))
+	assert.Equal(t, []core.FrameType{core.FrameTypeSetup, core.FrameTypeKeepalive}, recorder.received)
+}
+
+func TestTransport_ObserveConcurrently(t *testing.T) {
+	ctrl, conn, tp := Init(t)
+	defer ctrl.Finish()
+
+	conn.EXPECT().Write(gomock.Any()).Return(nil).AnyTimes()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tp.Observe(&frameRecorder{})
+	}()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, tp.Send(framing.NewWriteableCancelFrame(1), false))
+	}
+	<-done
+}
+
 func TestTransport_Connection(t *testing.T) {
 	ctrl, conn, tp := Init(t)
 	defer ctrl.Finish()
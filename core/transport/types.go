@@ -35,7 +35,20 @@ type Conn interface {
 	Flush() error
 }
 
+// frameRetainer is a Conn which retains some of the frames written, it marks them done after they are consumed.
+type frameRetainer interface {
+	retains(frame core.WriteableFrame) bool
+}
+
 type AddrConn interface {
 	Conn
 	Addr() string
 }
+
+// FrameObserver observes the frames sent and received by Transport.
+type FrameObserver interface {
+	// FrameSent is called after a frame is written.
+	FrameSent(frame core.Frame)
+	// FrameReceived is called after a frame is read, before it is dispatched.
+	FrameReceived(frame core.Frame)
+}
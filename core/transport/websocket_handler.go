@@ -0,0 +1,165 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+// WebsocketHandler is a server-side websocket transport which doesn't own any listener.
+// It accepts websocket connections as an http.Handler, so it can be mounted at any path of an existing http server.
+type WebsocketHandler interface {
+	ServerTransport
+	http.Handler
+}
+
+type wsHandlerTransport struct {
+	upgrader *websocket.Upgrader
+	opts     *websocketOptions
+	mu       sync.Mutex
+	ctx      context.Context
+	acceptor ServerTransportAcceptor
+	m        map[*Transport]struct{}
+	done     chan struct{}
+}
+
+func (ws *wsHandlerTransport) Accept(acceptor ServerTransportAcceptor) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.acceptor = acceptor
+}
+
+func (ws *wsHandlerTransport) Close() (err error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	select {
+	case <-ws.done:
+		// already closed
+		break
+	default:
+		close(ws.done)
+		// close transports
+		for k := range ws.m {
+			_ = k.Close()
+		}
+		ws.m = nil
+	}
+	return
+}
+
+// Listen doesn't listen any address, it only blocks until the context is done or the transport is closed.
+// The websocket connections will be accepted by ServeHTTP after listening.
+func (ws *wsHandlerTransport) Listen(ctx context.Context, notifier chan<- bool) (err error) {
+	ws.mu.Lock()
+	select {
+	case <-ws.done:
+		ws.mu.Unlock()
+		notifier <- false
+		return errTransportClosed
+	default:
+		ws.ctx = ctx
+	}
+	ws.mu.Unlock()
+
+	defer func() {
+		_ = ws.Close()
+	}()
+
+	notifier <- true
+
+	select {
+	case <-ctx.Done():
+	case <-ws.done:
+	}
+	return
+}
+
+func (ws *wsHandlerTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, acceptor, ok := ws.ready()
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	// upgrade websocket
+	c, err := ws.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Errorf("create websocket conn failed: %s\n", err.Error())
+		return
+	}
+	ws.opts.configure(c)
+
+	// new websocket transport
+	tp := NewTransport(NewWebsocketConnection(c))
+
+	if ws.putTransport(tp) {
+		// accept async
+		go acceptor(ctx, tp, func(tp *Transport) {
+			// remove transport
+			ws.removeTransport(tp)
+		})
+	} else {
+		_ = tp.Close()
+	}
+}
+
+// ready returns true if the transport is listening and not closed.
+func (ws *wsHandlerTransport) ready() (ctx context.Context, acceptor ServerTransportAcceptor, ok bool) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	select {
+	case <-ws.done:
+		return
+	default:
+	}
+	ctx, acceptor = ws.ctx, ws.acceptor
+	ok = ctx != nil && acceptor != nil
+	return
+}
+
+func (ws *wsHandlerTransport) removeTransport(tp *Transport) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.m == nil {
+		return
+	}
+	delete(ws.m, tp)
+}
+
+func (ws *wsHandlerTransport) putTransport(tp *Transport) bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	select {
+	case <-ws.done:
+		// already closed
+		return false
+	default:
+		if ws.m == nil {
+			return false
+		}
+		// put transport
+		ws.m[tp] = struct{}{}
+		return true
+	}
+}
+
+// NewWebsocketHandler creates a new server-side websocket transport which can be used as an http.Handler.
+// If the server is not nil, the transport will be closed when the server is shut down.
+func NewWebsocketHandler(upgrader *websocket.Upgrader, server *http.Server, opts ...WebsocketOption) WebsocketHandler {
+	o := newWebsocketOptions(opts)
+	ws := &wsHandlerTransport{
+		upgrader: o.upgrader(upgrader),
+		opts:     o,
+		m:        make(map[*Transport]struct{}),
+		done:     make(chan struct{}),
+	}
+	if server != nil {
+		server.RegisterOnShutdown(func() {
+			_ = ws.Close()
+		})
+	}
+	return ws
+}
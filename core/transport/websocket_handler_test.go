@@ -0,0 +1,65 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebsocketHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	ts := httptest.NewUnstartedServer(mux)
+	server := ts.Config
+	tp := transport.NewWebsocketHandler(nil, server)
+	mux.Handle("/rsocket", tp)
+	ts.Start()
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/rsocket"
+
+	// not ready before listening.
+	_, err := transport.NewWebsocketClientTransport(context.Background(), url, nil, nil, nil)
+	assert.Error(t, err)
+
+	accepted := make(chan *transport.Transport, 1)
+	tp.Accept(func(ctx context.Context, tp *transport.Transport, onClose func(*transport.Transport)) {
+		accepted <- tp
+	})
+
+	done := make(chan struct{})
+	notifier := make(chan bool)
+	go func() {
+		defer close(done)
+		assert.NoError(t, tp.Listen(context.Background(), notifier))
+	}()
+	require.True(t, <-notifier)
+
+	client, err := transport.NewWebsocketClientTransport(context.Background(), url, nil, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	select {
+	case <-accepted:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "accept timeout")
+	}
+
+	// transport follows the shutdown of host server.
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "transport should be closed after server shutdown")
+	}
+	_, err = client.Connection().Read()
+	assert.Error(t, err)
+}
@@ -0,0 +1,195 @@
+package transport
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebsocketSubprotocol is the websocket subprotocol of RSocket.
+const WebsocketSubprotocol = "rsocket"
+
+// WebsocketOption is the option of websocket transport.
+type WebsocketOption func(*websocketOptions)
+
+type websocketOptions struct {
+	compression      bool
+	compressionLevel int
+	readLimit        int64
+	writeBufferPool  websocket.BufferPool
+	pingInterval     time.Duration
+	pingHandler      func(appData string) error
+	pongHandler      func(appData string) error
+	subprotocols     []string
+	checkOrigin      func(r *http.Request) bool
+}
+
+// WithWebsocketCompression enables the per-message compression negotiation (permessage-deflate).
+// The level is the compression level of flate, it can be between flate.BestSpeed and flate.BestCompression.
+// Zero means the default compression level.
+func WithWebsocketCompression(level int) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.compression = true
+		o.compressionLevel = level
+	}
+}
+
+// WithWebsocketReadLimit sets the max size in bytes of a message read from the peer.
+// The connection will be closed if a message exceeds the limit.
+func WithWebsocketReadLimit(limit int64) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.readLimit = limit
+	}
+}
+
+// WithWebsocketWriteBufferPool sets the pool of buffers for write operations.
+func WithWebsocketWriteBufferPool(pool websocket.BufferPool) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.writeBufferPool = pool
+	}
+}
+
+// WithWebsocketPingInterval sends a ping message to the peer at the given interval.
+// It helps to keep the connection alive through proxies which close idle connections.
+func WithWebsocketPingInterval(interval time.Duration) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.pingInterval = interval
+	}
+}
+
+// WithWebsocketPingHandler sets the handler of ping messages received from the peer.
+// The default handler sends a pong message to the peer.
+func WithWebsocketPingHandler(h func(appData string) error) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.pingHandler = h
+	}
+}
+
+// WithWebsocketPongHandler sets the handler of pong messages received from the peer.
+func WithWebsocketPongHandler(h func(appData string) error) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.pongHandler = h
+	}
+}
+
+// WithWebsocketSubprotocols sets the subprotocols in order of preference, eg: WebsocketSubprotocol.
+func WithWebsocketSubprotocols(protocols ...string) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.subprotocols = protocols
+	}
+}
+
+// WithWebsocketCheckOrigin sets the function which checks the origin of requests, it only works on the server side.
+// See AllowAllOrigins, SameOrigin and AllowOrigins.
+func WithWebsocketCheckOrigin(checkOrigin func(r *http.Request) bool) WebsocketOption {
+	return func(o *websocketOptions) {
+		o.checkOrigin = checkOrigin
+	}
+}
+
+func newWebsocketOptions(opts []WebsocketOption) *websocketOptions {
+	o := &websocketOptions{}
+	for _, it := range opts {
+		it(o)
+	}
+	return o
+}
+
+// upgrader returns a copy of upgrader with the options applied.
+func (o *websocketOptions) upgrader(upgrader *websocket.Upgrader) *websocket.Upgrader {
+	if upgrader == nil {
+		upgrader = newDefaultUpgrader()
+	} else {
+		copied := *upgrader
+		upgrader = &copied
+	}
+	if o.compression {
+		upgrader.EnableCompression = true
+	}
+	if o.writeBufferPool != nil {
+		upgrader.WriteBufferPool = o.writeBufferPool
+	}
+	if len(o.subprotocols) > 0 {
+		upgrader.Subprotocols = o.subprotocols
+	}
+	if o.checkOrigin != nil {
+		upgrader.CheckOrigin = o.checkOrigin
+	}
+	return upgrader
+}
+
+// dialer applies the options to websocket dialer.
+func (o *websocketOptions) dialer(dialer *websocket.Dialer) *websocket.Dialer {
+	dialer.EnableCompression = o.compression
+	dialer.WriteBufferPool = o.writeBufferPool
+	dialer.Subprotocols = o.subprotocols
+	return dialer
+}
+
+// configure applies the options to a websocket connection.
+func (o *websocketOptions) configure(c *websocket.Conn) {
+	if o.compression && o.compressionLevel != 0 {
+		_ = c.SetCompressionLevel(o.compressionLevel)
+	}
+	if o.readLimit > 0 {
+		c.SetReadLimit(o.readLimit)
+	}
+	if o.pingHandler != nil {
+		c.SetPingHandler(o.pingHandler)
+	}
+	if o.pongHandler != nil {
+		c.SetPongHandler(o.pongHandler)
+	}
+	if o.pingInterval > 0 {
+		go keepPing(c, o.pingInterval)
+	}
+}
+
+// keepPing sends ping messages until the connection is closed.
+func keepPing(c *websocket.Conn, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := c.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+			return
+		}
+	}
+}
+
+// AllowAllOrigins accepts requests from any origin.
+func AllowAllOrigins(_ *http.Request) bool {
+	return true
+}
+
+// SameOrigin accepts requests without Origin header, or the host of Origin header is same as the Host header.
+func SameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// AllowOrigins accepts requests without Origin header, or the Origin header matches one of the origins.
+// The origin should be in the form of "scheme://host[:port]", eg: "https://example.com".
+func AllowOrigins(origins ...string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, it := range origins {
+			if strings.EqualFold(origin, it) {
+				return true
+			}
+		}
+		return false
+	}
+}
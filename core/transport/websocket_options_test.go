@@ -0,0 +1,127 @@
+package transport_test
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOriginHelpers(t *testing.T) {
+	newRequest := func(host, origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+	assert.True(t, transport.AllowAllOrigins(newRequest("example.com", "http://foo.com")))
+
+	assert.True(t, transport.SameOrigin(newRequest("example.com", "")))
+	assert.True(t, transport.SameOrigin(newRequest("example.com", "https://example.com")))
+	assert.False(t, transport.SameOrigin(newRequest("example.com", "https://foo.com")))
+
+	allow := transport.AllowOrigins("https://foo.com", "https://bar.com")
+	assert.True(t, allow(newRequest("example.com", "")))
+	assert.True(t, allow(newRequest("example.com", "https://BAR.com")))
+	assert.False(t, allow(newRequest("example.com", "http://foo.com")))
+}
+
+func TestWebsocketOptions(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		pings int
+	)
+	tp := transport.NewWebsocketHandler(
+		nil,
+		nil,
+		transport.WithWebsocketCompression(1),
+		transport.WithWebsocketReadLimit(128),
+		transport.WithWebsocketSubprotocols(transport.WebsocketSubprotocol),
+		transport.WithWebsocketCheckOrigin(transport.AllowOrigins("https://foo.com")),
+		transport.WithWebsocketPingHandler(func(appData string) error {
+			mu.Lock()
+			pings++
+			mu.Unlock()
+			return nil
+		}),
+	)
+	ts := httptest.NewServer(tp)
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	accepted := make(chan *transport.Transport, 1)
+	tp.Accept(func(ctx context.Context, tp *transport.Transport, onClose func(*transport.Transport)) {
+		accepted <- tp
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := make(chan bool)
+	go func() {
+		_ = tp.Listen(ctx, notifier)
+	}()
+	require.True(t, <-notifier)
+
+	// bad origin
+	header := http.Header{}
+	header.Set("Origin", "https://bar.com")
+	_, err := transport.NewWebsocketClientTransport(ctx, url, nil, header, nil)
+	assert.Error(t, err)
+
+	// negotiation
+	dialer := &websocket.Dialer{
+		EnableCompression: true,
+		Subprotocols:      []string{transport.WebsocketSubprotocol},
+	}
+	conn, resp, err := dialer.Dial(url, nil)
+	require.NoError(t, err)
+	assert.Equal(t, transport.WebsocketSubprotocol, conn.Subprotocol())
+	assert.Contains(t, resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+	_ = conn.Close()
+	<-accepted
+
+	client, err := transport.NewWebsocketClientTransport(
+		ctx,
+		url,
+		nil,
+		nil,
+		nil,
+		transport.WithWebsocketCompression(0),
+		transport.WithWebsocketSubprotocols(transport.WebsocketSubprotocol),
+		transport.WithWebsocketPingInterval(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+	server := <-accepted
+
+	// wait for pings
+	time.Sleep(100 * time.Millisecond)
+
+	small := framing.NewWriteableMetadataPushFrame([]byte("small"))
+	require.NoError(t, client.Send(small, true))
+	frame, err := server.Connection().Read()
+	require.NoError(t, err)
+	frame.Release()
+
+	mu.Lock()
+	assert.NotZero(t, pings, "should receive pings")
+	mu.Unlock()
+
+	// exceed the read limit, use random bytes which cannot be compressed.
+	b := make([]byte, 1024)
+	_, _ = rand.Read(b)
+	large := framing.NewWriteableMetadataPushFrame(b)
+	require.NoError(t, client.Send(large, true))
+	_, err = server.Connection().Read()
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ const defaultWebsocketPath = "/"
 
 type wsServerTransport struct {
 	upgrader *websocket.Upgrader
+	opts     *websocketOptions
 	mu       sync.Mutex
 	path     string
 	acceptor ServerTransportAcceptor
@@ -88,6 +89,7 @@ func (ws *wsServerTransport) Listen(ctx context.Context, notifier chan<- bool) (
 			logger.Errorf("create websocket conn failed: %s\n", err.Error())
 			return
 		}
+		ws.opts.configure(c)
 
 		// new websocket transport
 		tp := NewTransport(NewWebsocketConnection(c))
@@ -138,22 +140,25 @@ func (ws *wsServerTransport) putTransport(tp *Transport) bool {
 	}
 }
 
+func newDefaultUpgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+}
+
 // NewWebsocketServerTransport creates a new server-side transport.
-func NewWebsocketServerTransport(f ListenerFactory, path string, upgrader *websocket.Upgrader) ServerTransport {
+func NewWebsocketServerTransport(f ListenerFactory, path string, upgrader *websocket.Upgrader, opts ...WebsocketOption) ServerTransport {
 	if path == "" {
 		path = defaultWebsocketPath
 	}
-	if upgrader == nil {
-		upgrader = &websocket.Upgrader{
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-		}
-	}
+	o := newWebsocketOptions(opts)
 	return &wsServerTransport{
-		upgrader: upgrader,
+		upgrader: o.upgrader(upgrader),
+		opts:     o,
 		path:     path,
 		f:        f,
 		m:        make(map[*Transport]struct{}),
@@ -162,7 +167,7 @@ func NewWebsocketServerTransport(f ListenerFactory, path string, upgrader *webso
 }
 
 // NewWebsocketServerTransportWithAddr creates a new server-side transport.
-func NewWebsocketServerTransportWithAddr(addr string, path string, upgrader *websocket.Upgrader, config *tls.Config) ServerTransport {
+func NewWebsocketServerTransportWithAddr(addr string, path string, upgrader *websocket.Upgrader, config *tls.Config, opts ...WebsocketOption) ServerTransport {
 	f := func(ctx context.Context) (net.Listener, error) {
 		var c net.ListenConfig
 		l, err := c.Listen(ctx, "tcp", addr)
@@ -174,16 +179,17 @@ func NewWebsocketServerTransportWithAddr(addr string, path string, upgrader *web
 		}
 		return tls.NewListener(l, config), nil
 	}
-	return NewWebsocketServerTransport(f, path, upgrader)
+	return NewWebsocketServerTransport(f, path, upgrader, opts...)
 }
 
 // NewWebsocketClientTransport creates a new client-side transport.
-func NewWebsocketClientTransport(ctx context.Context, url string, config *tls.Config, header http.Header, proxy func(*http.Request) (*url.URL, error)) (*Transport, error) {
-	dial := &websocket.Dialer{
+func NewWebsocketClientTransport(ctx context.Context, url string, config *tls.Config, header http.Header, proxy func(*http.Request) (*url.URL, error), opts ...WebsocketOption) (*Transport, error) {
+	o := newWebsocketOptions(opts)
+	dial := o.dialer(&websocket.Dialer{
 		Proxy:            proxy,
 		HandshakeTimeout: 45 * time.Second,
 		TLSClientConfig:  config,
-	}
+	})
 	conn, resp, err := dial.DialContext(ctx, url, header)
 	if err != nil {
 		if resp != nil {
@@ -192,5 +198,6 @@ func NewWebsocketClientTransport(ctx context.Context, url string, config *tls.Co
 			return nil, errors.Wrap(err, "dial websocket failed")
 		}
 	}
+	o.configure(conn)
 	return NewTransport(NewWebsocketConnection(conn)), nil
 }
@@ -89,6 +89,9 @@ func (f FrameFlag) String() string {
 	if f.Check(FlagIgnore) {
 		foo = append(foo, "I")
 	}
+	if f.Check(FlagCompressed) {
+		foo = append(foo, "Z")
+	}
 	return strings.Join(foo, "|")
 }
 
@@ -103,6 +106,10 @@ const (
 	FlagResume  = FlagFollow
 	FlagLease   = FlagComplete
 	FlagRespond = FlagFollow
+
+	// FlagCompressed is an extension flag of rsocket-go, it means the payload data is compressed.
+	// It is only used when compression is negotiated in SETUP, and it is set on the first fragment of payload.
+	FlagCompressed FrameFlag = 1 << 4
 )
 
 // Check returns true if mask exists.
@@ -11,3 +11,9 @@ func TestFrameFlag_String(t *testing.T) {
 	f := core.FlagNext | core.FlagComplete | core.FlagFollow | core.FlagMetadata | core.FlagIgnore
 	assert.True(t, f.String() != "")
 }
+
+func TestFrameFlag_Compressed(t *testing.T) {
+	f := core.FlagNext | core.FlagCompressed
+	assert.True(t, f.Check(core.FlagCompressed))
+	assert.Equal(t, "N|Z", f.String())
+}
@@ -1,6 +1,7 @@
 package extension
 
 import (
+	"encoding/binary"
 	"errors"
 )
 
@@ -18,6 +19,8 @@ const (
 var (
 	errInvalidAuthBytes     = errors.New("invalid authentication bytes")
 	errAuthTypeLengthExceed = errors.New("invalid authType length: exceed 127 bytes")
+	errUsernameLengthExceed = errors.New("invalid username length: exceed 65535 bytes")
+	errNotSimpleAuth        = errors.New("authentication type is not simple")
 )
 
 type wellKnownAuthenticationType uint8
@@ -77,6 +80,51 @@ func MustNewAuthentication(authType string, payload []byte) *Authentication {
 	return auth
 }
 
+// NewSimpleAuthentication creates a new Authentication of the well-known simple type.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Security/Simple.md
+func NewSimpleAuthentication(username string, password []byte) (*Authentication, error) {
+	if len(username) > 0xFFFF {
+		return nil, errUsernameLengthExceed
+	}
+	raw := make([]byte, 2, 2+len(username)+len(password))
+	binary.BigEndian.PutUint16(raw, uint16(len(username)))
+	raw = append(raw, username...)
+	raw = append(raw, password...)
+	return &Authentication{
+		typ:     _simpleAuth,
+		payload: raw,
+	}, nil
+}
+
+// NewBearerAuthentication creates a new Authentication of the well-known bearer type.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Security/Bearer.md
+func NewBearerAuthentication(token string) *Authentication {
+	return &Authentication{
+		typ:     _bearerAuth,
+		payload: []byte(token),
+	}
+}
+
+// ParseSimple returns username and password of a simple Authentication.
+func (a Authentication) ParseSimple() (username string, password []byte, err error) {
+	if a.typ != _simpleAuth {
+		err = errNotSimpleAuth
+		return
+	}
+	if len(a.payload) < 2 {
+		err = errInvalidAuthBytes
+		return
+	}
+	n := int(binary.BigEndian.Uint16(a.payload))
+	if len(a.payload) < 2+n {
+		err = errInvalidAuthBytes
+		return
+	}
+	username = string(a.payload[2 : 2+n])
+	password = a.payload[2+n:]
+	return
+}
+
 // Bytes encodes current Authentication to byte slice.
 func (a Authentication) Bytes() (raw []byte) {
 	if w, ok := parseWellKnownAuthenticateType(a.typ); ok {
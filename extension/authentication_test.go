@@ -67,3 +67,32 @@ func BenchmarkParseAuthentication(b *testing.B) {
 		_, _ = extension.ParseAuthentication(raw)
 	}
 }
+
+func TestSimpleAuthentication(t *testing.T) {
+	auth, err := extension.NewSimpleAuthentication("user", []byte("pass"))
+	assert.NoError(t, err)
+	assert.Equal(t, "simple", auth.Type())
+	assert.True(t, auth.IsWellKnown())
+
+	parsed, err := extension.ParseAuthentication(auth.Bytes())
+	assert.NoError(t, err)
+	username, password, err := parsed.ParseSimple()
+	assert.NoError(t, err)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, []byte("pass"), password)
+
+	_, err = extension.NewSimpleAuthentication(strings.Repeat("x", 0x10000), nil)
+	assert.Error(t, err)
+	_, _, err = extension.NewBearerAuthentication("token").ParseSimple()
+	assert.Error(t, err)
+	_, _, err = extension.MustNewAuthentication("simple", []byte{0x00, 0x05, 'a'}).ParseSimple()
+	assert.Error(t, err)
+}
+
+func TestBearerAuthentication(t *testing.T) {
+	auth := extension.NewBearerAuthentication("token")
+	parsed, err := extension.ParseAuthentication(auth.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "bearer", parsed.Type())
+	assert.Equal(t, []byte("token"), parsed.Payload())
+}
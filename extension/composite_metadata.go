@@ -2,6 +2,7 @@ package extension
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/rsocket/rsocket-go/internal/bytesconv"
 	"math"
@@ -9,6 +10,8 @@ import (
 	"github.com/rsocket/rsocket-go/internal/u24"
 )
 
+var errBrokenCompositeMetadata = errors.New("broken composite metadata")
+
 // CompositeMetadata provides multi Metadata payloads with different MIME types.
 type CompositeMetadata []byte
 
@@ -18,6 +21,12 @@ type CompositeMetadataScanner struct {
 	raw    []byte
 }
 
+// CompositeMetadataEntry is a decoded entry of CompositeMetadata.
+type CompositeMetadataEntry struct {
+	MimeType string
+	Metadata []byte
+}
+
 // CompositeMetadataBuilder can be used to build a CompositeMetadata.
 type CompositeMetadataBuilder struct {
 	k []interface{}
@@ -32,6 +41,37 @@ func (c CompositeMetadata) Scanner() *CompositeMetadataScanner {
 	}
 }
 
+// Entries decodes all entries of CompositeMetadata.
+// The metadata of entries shares the underlying bytes of CompositeMetadata.
+func (c CompositeMetadata) Entries() (entries []CompositeMetadataEntry, err error) {
+	scanner := c.Scanner()
+	for scanner.Scan() {
+		mimeType, metadata, e := scanner.Metadata()
+		if e != nil {
+			return nil, e
+		}
+		entries = append(entries, CompositeMetadataEntry{
+			MimeType: mimeType,
+			Metadata: metadata,
+		})
+	}
+	return
+}
+
+// Lookup returns the metadata of the first entry with the MIME type, ok is false if there's no such entry.
+func (c CompositeMetadata) Lookup(mimeType string) (metadata []byte, ok bool, err error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.MimeType == mimeType {
+			return entry.Metadata, true, nil
+		}
+	}
+	return
+}
+
 // Scan returns true when scanner has more content.
 func (c *CompositeMetadataScanner) Scan() bool {
 	return c.offset < len(c.raw)
@@ -66,10 +106,22 @@ func (c *CompositeMetadataScanner) decodeCompositeMetadataOnce(raw []byte) (leng
 	} else {
 		mimeTypeLen := int(idOrLen) + 1
 		size += mimeTypeLen
+		if len(raw) < size {
+			err = errBrokenCompositeMetadata
+			return
+		}
 		mimeType = string(raw[1 : 1+mimeTypeLen])
 	}
+	if len(raw) < size+3 {
+		err = errBrokenCompositeMetadata
+		return
+	}
 	metadataLen := u24.NewUint24Bytes(raw[size : size+3]).AsInt()
 	length = size + 3 + metadataLen
+	if len(raw) < length {
+		err = errBrokenCompositeMetadata
+		return
+	}
 	metadata = raw[size+3 : length]
 	return
 }
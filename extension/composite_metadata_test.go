@@ -21,3 +21,42 @@ func TestCompositeMetadataBuilder_Build(t *testing.T) {
 		fmt.Println("mimeType:", mimeType, "metadata:", string(metadata))
 	}
 }
+
+func TestCompositeMetadata_Entries(t *testing.T) {
+	cm, err := NewCompositeMetadataBuilder().
+		PushString("application/custom", "not well").
+		PushWellKnownString(TextPlain, "text").
+		Build()
+	assert.NoError(t, err, "build composite metadata failed")
+	entries, err := cm.Entries()
+	assert.NoError(t, err)
+	assert.Equal(t, []CompositeMetadataEntry{
+		{MimeType: "application/custom", Metadata: []byte("not well")},
+		{MimeType: TextPlain.String(), Metadata: []byte("text")},
+	}, entries)
+
+	_, err = cm[:len(cm)-1].Entries()
+	assert.Error(t, err, "should fail with broken composite metadata")
+}
+
+func TestCompositeMetadata_Lookup(t *testing.T) {
+	cm, err := NewCompositeMetadataBuilder().
+		PushString("application/custom", "not well").
+		PushWellKnownString(TextPlain, "first").
+		PushWellKnownString(TextPlain, "second").
+		Build()
+	assert.NoError(t, err, "build composite metadata failed")
+
+	metadata, ok, err := cm.Lookup(TextPlain.String())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "first", string(metadata))
+
+	_, ok, err = cm.Lookup(ApplicationJSON.String())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = cm[:3].Lookup(TextPlain.String())
+	assert.Error(t, err, "should fail with broken composite metadata")
+	assert.False(t, ok)
+}
@@ -0,0 +1,99 @@
+package extension
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	errMimeTypeEmpty        = errors.New("MIME type is empty")
+	errMimeTypeLengthExceed = errors.New("length of MIME type exceed 128")
+)
+
+// EncodeMimeType encodes the MIME type of data to raw bytes of MessageMimeType entry.
+// Well-known MIME types are encoded as ids, others are encoded as strings.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/PerStreamDataMimeTypesDefinition.md
+func EncodeMimeType(mimeType string) (raw []byte, err error) {
+	return appendMimeType(nil, mimeType)
+}
+
+// EncodeAcceptMimeTypes encodes the acceptable MIME types of data to raw bytes of MessageAcceptMimeTypes entry.
+// The MIME types should be ordered by preference.
+func EncodeAcceptMimeTypes(mimeType string, otherMimeTypes ...string) (raw []byte, err error) {
+	if raw, err = appendMimeType(nil, mimeType); err != nil {
+		return
+	}
+	for _, it := range otherMimeTypes {
+		if raw, err = appendMimeType(raw, it); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ParseMimeType parses the MIME type in MessageMimeType entry.
+func ParseMimeType(raw []byte) (mimeType string, err error) {
+	mimeType, n, err := readMimeType(raw)
+	if err != nil {
+		return
+	}
+	if n != len(raw) {
+		err = fmt.Errorf("bad MIME type: %d extra bytes", len(raw)-n)
+		mimeType = ""
+	}
+	return
+}
+
+// ParseAcceptMimeTypes parses the MIME types in MessageAcceptMimeTypes entry.
+func ParseAcceptMimeTypes(raw []byte) (mimeTypes []string, err error) {
+	for cursor := 0; cursor < len(raw); {
+		mimeType, n, e := readMimeType(raw[cursor:])
+		if e != nil {
+			return nil, e
+		}
+		mimeTypes = append(mimeTypes, mimeType)
+		cursor += n
+	}
+	return
+}
+
+func appendMimeType(raw []byte, mimeType string) ([]byte, error) {
+	if well, ok := ParseMIME(mimeType); ok {
+		return append(raw, 0x80|byte(well)), nil
+	}
+	size := len(mimeType)
+	if size < 1 {
+		return nil, errMimeTypeEmpty
+	}
+	if size > 0x80 {
+		return nil, errMimeTypeLengthExceed
+	}
+	raw = append(raw, byte(size-1))
+	return append(raw, mimeType...), nil
+}
+
+// readMimeType reads a MIME type, n is the number of consumed bytes.
+func readMimeType(raw []byte) (mimeType string, n int, err error) {
+	if len(raw) < 1 {
+		err = errMimeTypeEmpty
+		return
+	}
+	if raw[0]&0x80 != 0 {
+		id := MIME(raw[0] & 0x7F)
+		if mimeType = id.String(); len(mimeType) < 1 {
+			err = fmt.Errorf("bad MIME type: unknown well-known id 0x%02X", byte(id))
+			return
+		}
+		n = 1
+		return
+	}
+	n = int(raw[0]) + 2
+	if n > len(raw) {
+		err = fmt.Errorf("bad MIME type: illegal length %d", n-1)
+		n = 0
+		return
+	}
+	mimeType = string(raw[1:n])
+	return
+}
@@ -0,0 +1,54 @@
+package extension_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMimeType(t *testing.T) {
+	raw, err := extension.EncodeMimeType("application/json")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x80 | byte(extension.ApplicationJSON)}, raw)
+	mimeType, err := extension.ParseMimeType(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", mimeType)
+
+	raw, err = extension.EncodeMimeType("application/x.custom")
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{byte(len("application/x.custom") - 1)}, "application/x.custom"...), raw)
+	mimeType, err = extension.ParseMimeType(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "application/x.custom", mimeType)
+
+	_, err = extension.EncodeMimeType("")
+	assert.Error(t, err)
+	_, err = extension.EncodeMimeType(strings.Repeat("x", 129))
+	assert.Error(t, err)
+	raw, err = extension.EncodeMimeType(strings.Repeat("x", 128))
+	assert.NoError(t, err)
+	mimeType, err = extension.ParseMimeType(raw)
+	assert.NoError(t, err)
+	assert.Len(t, mimeType, 128)
+
+	for _, bad := range [][]byte{nil, {0x80 | 0x70}, {0x05, 'a'}, {0x80, 0x00}} {
+		_, err = extension.ParseMimeType(bad)
+		assert.Error(t, err)
+	}
+}
+
+func TestEncodeAcceptMimeTypes(t *testing.T) {
+	raw, err := extension.EncodeAcceptMimeTypes("application/cbor", "application/x.custom", "application/json")
+	require.NoError(t, err)
+	mimeTypes, err := extension.ParseAcceptMimeTypes(raw)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"application/cbor", "application/x.custom", "application/json"}, mimeTypes)
+
+	_, err = extension.EncodeAcceptMimeTypes("application/json", "")
+	assert.Error(t, err)
+	_, err = extension.ParseAcceptMimeTypes(append(raw, 0x05, 'a'))
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package extension
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// TimeoutMimeType is the MIME type of the entry in CompositeMetadata which carries the timeout of a request.
+// The entry is a 64-bit big-endian unsigned integer, which is the remaining time of request in milliseconds.
+// It is relative to the time the request is sent, so it doesn't depend on the clocks of requester and responder.
+const TimeoutMimeType = "message/x.rsocket-go.timeout.v0"
+
+// EncodeTimeout encodes the timeout of request to raw bytes.
+// The timeout is truncated to milliseconds, and negative timeout is encoded as zero.
+func EncodeTimeout(timeout time.Duration) []byte {
+	if timeout < 0 {
+		timeout = 0
+	}
+	raw := make([]byte, 8)
+	binary.BigEndian.PutUint64(raw, uint64(timeout/time.Millisecond))
+	return raw
+}
+
+// ParseTimeout parses the timeout of request from raw bytes.
+func ParseTimeout(raw []byte) (timeout time.Duration, err error) {
+	if len(raw) != 8 {
+		err = fmt.Errorf("bad timeout: illegal length %d", len(raw))
+		return
+	}
+	millis := binary.BigEndian.Uint64(raw)
+	if millis > uint64(1<<63-1)/uint64(time.Millisecond) {
+		err = fmt.Errorf("bad timeout: %d milliseconds overflows", millis)
+		return
+	}
+	timeout = time.Duration(millis) * time.Millisecond
+	return
+}
@@ -0,0 +1,28 @@
+package extension_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	raw := extension.EncodeTimeout(1500 * time.Millisecond)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0x05, 0xDC}, raw)
+	timeout, err := extension.ParseTimeout(raw)
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, timeout)
+
+	timeout, err = extension.ParseTimeout(extension.EncodeTimeout(-time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, timeout)
+
+	_, err = extension.ParseTimeout(raw[:4])
+	assert.Error(t, err)
+	_, err = extension.ParseTimeout([]byte{math.MaxUint8, 0, 0, 0, 0, 0, 0, 0})
+	assert.Error(t, err)
+}
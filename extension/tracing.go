@@ -0,0 +1,175 @@
+package extension
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+const (
+	_tracingIDsSet          = 0x80
+	_tracingDebug           = 0x40
+	_tracingSampled         = 0x20
+	_tracingNotSampled      = 0x10
+	_tracingExtendedTraceID = 0x08
+	_tracingIncludeParentID = 0x04
+)
+
+// TracingSampling is the sampling decision of tracing.
+type TracingSampling int8
+
+const (
+	// TracingUnspecified means the sampling decision is deferred.
+	TracingUnspecified TracingSampling = iota
+	// TracingSampled means the trace should be reported.
+	TracingSampled
+	// TracingNotSampled means the trace should not be reported.
+	TracingNotSampled
+	// TracingDebug means the trace should be reported and bypass any sampling.
+	TracingDebug
+)
+
+func (s TracingSampling) String() string {
+	switch s {
+	case TracingSampled:
+		return "SAMPLED"
+	case TracingNotSampled:
+		return "NOT_SAMPLED"
+	case TracingDebug:
+		return "DEBUG"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// Tracing is the Zipkin tracing context in MessageZipkin entry.
+// The ids are absent if both TraceID and SpanID are zero, then only the sampling decision is propagated.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Tracing-Zipkin.md
+type Tracing struct {
+	// TraceIDHigh is the high 64 bits of a 128-bit trace id, zero means the trace id is 64-bit.
+	TraceIDHigh uint64
+	// TraceID is the low 64 bits of trace id.
+	TraceID uint64
+	// SpanID is the id of span.
+	SpanID uint64
+	// ParentID is the id of parent span, zero means no parent.
+	ParentID uint64
+	// Sampling is the sampling decision.
+	Sampling TracingSampling
+}
+
+// HasIDs returns true if the ids are present.
+func (t Tracing) HasIDs() bool {
+	return t.TraceIDHigh != 0 || t.TraceID != 0 || t.SpanID != 0
+}
+
+// TraceIDString returns the trace id as lower-hex, which has 16 or 32 characters.
+func (t Tracing) TraceIDString() string {
+	if t.TraceIDHigh != 0 {
+		return fmt.Sprintf("%016x%016x", t.TraceIDHigh, t.TraceID)
+	}
+	return fmt.Sprintf("%016x", t.TraceID)
+}
+
+// SpanIDString returns the span id as lower-hex.
+func (t Tracing) SpanIDString() string {
+	return fmt.Sprintf("%016x", t.SpanID)
+}
+
+// Bytes encodes current Tracing to byte slice.
+func (t Tracing) Bytes() []byte {
+	var flags byte
+	switch t.Sampling {
+	case TracingSampled:
+		flags |= _tracingSampled
+	case TracingNotSampled:
+		flags |= _tracingNotSampled
+	case TracingDebug:
+		flags |= _tracingDebug
+	}
+	if !t.HasIDs() {
+		return []byte{flags}
+	}
+	flags |= _tracingIDsSet
+	size := 17
+	if t.TraceIDHigh != 0 {
+		flags |= _tracingExtendedTraceID
+		size += 8
+	}
+	if t.ParentID != 0 {
+		flags |= _tracingIncludeParentID
+		size += 8
+	}
+	raw := make([]byte, size)
+	raw[0] = flags
+	cursor := 1
+	put := func(v uint64) {
+		binary.BigEndian.PutUint64(raw[cursor:cursor+8], v)
+		cursor += 8
+	}
+	if t.TraceIDHigh != 0 {
+		put(t.TraceIDHigh)
+	}
+	put(t.TraceID)
+	put(t.SpanID)
+	if t.ParentID != 0 {
+		put(t.ParentID)
+	}
+	return raw
+}
+
+func (t Tracing) String() string {
+	if !t.HasIDs() {
+		return "Tracing{sampling=" + t.Sampling.String() + "}"
+	}
+	return "Tracing{traceId=" + t.TraceIDString() +
+		",spanId=" + t.SpanIDString() +
+		",parentId=" + strconv.FormatUint(t.ParentID, 16) +
+		",sampling=" + t.Sampling.String() + "}"
+}
+
+// ParseTracing parses Tracing from raw bytes of MessageZipkin entry.
+func ParseTracing(raw []byte) (t Tracing, err error) {
+	if len(raw) < 1 {
+		err = fmt.Errorf("bad tracing: empty")
+		return
+	}
+	flags := raw[0]
+	switch {
+	case flags&_tracingDebug != 0:
+		t.Sampling = TracingDebug
+	case flags&_tracingSampled != 0:
+		t.Sampling = TracingSampled
+	case flags&_tracingNotSampled != 0:
+		t.Sampling = TracingNotSampled
+	}
+	if flags&_tracingIDsSet == 0 {
+		return
+	}
+	size := 17
+	if flags&_tracingExtendedTraceID != 0 {
+		size += 8
+	}
+	if flags&_tracingIncludeParentID != 0 {
+		size += 8
+	}
+	if len(raw) < size {
+		err = fmt.Errorf("bad tracing: expect %d bytes, got %d", size, len(raw))
+		return
+	}
+	cursor := 1
+	next := func() uint64 {
+		v := binary.BigEndian.Uint64(raw[cursor : cursor+8])
+		cursor += 8
+		return v
+	}
+	if flags&_tracingExtendedTraceID != 0 {
+		t.TraceIDHigh = next()
+	}
+	t.TraceID = next()
+	t.SpanID = next()
+	if flags&_tracingIncludeParentID != 0 {
+		t.ParentID = next()
+	}
+	return
+}
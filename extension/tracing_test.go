@@ -0,0 +1,40 @@
+package extension_test
+
+import (
+	"testing"
+
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	for _, it := range []extension.Tracing{
+		{},
+		{Sampling: extension.TracingNotSampled},
+		{TraceID: 1, SpanID: 2, Sampling: extension.TracingSampled},
+		{TraceID: 1, SpanID: 2, ParentID: 3, Sampling: extension.TracingDebug},
+		{TraceIDHigh: 0xFF, TraceID: 1, SpanID: 2},
+		{TraceIDHigh: 0xFF, TraceID: 1, SpanID: 2, ParentID: 3, Sampling: extension.TracingSampled},
+	} {
+		raw := it.Bytes()
+		parsed, err := extension.ParseTracing(raw)
+		require.NoError(t, err)
+		assert.Equal(t, it, parsed, "bad tracing %s", it)
+	}
+
+	tracing := extension.Tracing{TraceIDHigh: 0xAB, TraceID: 0x01, SpanID: 0x02, ParentID: 0x03, Sampling: extension.TracingSampled}
+	raw := tracing.Bytes()
+	assert.Len(t, raw, 33)
+	assert.Equal(t, byte(0x80|0x20|0x08|0x04), raw[0])
+	assert.Equal(t, "00000000000000ab0000000000000001", tracing.TraceIDString())
+	assert.Equal(t, "0000000000000002", tracing.SpanIDString())
+	assert.Equal(t, "0000000000000001", extension.Tracing{TraceID: 1}.TraceIDString())
+	assert.Equal(t, []byte{0x10}, extension.Tracing{Sampling: extension.TracingNotSampled}.Bytes())
+	assert.NotEmpty(t, tracing.String())
+
+	_, err := extension.ParseTracing(nil)
+	assert.Error(t, err)
+	_, err = extension.ParseTracing(raw[:20])
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package rsocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+var (
+	_ CloseableRSocket = (*interceptedSocket)(nil)
+	_ addressedRSocket = (*interceptedSocket)(nil)
+	_ Client           = (*interceptedClient)(nil)
+	_ LeaseAware       = (*interceptedClient)(nil)
+	_ MimeTypeAware    = (*interceptedClient)(nil)
+)
+
+// Interceptor wraps a RSocket to intercept all interaction models.
+// The requester-side interceptors wrap the RSocket sending requests, and the responder-side interceptors wrap
+// the RSocket handling requests. The first interceptor in a chain is the outermost one.
+type Interceptor func(RSocket) RSocket
+
+// ConnectionInterceptor intercepts a connection after SETUP.
+// The socket is the requester-side RSocket of the connection, its OnClose can be used to observe the close event.
+// Returning an error rejects the SETUP on server side, or closes the client on client side.
+type ConnectionInterceptor func(ctx context.Context, setup payload.SetupPayload, socket CloseableRSocket) error
+
+// InterceptorOption configures interceptors.
+type InterceptorOption func(*interceptorChains)
+
+// WithRequesterInterceptors adds interceptors of the RSocket sending requests.
+func WithRequesterInterceptors(interceptors ...Interceptor) InterceptorOption {
+	return func(i *interceptorChains) {
+		i.requester = append(i.requester, interceptors...)
+	}
+}
+
+// WithResponderInterceptors adds interceptors of the RSocket handling requests.
+func WithResponderInterceptors(interceptors ...Interceptor) InterceptorOption {
+	return func(i *interceptorChains) {
+		i.responder = append(i.responder, interceptors...)
+	}
+}
+
+// WithConnectionInterceptors adds interceptors of connections.
+func WithConnectionInterceptors(interceptors ...ConnectionInterceptor) InterceptorOption {
+	return func(i *interceptorChains) {
+		i.connection = append(i.connection, interceptors...)
+	}
+}
+
+type interceptorChains struct {
+	requester  []Interceptor
+	responder  []Interceptor
+	connection []ConnectionInterceptor
+}
+
+func newInterceptorChains(prev *interceptorChains, opts []InterceptorOption) *interceptorChains {
+	if prev == nil {
+		prev = &interceptorChains{}
+	}
+	for _, opt := range opts {
+		opt(prev)
+	}
+	return prev
+}
+
+// prepend returns a copy of chains with the given outermost interceptors.
+func (i *interceptorChains) prepend(requester, responder Interceptor) *interceptorChains {
+	c := &interceptorChains{
+		requester: []Interceptor{requester},
+		responder: []Interceptor{responder},
+	}
+	if i != nil {
+		c.requester = append(c.requester, i.requester...)
+		c.responder = append(c.responder, i.responder...)
+		c.connection = i.connection
+	}
+	return c
+}
+
+func chain(rs RSocket, interceptors []Interceptor) RSocket {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		rs = interceptors[i](rs)
+	}
+	return rs
+}
+
+func (i *interceptorChains) wrapResponder(responder RSocket) RSocket {
+	if i == nil || len(i.responder) < 1 {
+		return responder
+	}
+	return chain(responder, i.responder)
+}
+
+// wrapRequester wraps the requester-side socket, the close functions are kept.
+func (i *interceptorChains) wrapRequester(requester CloseableRSocket) CloseableRSocket {
+	if i == nil || len(i.requester) < 1 {
+		return requester
+	}
+	return &interceptedSocket{
+		CloseableRSocket: requester,
+		rs:               chain(requester, i.requester),
+	}
+}
+
+// wrapClient wraps the client, the close functions and the states of client are kept.
+func (i *interceptorChains) wrapClient(client Client) Client {
+	if i == nil || len(i.requester) < 1 {
+		return client
+	}
+	return &interceptedClient{
+		interceptedSocket: interceptedSocket{
+			CloseableRSocket: client,
+			rs:               chain(client, i.requester),
+		},
+	}
+}
+
+func (i *interceptorChains) connect(ctx context.Context, setup payload.SetupPayload, requester CloseableRSocket) error {
+	if i == nil {
+		return nil
+	}
+	for _, fn := range i.connection {
+		if err := fn(ctx, setup, requester); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interceptedSocket sends requests by the intercepted RSocket.
+type interceptedSocket struct {
+	CloseableRSocket
+	rs RSocket
+}
+
+func (s *interceptedSocket) FireAndForget(message payload.Payload) {
+	s.rs.FireAndForget(message)
+}
+
+func (s *interceptedSocket) MetadataPush(message payload.Payload) {
+	s.rs.MetadataPush(message)
+}
+
+func (s *interceptedSocket) RequestResponse(message payload.Payload) mono.Mono {
+	return s.rs.RequestResponse(message)
+}
+
+func (s *interceptedSocket) RequestStream(message payload.Payload) flux.Flux {
+	return s.rs.RequestStream(message)
+}
+
+func (s *interceptedSocket) RequestChannel(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+	return s.rs.RequestChannel(initialMessage, messages)
+}
+
+func (s *interceptedSocket) Addr() (string, bool) {
+	return GetAddr(s.CloseableRSocket)
+}
+
+type interceptedClient struct {
+	interceptedSocket
+}
+
+func (c *interceptedClient) RemainingLease() (tickets int64, expiry time.Time, enabled bool) {
+	if leased, ok := c.CloseableRSocket.(LeaseAware); ok {
+		return leased.RemainingLease()
+	}
+	return
+}
+
+func (c *interceptedClient) DataMimeType() string {
+	if aware, ok := c.CloseableRSocket.(MimeTypeAware); ok {
+		return aware.DataMimeType()
+	}
+	return ""
+}
+
+func (c *interceptedClient) MetadataMimeType() string {
+	if aware, ok := c.CloseableRSocket.(MimeTypeAware); ok {
+		return aware.MetadataMimeType()
+	}
+	return ""
+}
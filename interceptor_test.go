@@ -0,0 +1,275 @@
+package rsocket_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type interceptorRecorder struct {
+	sync.Mutex
+	records []string
+}
+
+func (r *interceptorRecorder) record(name string) {
+	r.Lock()
+	r.records = append(r.records, name)
+	r.Unlock()
+}
+
+func (r *interceptorRecorder) take() []string {
+	r.Lock()
+	defer r.Unlock()
+	records := r.records
+	r.records = nil
+	return records
+}
+
+func (r *interceptorRecorder) interceptor(name string) Interceptor {
+	return func(next RSocket) RSocket {
+		return NewAbstractSocket(
+			FireAndForget(func(request payload.Payload) {
+				r.record(name + ":ff")
+				next.FireAndForget(request)
+			}),
+			MetadataPush(func(request payload.Payload) {
+				r.record(name + ":mp")
+				next.MetadataPush(request)
+			}),
+			RequestResponse(func(request payload.Payload) mono.Mono {
+				r.record(name + ":rr")
+				return next.RequestResponse(request)
+			}),
+			RequestStream(func(request payload.Payload) flux.Flux {
+				r.record(name + ":rs")
+				return next.RequestStream(request)
+			}),
+			RequestChannel(func(initialRequest payload.Payload, requests flux.Flux) flux.Flux {
+				r.record(name + ":rc")
+				return next.RequestChannel(initialRequest, requests)
+			}),
+		)
+	}
+}
+
+// channelRejected rejects request-channel without sending it.
+type channelRejected struct {
+	RSocket
+}
+
+func (channelRejected) RequestChannel(initialRequest payload.Payload, requests flux.Flux) flux.Flux {
+	return flux.Error(core.NewCustomError(core.ErrorCodeRejected, []byte("channel rejected")))
+}
+
+func startInterceptedServer(ctx context.Context, t *testing.T, port int, opts ...InterceptorOption) {
+	started := make(chan struct{})
+	go func() {
+		err := Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Interceptors(opts...).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					FireAndForget(func(request payload.Payload) {}),
+					MetadataPush(func(request payload.Payload) {}),
+					RequestResponse(func(request payload.Payload) mono.Mono {
+						return mono.Just(payload.Clone(request))
+					}),
+					RequestStream(func(request payload.Payload) flux.Flux {
+						return flux.Just(payload.Clone(request))
+					}),
+				), nil
+			}).
+			Transport(TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+			Serve(ctx)
+		assert.NoError(t, err)
+	}()
+	<-started
+}
+
+func TestInterceptors(t *testing.T) {
+	const port = 9816
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverRecorder := &interceptorRecorder{}
+	serverSetups := make(chan string, 1)
+	serverClosed := make(chan struct{})
+	startInterceptedServer(ctx, t, port,
+		WithResponderInterceptors(serverRecorder.interceptor("server")),
+		WithConnectionInterceptors(func(ctx context.Context, setup payload.SetupPayload, socket CloseableRSocket) error {
+			serverSetups <- setup.DataUTF8()
+			socket.OnClose(func(error) {
+				close(serverClosed)
+			})
+			return nil
+		}),
+	)
+
+	clientRecorder := &interceptorRecorder{}
+	var clientSetup payload.SetupPayload
+	cli, err := Connect().
+		SetupPayload(payload.NewString("hello", "")).
+		Interceptors(WithRequesterInterceptors(clientRecorder.interceptor("first"))).
+		Interceptors(
+			WithRequesterInterceptors(clientRecorder.interceptor("second"), func(next RSocket) RSocket {
+				return channelRejected{next}
+			}),
+			WithConnectionInterceptors(func(ctx context.Context, setup payload.SetupPayload, socket CloseableRSocket) error {
+				clientSetup = setup
+				return nil
+			}),
+		).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+
+	require.NotNil(t, clientSetup)
+	assert.Equal(t, "hello", clientSetup.DataUTF8())
+	assert.Equal(t, "hello", <-serverSetups)
+	_, ok := GetAddr(cli)
+	assert.True(t, ok)
+
+	res, err := cli.RequestResponse(payload.NewString("rr", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "rr", res.DataUTF8())
+	assert.Equal(t, []string{"first:rr", "second:rr"}, clientRecorder.take())
+	assert.Equal(t, []string{"server:rr"}, serverRecorder.take())
+
+	results, err := cli.RequestStream(payload.NewString("rs", "")).BlockSlice(ctx)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, []string{"first:rs", "second:rs"}, clientRecorder.take())
+
+	_, err = cli.RequestChannel(payload.NewString("rc", ""), flux.Just(payload.NewString("rc", ""))).BlockSlice(ctx)
+	require.Error(t, err)
+	assert.Equal(t, core.ErrorCodeRejected, err.(core.CustomError).ErrorCode())
+	assert.Equal(t, []string{"first:rc", "second:rc"}, clientRecorder.take())
+
+	cli.FireAndForget(payload.NewString("ff", ""))
+	cli.MetadataPush(payload.NewString("", "mp"))
+	assert.Equal(t, []string{"first:ff", "second:ff", "first:mp", "second:mp"}, clientRecorder.take())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.ElementsMatch(t, []string{"server:rs", "server:ff", "server:mp"}, serverRecorder.take())
+
+	_ = cli.Close()
+	select {
+	case <-serverClosed:
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "server should observe the close event")
+	}
+}
+
+func TestInterceptors_Reject(t *testing.T) {
+	const port = 9817
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startInterceptedServer(ctx, t, port,
+		WithConnectionInterceptors(func(ctx context.Context, setup payload.SetupPayload, socket CloseableRSocket) error {
+			if setup.DataUTF8() == "bad" {
+				return core.NewCustomError(core.ErrorCodeUnsupportedSetup, []byte("bad setup"))
+			}
+			if setup.DataUTF8() != "good" {
+				return errors.New("unknown setup")
+			}
+			return nil
+		}),
+	)
+
+	for setup, code := range map[string]core.ErrorCode{
+		"bad":   core.ErrorCodeUnsupportedSetup,
+		"other": core.ErrorCodeRejectedSetup,
+	} {
+		cli, err := Connect().
+			SetupPayload(payload.NewString(setup, "")).
+			Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+			Start(ctx)
+		require.NoError(t, err)
+		_, err = cli.RequestResponse(payload.NewString("rr", "")).Block(ctx)
+		require.Error(t, err)
+		customErr, ok := err.(core.CustomError)
+		require.True(t, ok, "should be a custom error: %v", err)
+		assert.Equal(t, code, customErr.ErrorCode(), fmt.Sprintf("setup %s", setup))
+		_ = cli.Close()
+	}
+
+	cli, err := Connect().
+		SetupPayload(payload.NewString("good", "")).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer cli.Close()
+	res, err := cli.RequestResponse(payload.NewString("rr", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "rr", res.DataUTF8())
+}
+
+func TestInterceptors_ClientConnectionError(t *testing.T) {
+	const port = 9818
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startInterceptedServer(ctx, t, port)
+
+	closed := make(chan error, 1)
+	cli, err := Connect().
+		OnClose(func(err error) {
+			closed <- err
+		}).
+		Interceptors(WithConnectionInterceptors(func(ctx context.Context, setup payload.SetupPayload, socket CloseableRSocket) error {
+			return fakeErr
+		})).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	assert.Equal(t, fakeErr, err)
+	assert.Nil(t, cli)
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "client should be closed")
+	}
+}
+
+func TestInterceptors_Reconnect(t *testing.T) {
+	const port = 9819
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startReconnectServer(ctx, t, port, nil)
+
+	recorder := &interceptorRecorder{}
+	connected := make(chan Client, 1)
+	cli, err := Connect().
+		Reconnect(ReconnectPolicy{
+			Backoff: NewConstantBackoff(100 * time.Millisecond),
+		}).
+		OnConnect(func(client Client, err error) {
+			connected <- client
+		}).
+		Interceptors(WithRequesterInterceptors(recorder.interceptor("reconnect"))).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer cli.Close()
+
+	assert.Equal(t, cli, <-connected)
+
+	res, err := cli.RequestResponse(payload.NewString("rr", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "rr", res.DataUTF8())
+	assert.Equal(t, []string{"reconnect:rr"}, recorder.take())
+}
@@ -0,0 +1,111 @@
+// Package deferred creates the Mono and Flux whose sources are resolved when they are subscribed.
+package deferred
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+// Mono returns a Mono which calls source to create the actual Mono when it's subscribed.
+func Mono(source func(ctx context.Context) mono.Mono) mono.Mono {
+	return mono.Create(func(ctx context.Context, sink mono.Sink) {
+		var emitted bool
+		source(ctx).Subscribe(ctx,
+			rx.OnNext(func(input payload.Payload) error {
+				emitted = true
+				sink.Success(input)
+				return nil
+			}),
+			rx.OnComplete(func() {
+				if !emitted {
+					sink.Success(nil)
+				}
+			}),
+			rx.OnError(func(e error) {
+				sink.Error(e)
+			}),
+		)
+	})
+}
+
+// Flux returns a Flux which calls source to create the actual Flux when it's subscribed.
+// The requests and the cancel of downstream are forwarded to the actual Flux.
+func Flux(source func(ctx context.Context) flux.Flux) flux.Flux {
+	s := &stream{}
+	return flux.Create(func(ctx context.Context, sink flux.Sink) {
+		s.subscribe(ctx, sink, source(ctx))
+	}).
+		DoOnRequest(s.request).
+		DoFinally(func(sig rx.SignalType) {
+			if sig == rx.SignalCancel {
+				s.cancel()
+			}
+		})
+}
+
+// stream forwards the requests of downstream to the actual Flux.
+type stream struct {
+	mu        sync.Mutex
+	sub       rx.Subscription
+	requested int
+	cancelled bool
+}
+
+func (s *stream) subscribe(ctx context.Context, sink flux.Sink, source flux.Flux) {
+	source.Subscribe(ctx,
+		rx.OnSubscribe(func(ctx context.Context, sub rx.Subscription) {
+			s.mu.Lock()
+			if s.cancelled {
+				s.mu.Unlock()
+				sub.Cancel()
+				return
+			}
+			s.sub = sub
+			n := s.requested
+			s.mu.Unlock()
+			if n > 0 {
+				sub.Request(n)
+			}
+		}),
+		rx.OnNext(func(input payload.Payload) error {
+			// the element may be released after OnNext, but the sink may buffer it.
+			sink.Next(payload.Clone(input))
+			return nil
+		}),
+		rx.OnComplete(func() {
+			sink.Complete()
+		}),
+		rx.OnError(func(e error) {
+			sink.Error(e)
+		}),
+	)
+}
+
+func (s *stream) request(n int) {
+	s.mu.Lock()
+	if s.requested < rx.RequestMax-n {
+		s.requested += n
+	} else {
+		s.requested = rx.RequestMax
+	}
+	sub := s.sub
+	s.mu.Unlock()
+	if sub != nil {
+		sub.Request(n)
+	}
+}
+
+func (s *stream) cancel() {
+	s.mu.Lock()
+	s.cancelled = true
+	sub := s.sub
+	s.mu.Unlock()
+	if sub != nil {
+		sub.Cancel()
+	}
+}
@@ -0,0 +1,85 @@
+package deferred_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rsocket/rsocket-go/internal/deferred"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+var fakeErr = errors.New("fake error")
+
+func TestMono(t *testing.T) {
+	calls := atomic.NewInt32(0)
+	m := deferred.Mono(func(ctx context.Context) mono.Mono {
+		calls.Inc()
+		return mono.Just(payload.NewString("foo", "bar"))
+	})
+	assert.Equal(t, int32(0), calls.Load(), "source should not be called before subscribing")
+	res, err := m.Block(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", res.DataUTF8())
+	assert.Equal(t, int32(1), calls.Load())
+
+	res, err = deferred.Mono(func(ctx context.Context) mono.Mono {
+		return mono.Empty()
+	}).Block(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	_, err = deferred.Mono(func(ctx context.Context) mono.Mono {
+		return mono.Error(fakeErr)
+	}).Block(context.Background())
+	assert.Equal(t, fakeErr, err)
+}
+
+func TestFlux(t *testing.T) {
+	calls := atomic.NewInt32(0)
+	f := deferred.Flux(func(ctx context.Context) flux.Flux {
+		calls.Inc()
+		// emit asynchronously like a socket.
+		return flux.Create(func(ctx context.Context, s flux.Sink) {
+			go func() {
+				for _, it := range []string{"a", "b", "c"} {
+					s.Next(payload.NewString(it, ""))
+				}
+				s.Complete()
+			}()
+		})
+	})
+	assert.Equal(t, int32(0), calls.Load(), "source should not be called before subscribing")
+
+	var su rx.Subscription
+	var received []string
+	done := make(chan struct{})
+	f.
+		DoFinally(func(s rx.SignalType) {
+			close(done)
+		}).
+		Subscribe(context.Background(),
+			rx.OnSubscribe(func(ctx context.Context, s rx.Subscription) {
+				su = s
+				su.Request(1)
+			}),
+			rx.OnNext(func(input payload.Payload) error {
+				received = append(received, input.DataUTF8())
+				su.Request(1)
+				return nil
+			}),
+		)
+	<-done
+	assert.Equal(t, []string{"a", "b", "c"}, received)
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err := deferred.Flux(func(ctx context.Context) flux.Flux {
+		return flux.Error(fakeErr)
+	}).BlockLast(context.Background())
+	assert.Equal(t, fakeErr, err)
+}
@@ -1,13 +1,18 @@
 package session
 
-type sHeap []*Session
+type node struct {
+	index int
+	entry Entry
+}
+
+type sHeap []*node
 
 func (h sHeap) Len() int {
 	return len(h)
 }
 
 func (h sHeap) Less(i, j int) bool {
-	return h[i].deadline.Before(h[j].deadline)
+	return h[i].entry.Deadline().Before(h[j].entry.Deadline())
 }
 
 func (h sHeap) Swap(i, j int) {
@@ -16,9 +21,9 @@ func (h sHeap) Swap(i, j int) {
 }
 
 func (h *sHeap) Push(x interface{}) {
-	session := x.(*Session)
-	session.index = len(*h)
-	*h = append(*h, session)
+	n := x.(*node)
+	n.index = len(*h)
+	*h = append(*h, n)
 }
 
 func (h *sHeap) Pop() interface{} {
@@ -2,14 +2,24 @@ package session
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
+	"time"
 )
 
+// Entry is an item which can be managed by Manager.
+type Entry interface {
+	// Token returns the token of entry.
+	Token() []byte
+	// Deadline returns the deadline of entry.
+	Deadline() time.Time
+}
+
 // Manager is used to manage RSocket session when resume is enabled.
 type Manager struct {
 	sync.RWMutex
 	h *sHeap
-	m map[string]*Session
+	m map[string]*node
 }
 
 // Len returns size of session in current manager.
@@ -21,49 +31,79 @@ func (p *Manager) Len() (n int) {
 }
 
 // Push push a new session.
-func (p *Manager) Push(session *Session) {
+// The old session with same token will be replaced.
+func (p *Manager) Push(entry Entry) {
 	p.Lock()
 	defer p.Unlock()
-	heap.Push(p.h, session)
-	p.m[(string)(session.Token())] = session
+	key := (string)(entry.Token())
+	if old, ok := p.m[key]; ok && old.index > -1 {
+		heap.Remove(p.h, old.index)
+	}
+	n := &node{entry: entry}
+	heap.Push(p.h, n)
+	p.m[key] = n
 }
 
 // Load returns session with custom token.
-func (p *Manager) Load(token []byte) (session *Session, ok bool) {
+func (p *Manager) Load(token []byte) (entry Entry, ok bool) {
 	p.RLock()
 	defer p.RUnlock()
-	session, ok = p.m[(string)(token)]
+	n, ok := p.m[(string)(token)]
+	if ok {
+		entry = n.entry
+	}
 	return
 }
 
 // Remove remove a session with custom token.
-func (p *Manager) Remove(token []byte) (session *Session, ok bool) {
+func (p *Manager) Remove(token []byte) (entry Entry, ok bool) {
 	p.Lock()
 	defer p.Unlock()
-	session, ok = p.m[(string)(token)]
-	if ok && session.index > -1 {
-		heap.Remove(p.h, session.index)
-		delete(p.m, (string)(token))
-		session.index = -1
+	n, ok := p.m[(string)(token)]
+	if !ok {
+		return
+	}
+	entry = n.entry
+	if n.index > -1 {
+		heap.Remove(p.h, n.index)
 	}
+	delete(p.m, (string)(token))
 	return
 }
 
 // Pop pop earliest session.
-func (p *Manager) Pop() (session *Session) {
+func (p *Manager) Pop() (entry Entry) {
 	p.Lock()
 	defer p.Unlock()
-	session = heap.Pop(p.h).(*Session)
-	if session != nil {
-		delete(p.m, (string)(session.Token()))
+	if p.h.Len() < 1 {
+		return
 	}
+	n := heap.Pop(p.h).(*node)
+	entry = n.entry
+	delete(p.m, (string)(entry.Token()))
 	return
 }
 
+// List returns all sessions ordered by deadline.
+func (p *Manager) List() []Entry {
+	p.RLock()
+	nodes := make(sHeap, len(*p.h))
+	copy(nodes, *p.h)
+	p.RUnlock()
+	entries := make([]Entry, len(nodes))
+	for i := range nodes {
+		entries[i] = nodes[i].entry
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Deadline().Before(entries[j].Deadline())
+	})
+	return entries
+}
+
 // NewManager returns a new blank session manager.
 func NewManager() *Manager {
 	return &Manager{
 		h: &sHeap{},
-		m: make(map[string]*Session),
+		m: make(map[string]*node),
 	}
 }
@@ -24,6 +24,11 @@ func (p *Session) Close() error {
 	return p.socket.Close()
 }
 
+// Deadline returns the deadline of current session.
+func (p *Session) Deadline() time.Time {
+	return p.deadline
+}
+
 // IsDead returns true if current session is dead.
 func (p *Session) IsDead() (dead bool) {
 	dead = time.Now().After(p.deadline)
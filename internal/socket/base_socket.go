@@ -19,6 +19,7 @@ type BaseSocket struct {
 	once     sync.Once
 	reqLease *leaser
 	addr     string
+	mimes    [2]string
 }
 
 func (p *BaseSocket) SetAddr(addr string) {
@@ -109,6 +110,26 @@ func (p *BaseSocket) close(triggerCloser bool) (err error) {
 	return
 }
 
+// RemainingLease returns the remaining requests and the expiry of the lease received from peer.
+// It returns false if lease is disabled.
+func (p *BaseSocket) RemainingLease() (tickets int64, expiry time.Time, enabled bool) {
+	return p.reqLease.remaining()
+}
+
+// DataMimeType returns the MIME type of data sent in SETUP.
+func (p *BaseSocket) DataMimeType() string {
+	return p.mimes[0]
+}
+
+// MetadataMimeType returns the MIME type of metadata sent in SETUP.
+func (p *BaseSocket) MetadataMimeType() string {
+	return p.mimes[1]
+}
+
+func (p *BaseSocket) setMimeTypes(setup *SetupInfo) {
+	p.mimes = [2]string{string(setup.DataMimeType), string(setup.MetadataMimeType)}
+}
+
 func (p *BaseSocket) refreshLease(ttl time.Duration, n int64) {
 	deadline := time.Now().Add(ttl)
 	if p.reqLease == nil {
@@ -0,0 +1,124 @@
+package socket
+
+import (
+	"github.com/rsocket/rsocket-go/compression"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/fragmentation"
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+var (
+	_ fragmentation.HeaderAndPayload = (*decompressedPayload)(nil)
+	_ common.Releasable              = (*decompressedPayload)(nil)
+)
+
+var errDecompressFailed = core.NewCustomError(core.ErrorCodeInvalid, []byte("rsocket: decompress payload failed"))
+
+// decompressedPayload is a received payload whose data has been decompressed.
+// It keeps the reference count of the original frames.
+type decompressedPayload struct {
+	fragmentation.HeaderAndPayload
+	data []byte
+}
+
+func (d *decompressedPayload) Data() []byte {
+	return d.data
+}
+
+func (d *decompressedPayload) DataUTF8() string {
+	return string(d.data)
+}
+
+func (d *decompressedPayload) IncRef() int32 {
+	if releasable, ok := d.HeaderAndPayload.(common.Releasable); ok {
+		return releasable.IncRef()
+	}
+	return 0
+}
+
+func (d *decompressedPayload) RefCnt() int32 {
+	if releasable, ok := d.HeaderAndPayload.(common.Releasable); ok {
+		return releasable.RefCnt()
+	}
+	return 0
+}
+
+func (d *decompressedPayload) Release() {
+	common.TryRelease(d.HeaderAndPayload)
+}
+
+// SetCompression enables the payload compression of current connection.
+// The payload data smaller than threshold won't be compressed.
+func (dc *DuplexConnection) SetCompression(c compression.Compressor, threshold int) {
+	if threshold < 1 {
+		threshold = compression.DefaultThreshold
+	}
+	dc.locker.Lock()
+	dc.compressor = c
+	dc.compressThreshold = threshold
+	dc.locker.Unlock()
+}
+
+func (dc *DuplexConnection) compression() (c compression.Compressor, threshold int) {
+	dc.locker.RLock()
+	c, threshold = dc.compressor, dc.compressThreshold
+	dc.locker.RUnlock()
+	return
+}
+
+// compress returns the compressed data and core.FlagCompressed if the data should be compressed.
+// The data is compressed before fragmentation, so the flag should be set on the first fragment only.
+func (dc *DuplexConnection) compress(data []byte) ([]byte, core.FrameFlag) {
+	c, threshold := dc.compression()
+	if c == nil || len(data) < threshold {
+		return data, 0
+	}
+	compressed, err := c.Compress(data)
+	if err != nil {
+		logger.Warnf("rsocket: compress payload failed: %s\n", err)
+		return data, 0
+	}
+	// skip the data which cannot be compressed.
+	if len(compressed) >= len(data) {
+		return data, 0
+	}
+	return compressed, core.FlagCompressed
+}
+
+// decompress decompresses the payload which has been joined.
+func (dc *DuplexConnection) decompress(input fragmentation.HeaderAndPayload) (fragmentation.HeaderAndPayload, error) {
+	if !input.Header().Flag().Check(core.FlagCompressed) {
+		return input, nil
+	}
+	c, _ := dc.compression()
+	if c == nil {
+		return nil, errDecompressFailed
+	}
+	data, err := c.Decompress(input.Data())
+	if err != nil {
+		logger.Warnf("rsocket: decompress payload failed: %s\n", err)
+		return nil, errDecompressFailed
+	}
+	return &decompressedPayload{
+		HeaderAndPayload: input,
+		data:             data,
+	}, nil
+}
+
+// failDecompress terminates the stream whose payload cannot be decompressed.
+func (dc *DuplexConnection) failDecompress(h core.FrameHeader, err error) {
+	sid := h.StreamID()
+	switch h.Type() {
+	case core.FrameTypeRequestFNF:
+		// no response for fire-and-forget.
+	case core.FrameTypeRequestResponse, core.FrameTypeRequestStream, core.FrameTypeRequestChannel:
+		dc.writeError(sid, err)
+	default:
+		if cb, ok := dc.messages.Load(sid); ok {
+			dc.sendFrame(framing.NewWriteableCancelFrame(sid))
+			cb.(callback).stopWithError(err)
+		}
+	}
+}
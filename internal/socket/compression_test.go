@@ -0,0 +1,104 @@
+package socket
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/rsocket/rsocket-go/compression"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/fragmentation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplexConnection_Compress(t *testing.T) {
+	dc := NewServerDuplexConnection(context.Background(), nil, nil, fragmentation.MaxFragment, nil)
+	data := []byte(strings.Repeat("foobar", 100))
+
+	// no compression
+	out, flag := dc.compress(data)
+	assert.Equal(t, data, out)
+	assert.Equal(t, core.FrameFlag(0), flag)
+
+	gzip, ok := compression.Lookup(compression.Gzip)
+	require.True(t, ok)
+
+	// default threshold
+	dc.SetCompression(gzip, 0)
+	_, flag = dc.compress(data)
+	assert.Equal(t, core.FrameFlag(0), flag, "should not compress data smaller than threshold")
+
+	dc.SetCompression(gzip, 64)
+	out, flag = dc.compress(data)
+	assert.Equal(t, core.FlagCompressed, flag)
+	assert.Less(t, len(out), len(data))
+
+	// uncompressible
+	random := make([]byte, 100)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	out, flag = dc.compress(random)
+	assert.Equal(t, core.FrameFlag(0), flag)
+	assert.Equal(t, random, out)
+}
+
+func TestDuplexConnection_DecompressFragments(t *testing.T) {
+	const sid = uint32(1)
+	dc := NewServerDuplexConnection(context.Background(), nil, nil, 128, nil)
+	gzip, _ := compression.Lookup(compression.Gzip)
+	dc.SetCompression(gzip, 64)
+
+	data := []byte(strings.Repeat(common.RandAlphanumeric(64), 100))
+	metadata := []byte(common.RandAlphanumeric(512))
+	compressed, flag := dc.compress(data)
+	require.Equal(t, core.FlagCompressed, flag)
+
+	var joiner fragmentation.Joiner
+	dc.doSplit(compressed, metadata, func(index int, result fragmentation.SplitResult) {
+		if index == 0 {
+			joiner = fragmentation.NewJoiner(newPayloadFrame(t, sid, result.Data, result.Metadata, core.FlagNext|result.Flag|flag))
+		} else {
+			joiner.Push(newPayloadFrame(t, sid, result.Data, result.Metadata, core.FlagNext|result.Flag))
+		}
+	})
+	require.NotNil(t, joiner)
+	defer joiner.Release()
+
+	out, err := dc.decompress(joiner)
+	assert.NoError(t, err)
+	assert.Equal(t, data, out.Data())
+	assert.Equal(t, string(data), out.DataUTF8())
+	m, ok := out.Metadata()
+	assert.True(t, ok)
+	assert.Equal(t, metadata, m)
+
+	// uncompressed payload
+	plain := newPayloadFrame(t, sid, data, nil, core.FlagNext)
+	defer plain.Release()
+	out, err = dc.decompress(plain)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, out)
+
+	// broken data
+	broken := newPayloadFrame(t, sid, []byte("broken"), nil, core.FlagNext|core.FlagCompressed)
+	defer broken.Release()
+	_, err = dc.decompress(broken)
+	assert.Equal(t, errDecompressFailed, err)
+
+	// oversized data
+	dc.SetCompression(compression.NewGzip(6, compression.WithMaxDecompressedSize(len(data)-1)), 64)
+	oversized := newPayloadFrame(t, sid, compressed, nil, core.FlagNext|core.FlagCompressed)
+	defer oversized.Release()
+	_, err = dc.decompress(oversized)
+	assert.Equal(t, errDecompressFailed, err)
+}
+
+func newPayloadFrame(t *testing.T, sid uint32, data, metadata []byte, flag core.FrameFlag) *framing.PayloadFrame {
+	f, err := framing.FromWriteable(framing.NewWriteablePayloadFrame(sid, data, metadata, flag))
+	require.NoError(t, err)
+	return f.(*framing.PayloadFrame)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/atomic"
 
+	"github.com/rsocket/rsocket-go/compression"
 	"github.com/rsocket/rsocket-go/core"
 	"github.com/rsocket/rsocket-go/core/framing"
 	"github.com/rsocket/rsocket-go/core/transport"
@@ -33,6 +34,8 @@ const _minRequestSchedulerSize = 1000
 
 var errSocketClosed = errors.New("rsocket: socket closed already")
 var errRequestFailed = errors.New("rsocket: send request failed")
+var errTransportChanged = errors.New("rsocket: transport changed")
+var errResumeUnavailable = errors.New("rsocket: resume is not enabled")
 
 var (
 	unsupportedRequestStream   = []byte("Request-Stream not implemented.")
@@ -49,27 +52,33 @@ func mustExecute(sc scheduler.Scheduler, handler func()) {
 
 // DuplexConnection represents a socket of RSocket which can be a requester or a responder.
 type DuplexConnection struct {
-	ctx            context.Context
-	reqSche        scheduler.Scheduler
-	resSche        scheduler.Scheduler
-	destroyReqSche bool
-	locker         sync.RWMutex
-	counter        *core.TrafficCounter
-	tp             *transport.Transport
-	sndQueue       chan core.WriteableFrame
-	sndBacklog     []core.WriteableFrame
-	responder      Responder
-	messages       sync.Map // key=streamID, value=callback
-	sids           StreamID
-	mtu            int
-	fragments      sync.Map // key=streamID, value=Joiner
-	writeDone      chan struct{}
-	keepaliver     *Keepaliver
-	cond           sync.Cond
-	e              error
-	leases         lease.Factory
-	closed         *atomic.Bool
-	ready          *atomic.Bool
+	ctx               context.Context
+	reqSche           scheduler.Scheduler
+	resSche           scheduler.Scheduler
+	destroyReqSche    bool
+	locker            sync.RWMutex
+	counter           *core.TrafficCounter
+	tp                *transport.Transport
+	sndQueue          chan core.WriteableFrame
+	sndBacklog        []core.WriteableFrame
+	responder         Responder
+	messages          sync.Map // key=streamID, value=callback
+	contexts          sync.Map // key=streamID, value=*requestPayload
+	sids              StreamID
+	mtu               int
+	fragments         sync.Map // key=streamID, value=Joiner
+	writeDone         chan struct{}
+	keepaliver        *Keepaliver
+	cond              sync.Cond
+	e                 error
+	leases            lease.Factory
+	closed            *atomic.Bool
+	ready             *atomic.Bool
+	replay            *replayBuffer
+	replayLocker      sync.Mutex
+	setup             *SetupInfo
+	compressor        compression.Compressor
+	compressThreshold int
 }
 
 // SetError sets error for current socket.
@@ -136,6 +145,7 @@ func (dc *DuplexConnection) Close() error {
 	} else {
 		dc.destroyHandler(err)
 	}
+	dc.destroyRequestContexts()
 
 	dc.destroySndQueue()
 	dc.destroySndBacklog()
@@ -192,8 +202,8 @@ func (dc *DuplexConnection) destroySndBacklog() {
 
 // FireAndForget start a request of FireAndForget.
 func (dc *DuplexConnection) FireAndForget(req payload.Payload) {
-	data := req.Data()
-	size := core.FrameHeaderLen + len(req.Data())
+	data, compressed := dc.compress(req.Data())
+	size := core.FrameHeaderLen + len(data)
 	m, ok := req.Metadata()
 	if ok {
 		size += 3 + len(m)
@@ -206,7 +216,7 @@ func (dc *DuplexConnection) FireAndForget(req payload.Payload) {
 	}
 
 	if !dc.shouldSplit(size) {
-		outMsg := framing.NewWriteableFireAndForgetFrame(sid, data, m, 0)
+		outMsg := framing.NewWriteableFireAndForgetFrame(sid, data, m, compressed)
 		if isReleasable {
 			outMsg.HandleDone(func() {
 				releasable.Release()
@@ -218,7 +228,7 @@ func (dc *DuplexConnection) FireAndForget(req payload.Payload) {
 	dc.doSplit(data, m, func(index int, result fragmentation.SplitResult) {
 		var outMsg core.WriteableFrame
 		if index == 0 {
-			outMsg = framing.NewWriteableFireAndForgetFrame(sid, result.Data, result.Metadata, result.Flag)
+			outMsg = framing.NewWriteableFireAndForgetFrame(sid, result.Data, result.Metadata, result.Flag|compressed)
 		} else {
 			outMsg = framing.NewWriteablePayloadFrame(sid, result.Data, result.Metadata, result.Flag|core.FlagNext)
 		}
@@ -240,18 +250,35 @@ func (dc *DuplexConnection) MetadataPush(payload payload.Payload) {
 }
 
 // RequestResponse start a request of RequestResponse.
+// The request is sent when the result is subscribed, so the deadline of subscriber context is sent as the timeout.
 func (dc *DuplexConnection) RequestResponse(req payload.Payload) (res mono.Mono) {
 	if dc.closed.Load() {
 		res = mono.Error(errSocketClosed)
 		return
 	}
 
-	sid := dc.nextStreamID()
-
 	handler := &requestResponseCallback{}
 
+	releasable, isReleasable := req.(common.Releasable)
+	if isReleasable {
+		releasable.IncRef()
+	}
+
+	var (
+		sid  uint32
+		sent bool
+	)
+
 	onFinally := func(s reactor.SignalType, d reactor.Disposable) {
 		common.TryRelease(handler.cache)
+		if !sent {
+			// the request is never sent, eg: the context of subscriber is done already.
+			if isReleasable {
+				releasable.Release()
+			}
+			d.Dispose()
+			return
+		}
 		if s == reactor.SignalTypeCancel {
 			dc.sendFrame(framing.NewWriteableCancelFrame(sid))
 		}
@@ -264,24 +291,25 @@ func (dc *DuplexConnection) RequestResponse(req payload.Payload) (res mono.Mono)
 	m, s, _ := mono.NewProcessor(dc.reqSche, onFinally)
 	handler.sink = s
 
-	dc.register(sid, handler)
-
-	res = m
+	res = m.DoOnSubscribe(func(ctx context.Context, _ rx.Subscription) {
+		sid = dc.nextStreamID()
+		sent = true
+		dc.register(sid, handler)
+		dc.sendRequestResponse(ctx, sid, req, releasable, isReleasable)
+	})
+	return
+}
 
-	data := req.Data()
-	metadata, _ := req.Metadata()
+func (dc *DuplexConnection) sendRequestResponse(ctx context.Context, sid uint32, req payload.Payload, releasable common.Releasable, isReleasable bool) {
+	data, compressed := dc.compress(req.Data())
+	metadata := dc.requestMetadata(ctx, req)
 
 	// sending...
 	size := framing.CalcPayloadFrameSize(data, metadata)
 
-	releasable, isReleasable := req.(common.Releasable)
-	if isReleasable {
-		releasable.IncRef()
-	}
-
 	// mtu disabled
 	if !dc.shouldSplit(size) {
-		toBeSent := framing.NewWriteableRequestResponseFrame(sid, data, metadata, 0)
+		toBeSent := framing.NewWriteableRequestResponseFrame(sid, data, metadata, compressed)
 		if isReleasable {
 			toBeSent.HandleDone(func() {
 				releasable.Release()
@@ -297,7 +325,7 @@ func (dc *DuplexConnection) RequestResponse(req payload.Payload) (res mono.Mono)
 	dc.doSplit(data, metadata, func(index int, result fragmentation.SplitResult) {
 		var toBeSent core.WriteableFrame
 		if index == 0 {
-			toBeSent = framing.NewWriteableRequestResponseFrame(sid, result.Data, result.Metadata, result.Flag)
+			toBeSent = framing.NewWriteableRequestResponseFrame(sid, result.Data, result.Metadata, result.Flag|compressed)
 		} else {
 			toBeSent = framing.NewWriteablePayloadFrame(sid, result.Data, result.Metadata, result.Flag|core.FlagNext)
 		}
@@ -313,11 +341,10 @@ func (dc *DuplexConnection) RequestResponse(req payload.Payload) (res mono.Mono)
 			dc.killCallback(sid)
 		}
 	})
-
-	return
 }
 
 // RequestStream start a request of RequestStream.
+// The request is sent at the first request of subscriber, and the deadline of subscriber context is sent as the timeout.
 func (dc *DuplexConnection) RequestStream(sending payload.Payload) (ret flux.Flux) {
 	if dc.closed.Load() {
 		ret = flux.Error(errSocketClosed)
@@ -334,7 +361,12 @@ func (dc *DuplexConnection) RequestStream(sending payload.Payload) (ret flux.Flu
 	// Create a queue to save those payloads to be released.
 	toBeReleased := queue.NewLKQueue()
 
+	reqCtx := context.Background()
+
 	ret = pc.
+		DoOnSubscribe(func(ctx context.Context, _ rx.Subscription) {
+			reqCtx = ctx
+		}).
 		DoFinally(func(sig rx.SignalType) {
 			if sig == rx.SignalCancel {
 				dc.sendFrame(framing.NewWriteableCancelFrame(sid))
@@ -379,12 +411,12 @@ func (dc *DuplexConnection) RequestStream(sending payload.Payload) (ret flux.Flu
 				releasable.IncRef()
 			}
 
-			data := sending.Data()
-			metadata, _ := sending.Metadata()
+			data, compressed := dc.compress(sending.Data())
+			metadata := dc.requestMetadata(reqCtx, sending)
 
 			size := framing.CalcPayloadFrameSize(data, metadata) + 4
 			if !dc.shouldSplit(size) {
-				toBeSent := framing.NewWriteableRequestStreamFrame(sid, n32, data, metadata, 0)
+				toBeSent := framing.NewWriteableRequestStreamFrame(sid, n32, data, metadata, compressed)
 
 				if isReleasable {
 					toBeSent.HandleDone(func() {
@@ -401,7 +433,7 @@ func (dc *DuplexConnection) RequestStream(sending payload.Payload) (ret flux.Flu
 			dc.doSplitSkip(4, data, metadata, func(index int, result fragmentation.SplitResult) {
 				var toBeSent core.WriteableFrame
 				if index == 0 {
-					toBeSent = framing.NewWriteableRequestStreamFrame(sid, n32, result.Data, result.Metadata, result.Flag)
+					toBeSent = framing.NewWriteableRequestStreamFrame(sid, n32, result.Data, result.Metadata, result.Flag|compressed)
 				} else {
 					toBeSent = framing.NewWriteablePayloadFrame(sid, result.Data, result.Metadata, result.Flag|core.FlagNext)
 				}
@@ -430,6 +462,7 @@ func (dc *DuplexConnection) killCallback(sid uint32) {
 }
 
 // RequestChannel start a request of RequestChannel.
+// The request is sent at the first request of subscriber, and the deadline of subscriber context is sent as the timeout.
 func (dc *DuplexConnection) RequestChannel(request payload.Payload, sending flux.Flux) (ret flux.Flux) {
 	if dc.closed.Load() {
 		ret = flux.Error(errSocketClosed)
@@ -444,7 +477,12 @@ func (dc *DuplexConnection) RequestChannel(request payload.Payload, sending flux
 
 	toBeReleased := queue.NewLKQueue()
 
+	reqCtx := context.Background()
+
 	ret = receiving.
+		DoOnSubscribe(func(ctx context.Context, _ rx.Subscription) {
+			reqCtx = ctx
+		}).
 		DoFinally(func(sig rx.SignalType) {
 			dc.unregister(sid)
 			// release resources.
@@ -489,12 +527,12 @@ func (dc *DuplexConnection) RequestChannel(request payload.Payload, sending flux
 				releasable.IncRef()
 			}
 
-			data := request.Data()
-			metadata, _ := request.Metadata()
+			data, compressed := dc.compress(request.Data())
+			metadata := dc.requestMetadata(reqCtx, request)
 
 			size := framing.CalcPayloadFrameSize(data, metadata) + 4
 			if !dc.shouldSplit(size) {
-				toBeSent := framing.NewWriteableRequestChannelFrame(sid, n, data, metadata, core.FlagNext)
+				toBeSent := framing.NewWriteableRequestChannelFrame(sid, n, data, metadata, core.FlagNext|compressed)
 
 				if isReleasable {
 					toBeSent.HandleDone(func() {
@@ -510,7 +548,7 @@ func (dc *DuplexConnection) RequestChannel(request payload.Payload, sending flux
 				dc.doSplitSkip(4, data, metadata, func(index int, result fragmentation.SplitResult) {
 					var toBeSent core.WriteableFrame
 					if index == 0 {
-						toBeSent = framing.NewWriteableRequestChannelFrame(sid, n, result.Data, result.Metadata, result.Flag|core.FlagNext)
+						toBeSent = framing.NewWriteableRequestChannelFrame(sid, n, result.Data, result.Metadata, result.Flag|core.FlagNext|compressed)
 					} else {
 						toBeSent = framing.NewWriteablePayloadFrame(sid, result.Data, result.Metadata, result.Flag|core.FlagNext)
 					}
@@ -541,7 +579,7 @@ func (dc *DuplexConnection) RequestChannel(request payload.Payload, sending flux
 
 func (dc *DuplexConnection) onFrameRequestResponse(frame core.BufferedFrame) error {
 	// fragment
-	receiving, ok := dc.doFragment(frame.(*framing.RequestResponseFrame))
+	receiving, ok := dc.doFragment(frame.(fragmentation.HeaderAndPayload))
 	if !ok {
 		return nil
 	}
@@ -550,6 +588,7 @@ func (dc *DuplexConnection) onFrameRequestResponse(frame core.BufferedFrame) err
 
 func (dc *DuplexConnection) respondRequestResponse(receiving fragmentation.HeaderAndPayload) error {
 	sid := receiving.Header().StreamID()
+	req := dc.withRequestContext(sid, receiving)
 
 	// execute socket handler
 	sending, err := func() (resp mono.Mono, err error) {
@@ -565,7 +604,7 @@ func (dc *DuplexConnection) respondRequestResponse(receiving fragmentation.Heade
 			}
 			logger.Errorf("handle request-response failed: %+v\n", err)
 		}()
-		resp = dc.responder.RequestResponse(receiving)
+		resp = dc.responder.RequestResponse(req)
 		if resp == nil {
 			err = framing.NewWriteableErrorFrame(sid, core.ErrorCodeApplicationError, unsupportedRequestResponse)
 		}
@@ -573,13 +612,14 @@ func (dc *DuplexConnection) respondRequestResponse(receiving fragmentation.Heade
 	}()
 	// sending error with panic
 	if err != nil {
+		dc.cancelRequestContext(sid)
 		common.TryRelease(receiving)
 		dc.writeError(sid, err)
 		return nil
 	}
 
 	// async subscribe publisher
-	sub := borrowRequestResponseSubscriber(dc, sid, receiving)
+	sub := req.withDeadline(borrowRequestResponseSubscriber(dc, sid, receiving), nil)
 	if mono.IsSubscribeAsync(sending) {
 		sending.SubscribeWith(dc.ctx, sub)
 		return nil
@@ -591,7 +631,7 @@ func (dc *DuplexConnection) respondRequestResponse(receiving fragmentation.Heade
 }
 
 func (dc *DuplexConnection) onFrameRequestChannel(input core.BufferedFrame) error {
-	receiving, ok := dc.doFragment(input.(*framing.RequestChannelFrame))
+	receiving, ok := dc.doFragment(input.(fragmentation.HeaderAndPayload))
 	if !ok {
 		return nil
 	}
@@ -603,6 +643,7 @@ func (dc *DuplexConnection) respondRequestChannel(req fragmentation.HeaderAndPay
 	initRequestN := extractRequestStreamInitN(req)
 
 	sid := req.Header().StreamID()
+	initialRequest := dc.withRequestContext(sid, req)
 	receivingProcessor := flux.CreateProcessor()
 
 	finallyRequests := atomic.NewInt32(0)
@@ -660,7 +701,7 @@ func (dc *DuplexConnection) respondRequestChannel(req fragmentation.HeaderAndPay
 			}
 			logger.Errorf("handle request-channel failed: %+v\n", err)
 		}()
-		resp = dc.responder.RequestChannel(req, receiving)
+		resp = dc.responder.RequestChannel(initialRequest, receiving)
 		if resp == nil {
 			err = framing.NewWriteableErrorFrame(sid, core.ErrorCodeApplicationError, unsupportedRequestChannel)
 		}
@@ -668,6 +709,7 @@ func (dc *DuplexConnection) respondRequestChannel(req fragmentation.HeaderAndPay
 	}()
 
 	if err != nil {
+		dc.cancelRequestContext(sid)
 		common.TryRelease(receiving)
 		dc.writeError(sid, err)
 		return nil
@@ -677,14 +719,16 @@ func (dc *DuplexConnection) respondRequestChannel(req fragmentation.HeaderAndPay
 	subscribed := make(chan struct{})
 
 	// Create subscriber
-	sub := &respondChannelSubscriber{
+	sub := initialRequest.withDeadline(&respondChannelSubscriber{
 		sid:        sid,
 		n:          initRequestN,
 		dc:         dc,
 		rcv:        receivingProcessor,
 		subscribed: subscribed,
 		calls:      finallyRequests,
-	}
+	}, func() {
+		receivingProcessor.Error(errRequestTimeout)
+	})
 
 	mustExecute(dc.reqSche, func() {
 		sending.SubscribeWith(dc.ctx, sub)
@@ -696,10 +740,10 @@ func (dc *DuplexConnection) respondRequestChannel(req fragmentation.HeaderAndPay
 }
 
 func (dc *DuplexConnection) respondMetadataPush(input core.BufferedFrame) error {
-	req := input.(*framing.MetadataPushFrame)
+	req := input.(fragmentation.HeaderAndPayload)
 	mustExecute(dc.resSche, func() {
 		defer func() {
-			req.Release()
+			input.Release()
 			rec := recover()
 			if rec == nil {
 				return
@@ -712,13 +756,13 @@ func (dc *DuplexConnection) respondMetadataPush(input core.BufferedFrame) error
 			}
 			logger.Errorf("handle metadata-push failed: %+v\n", err)
 		}()
-		dc.responder.MetadataPush(req)
+		dc.responder.MetadataPush(dc.withConnectionContext(0, req))
 	})
 	return nil
 }
 
 func (dc *DuplexConnection) onFrameFNF(frame core.BufferedFrame) error {
-	receiving, ok := dc.doFragment(frame.(*framing.FireAndForgetFrame))
+	receiving, ok := dc.doFragment(frame.(fragmentation.HeaderAndPayload))
 	if !ok {
 		return nil
 	}
@@ -741,13 +785,13 @@ func (dc *DuplexConnection) respondFireAndForget(receiving fragmentation.HeaderA
 			}
 			logger.Errorf("handle fire-and-forget failed: %+v\n", err)
 		}()
-		dc.responder.FireAndForget(receiving)
+		dc.responder.FireAndForget(dc.withConnectionContext(receiving.Header().StreamID(), receiving))
 	})
 	return nil
 }
 
 func (dc *DuplexConnection) onFrameRequestStream(frame core.BufferedFrame) error {
-	receiving, ok := dc.doFragment(frame.(*framing.RequestStreamFrame))
+	receiving, ok := dc.doFragment(frame.(fragmentation.HeaderAndPayload))
 	if !ok {
 		return nil
 
@@ -758,6 +802,7 @@ func (dc *DuplexConnection) onFrameRequestStream(frame core.BufferedFrame) error
 func (dc *DuplexConnection) respondRequestStream(receiving fragmentation.HeaderAndPayload) error {
 	sid := receiving.Header().StreamID()
 	n := extractRequestStreamInitN(receiving)
+	req := dc.withRequestContext(sid, receiving)
 
 	// execute request stream handler
 	sending, err := func() (resp flux.Flux, err error) {
@@ -773,7 +818,7 @@ func (dc *DuplexConnection) respondRequestStream(receiving fragmentation.HeaderA
 			}
 			logger.Errorf("handle request-stream failed: %+v\n", err)
 		}()
-		resp = dc.responder.RequestStream(receiving)
+		resp = dc.responder.RequestStream(req)
 		if resp == nil {
 			err = framing.NewWriteableErrorFrame(sid, core.ErrorCodeApplicationError, unsupportedRequestStream)
 		}
@@ -782,13 +827,14 @@ func (dc *DuplexConnection) respondRequestStream(receiving fragmentation.HeaderA
 
 	// send error with panic
 	if err != nil {
+		dc.cancelRequestContext(sid)
 		common.TryRelease(receiving)
 		dc.writeError(sid, err)
 		return nil
 	}
 
 	// async subscribe publisher
-	sub := borrowRequestStreamSubscriber(receiving, dc, sid, n)
+	sub := req.withDeadline(borrowRequestStreamSubscriber(receiving, dc, sid, n), nil)
 	sending.SubscribeOn(dc.resSche).SubscribeWith(dc.ctx, sub)
 	return nil
 }
@@ -821,12 +867,16 @@ func (dc *DuplexConnection) SetResponder(responder Responder) {
 func (dc *DuplexConnection) onFrameKeepalive(frame core.BufferedFrame) (err error) {
 	defer frame.Release()
 	f := frame.(*framing.KeepaliveFrame)
+	if dc.replay != nil {
+		// frames before the position have been received by peer, discard them.
+		dc.replay.release(f.LastReceivedPosition())
+	}
 	if !f.HasFlag(core.FlagRespond) {
 		return
 	}
 	// TODO: optimize, if keepalive frame support modify data.
 	data := common.CloneBytes(f.Data())
-	k := framing.NewWriteableKeepaliveFrame(f.LastReceivedPosition(), data, false)
+	k := framing.NewWriteableKeepaliveFrame(dc.counter.ReadBytes(), data, false)
 	dc.sendFrame(k)
 	return
 }
@@ -846,6 +896,8 @@ func (dc *DuplexConnection) onFrameCancel(frame core.BufferedFrame) (err error)
 
 	defer dc.deleteFragment(sid)
 
+	dc.cancelRequestContext(sid)
+
 	v, ok := dc.messages.Load(sid)
 	if !ok {
 		logger.Warnf("unmatched frame CANCEL(id=%d), maybe original request has been cancelled\n", sid)
@@ -919,6 +971,20 @@ func (dc *DuplexConnection) onFrameRequestN(input core.BufferedFrame) error {
 }
 
 func (dc *DuplexConnection) doFragment(input fragmentation.HeaderAndPayload) (out fragmentation.HeaderAndPayload, ok bool) {
+	defer func() {
+		if !ok {
+			return
+		}
+		// decompress after joining
+		decompressed, err := dc.decompress(out)
+		if err != nil {
+			common.TryRelease(out)
+			dc.failDecompress(out.Header(), err)
+			out, ok = nil, false
+			return
+		}
+		out = decompressed
+	}()
 	h := input.Header()
 	sid := h.StreamID()
 	v, exist := dc.fragments.Load(sid)
@@ -941,7 +1007,7 @@ func (dc *DuplexConnection) doFragment(input fragmentation.HeaderAndPayload) (ou
 }
 
 func (dc *DuplexConnection) onFramePayload(frame core.BufferedFrame) error {
-	next, ok := dc.doFragment(frame.(*framing.PayloadFrame))
+	next, ok := dc.doFragment(frame.(fragmentation.HeaderAndPayload))
 	if !ok {
 		return nil
 	}
@@ -1027,6 +1093,110 @@ func (dc *DuplexConnection) currentTransport() (tp *transport.Transport) {
 
 // SetTransport sets a transport for current socket.
 func (dc *DuplexConnection) SetTransport(tp *transport.Transport) (ok bool) {
+	dc.bindHandlers(tp)
+
+	ok = dc.ready.CAS(false, true)
+	if !ok {
+		return
+	}
+
+	dc.locker.Lock()
+	dc.tp = tp
+	dc.cond.Signal()
+	dc.locker.Unlock()
+	return
+}
+
+// SetResumeBufferSize sets the max bytes of sent frames which can be kept for resume.
+func (dc *DuplexConnection) SetResumeBufferSize(size int) {
+	if dc.replay != nil {
+		dc.replay.setCapacity(size)
+	}
+}
+
+func (dc *DuplexConnection) enableResume() {
+	if dc.replay == nil {
+		dc.replay = newReplayBuffer(DefaultResumeBufferSize)
+	}
+}
+
+// resumePositions returns the last received position and the first available position.
+func (dc *DuplexConnection) resumePositions() (lastReceived, firstAvailable uint64) {
+	lastReceived = dc.counter.ReadBytes()
+	if dc.replay != nil {
+		firstAvailable, _ = dc.replay.positions()
+	}
+	return
+}
+
+// resumeServer validates the positions of a RESUME frame, then sends RESUME_OK and
+// retransmits frames which have not been received by client before binding the new transport.
+func (dc *DuplexConnection) resumeServer(tp *transport.Transport, lastReceivedServerPosition, firstAvailableClientPosition uint64) error {
+	if dc.replay == nil {
+		return errResumeUnavailable
+	}
+	dc.replayLocker.Lock()
+	defer dc.replayLocker.Unlock()
+
+	lastReceivedClientPosition := dc.counter.ReadBytes()
+	if firstAvailableClientPosition > lastReceivedClientPosition {
+		return errors.Errorf(
+			"client frames are unavailable: first available client position %d, last received client position %d",
+			firstAvailableClientPosition,
+			lastReceivedClientPosition,
+		)
+	}
+	frames, ok := dc.replay.since(lastReceivedServerPosition)
+	if !ok {
+		first, last := dc.replay.positions()
+		return errors.Errorf(
+			"server frames are unavailable: last received server position %d, available positions [%d,%d]",
+			lastReceivedServerPosition,
+			first,
+			last,
+		)
+	}
+	if err := tp.Send(framing.NewWriteableResumeOKFrame(lastReceivedClientPosition), false); err != nil {
+		return err
+	}
+	return dc.replayTo(tp, frames)
+}
+
+// resumeClient retransmits frames which have not been received by server before binding the new transport.
+func (dc *DuplexConnection) resumeClient(tp *transport.Transport, lastReceivedClientPosition uint64) error {
+	if dc.replay == nil {
+		return errResumeUnavailable
+	}
+	dc.replayLocker.Lock()
+	defer dc.replayLocker.Unlock()
+
+	frames, ok := dc.replay.since(lastReceivedClientPosition)
+	if !ok {
+		first, last := dc.replay.positions()
+		return errors.Errorf(
+			"client frames are unavailable: last received client position %d, available positions [%d,%d]",
+			lastReceivedClientPosition,
+			first,
+			last,
+		)
+	}
+	return dc.replayTo(tp, frames)
+}
+
+func (dc *DuplexConnection) replayTo(tp *transport.Transport, frames [][]byte) error {
+	for _, next := range frames {
+		if err := tp.Send(replayFrame(next), false); err != nil {
+			return err
+		}
+	}
+	if err := tp.Flush(); err != nil {
+		return err
+	}
+	dc.SetTransport(tp)
+	return nil
+}
+
+func (dc *DuplexConnection) bindHandlers(tp *transport.Transport) {
 	tp.Handle(transport.OnCancel, dc.onFrameCancel)
 	tp.Handle(transport.OnError, dc.onFrameError)
 	tp.Handle(transport.OnRequestN, dc.onFrameRequestN)
@@ -1039,17 +1209,34 @@ func (dc *DuplexConnection) SetTransport(tp *transport.Transport) (ok bool) {
 		tp.Handle(transport.OnRequestStream, dc.onFrameRequestStream)
 		tp.Handle(transport.OnRequestChannel, dc.onFrameRequestChannel)
 	}
+}
 
-	ok = dc.ready.CAS(false, true)
-	if !ok {
-		return
+// sendTo sends a frame with given transport.
+// Resumable frames will be kept until peer acknowledges them if resume is enabled.
+func (dc *DuplexConnection) sendTo(tp *transport.Transport, out core.WriteableFrame, flush bool) error {
+	if dc.replay == nil || !out.Header().Resumable() {
+		return tp.Send(out, flush)
 	}
-
-	dc.locker.Lock()
-	dc.tp = tp
-	dc.cond.Signal()
-	dc.locker.Unlock()
-	return
+	// encode the frame before locking, the bytes are both sent and kept for resume.
+	raw, err := encodeFrame(out)
+	if err != nil {
+		return err
+	}
+	dc.replayLocker.Lock()
+	// transport has been replaced or paused, the frame should be sent later.
+	if dc.currentTransport() != tp {
+		dc.replayLocker.Unlock()
+		return errTransportChanged
+	}
+	if err = tp.Send(replayFrame(raw), flush); err != nil {
+		dc.replayLocker.Unlock()
+		return err
+	}
+	dc.replay.append(raw)
+	dc.replayLocker.Unlock()
+	// the original frame is not sent by transport, so mark it done here.
+	out.Done()
+	return nil
 }
 
 func (dc *DuplexConnection) sendFrame(f core.WriteableFrame) (ok bool) {
@@ -1073,9 +1260,10 @@ func (dc *DuplexConnection) sendPayload(
 	sending payload.Payload,
 	frameFlag core.FrameFlag,
 ) {
-	d := sending.Data()
+	d, compressed := dc.compress(sending.Data())
 	m, _ := sending.Metadata()
 	size := framing.CalcPayloadFrameSize(d, m)
+	frameFlag |= compressed
 
 	releasable, isReleasable := sending.(common.Releasable)
 	if isReleasable {
@@ -1155,7 +1343,7 @@ func (dc *DuplexConnection) drainWithKeepaliveAndLease(leaseChan <-chan lease.Le
 		}
 		if tp := dc.currentTransport(); tp == nil {
 			dc.sndBacklog = append(dc.sndBacklog, out)
-		} else if err := tp.Send(out, true); err != nil {
+		} else if err := dc.sendTo(tp, out, true); err != nil {
 			logger.Errorf("send frame failed: %s\n", err.Error())
 			dc.sndBacklog = append(dc.sndBacklog, out)
 		}
@@ -1188,7 +1376,7 @@ func (dc *DuplexConnection) drainWithKeepalive() (ok bool) {
 
 		if tp := dc.currentTransport(); tp == nil {
 			dc.sndBacklog = append(dc.sndBacklog, out)
-		} else if err := tp.Send(out, true); err != nil {
+		} else if err := dc.sendTo(tp, out, true); err != nil {
 			logger.Errorf("send frame failed: %s\n", err.Error())
 			dc.sndBacklog = append(dc.sndBacklog, out)
 		}
@@ -1235,7 +1423,7 @@ func (dc *DuplexConnection) drainOne(out core.WriteableFrame) (ok bool) {
 		dc.sndBacklog = append(dc.sndBacklog, out)
 		return
 	}
-	err := tp.Send(out, false)
+	err := dc.sendTo(tp, out, false)
 	if err != nil {
 		dc.sndBacklog = append(dc.sndBacklog, out)
 		logger.Errorf("send frame failed: %s\n", err.Error())
@@ -1249,9 +1437,6 @@ func (dc *DuplexConnection) drainBacklog() {
 	if len(dc.sndBacklog) < 1 {
 		return
 	}
-	defer func() {
-		dc.sndBacklog = dc.sndBacklog[:0]
-	}()
 
 	dc.locker.RLock()
 	tp := dc.tp
@@ -1259,14 +1444,29 @@ func (dc *DuplexConnection) drainBacklog() {
 	if tp == nil {
 		return
 	}
-	var out core.WriteableFrame
+
+	var (
+		out  core.WriteableFrame
+		sent = len(dc.sndBacklog)
+	)
 	for i := range dc.sndBacklog {
 		out = dc.sndBacklog[i]
-		if err := tp.Send(out, false); err != nil {
-			out.Done()
+		if err := dc.sendTo(tp, out, false); err != nil {
 			logger.Errorf("send frame failed: %v\n", err)
+			// keep unsent frames in order, they will be sent after resume.
+			if dc.replay != nil {
+				sent = i
+				break
+			}
+			out.Done()
 		}
 	}
+	n := copy(dc.sndBacklog, dc.sndBacklog[sent:])
+	for i := n; i < len(dc.sndBacklog); i++ {
+		dc.sndBacklog[i] = nil
+	}
+	dc.sndBacklog = dc.sndBacklog[:n]
+
 	if err := tp.Flush(); err != nil {
 		logger.Errorf("flush failed: %v\n", err)
 	}
@@ -1378,6 +1578,7 @@ func (dc *DuplexConnection) register(sid uint32, msg interface{}) {
 func (dc *DuplexConnection) unregister(sid uint32) {
 	dc.messages.Delete(sid)
 	dc.deleteFragment(sid)
+	dc.cancelRequestContext(sid)
 }
 
 // IsSocketClosedError returns true if input error is for socket closed.
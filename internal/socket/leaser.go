@@ -35,6 +35,23 @@ func (p *leaser) allow() (err error) {
 	return
 }
 
+// remaining returns the remaining tickets and the deadline of current lease.
+// Both of them are zero if no lease has been received yet.
+func (p *leaser) remaining() (tickets int64, deadline time.Time, enabled bool) {
+	if p == nil {
+		return
+	}
+	enabled = true
+	if !p.initialized.Load() {
+		return
+	}
+	if tickets = p.tickets.Load(); tickets < 0 {
+		tickets = 0
+	}
+	deadline = time.Unix(0, p.deadline.Load())
+	return
+}
+
 //func (p *leaser) allowFrame(f framing.Frame) (err error) {
 //	if p == nil {
 //		return
@@ -71,17 +71,19 @@ func tryRecover(e interface{}) (err error) {
 	return
 }
 
+// initialRequestNFrame is the frame of RequestStream or RequestChannel.
+type initialRequestNFrame interface {
+	InitialRequestN() uint32
+}
+
 func extractRequestStreamInitN(receiving fragmentation.HeaderAndPayload) (n uint32) {
 	switch v := receiving.(type) {
-	case *framing.RequestStreamFrame:
-		n = v.InitialRequestN()
-	case *framing.RequestChannelFrame:
+	case initialRequestNFrame:
 		n = v.InitialRequestN()
+	case *decompressedPayload:
+		n = extractRequestStreamInitN(v.HeaderAndPayload)
 	case fragmentation.Joiner:
-		switch first := v.First().(type) {
-		case *framing.RequestStreamFrame:
-			n = first.InitialRequestN()
-		case *framing.RequestChannelFrame:
+		if first, ok := v.First().(initialRequestNFrame); ok {
 			n = first.InitialRequestN()
 		}
 	}
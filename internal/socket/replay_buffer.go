@@ -0,0 +1,149 @@
+package socket
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/rsocket/rsocket-go/core"
+)
+
+// DefaultResumeBufferSize is the default max bytes of frames which can be kept for resume.
+const DefaultResumeBufferSize = 16 * 1024 * 1024
+
+// replayBuffer keeps sent resumable frames until they are acknowledged by the peer.
+// Positions are counted in bytes of resumable frames, just like core.TrafficCounter.
+type replayBuffer struct {
+	mu       sync.Mutex
+	capacity int
+	size     int
+	first    uint64
+	last     uint64
+	frames   [][]byte
+}
+
+func newReplayBuffer(capacity int) *replayBuffer {
+	if capacity < 1 {
+		capacity = DefaultResumeBufferSize
+	}
+	return &replayBuffer{
+		capacity: capacity,
+	}
+}
+
+func (b *replayBuffer) setCapacity(capacity int) {
+	if capacity < 1 {
+		return
+	}
+	b.mu.Lock()
+	b.capacity = capacity
+	b.shrink()
+	b.mu.Unlock()
+}
+
+// positions returns the first available position and the last sent position.
+func (b *replayBuffer) positions() (first, last uint64) {
+	b.mu.Lock()
+	first, last = b.first, b.last
+	b.mu.Unlock()
+	return
+}
+
+// append stores a sent frame. Oldest frames will be discarded when the capacity is exceeded.
+func (b *replayBuffer) append(raw []byte) {
+	b.mu.Lock()
+	b.frames = append(b.frames, raw)
+	b.size += len(raw)
+	b.last += uint64(len(raw))
+	b.shrink()
+	b.mu.Unlock()
+}
+
+// release discards all frames which have been received by the peer.
+func (b *replayBuffer) release(position uint64) {
+	b.mu.Lock()
+	b.releaseUntil(position)
+	b.mu.Unlock()
+}
+
+// since returns frames which are sent after the position.
+// It returns false if the position cannot be covered by current buffer.
+func (b *replayBuffer) since(position uint64) (frames [][]byte, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if position < b.first || position > b.last {
+		return
+	}
+	b.releaseUntil(position)
+	if b.first != position {
+		return
+	}
+	frames = make([][]byte, len(b.frames))
+	copy(frames, b.frames)
+	ok = true
+	return
+}
+
+func (b *replayBuffer) releaseUntil(position uint64) {
+	n := 0
+	for _, next := range b.frames {
+		end := b.first + uint64(len(next))
+		if end > position {
+			break
+		}
+		b.first = end
+		b.size -= len(next)
+		n++
+	}
+	b.discard(n)
+}
+
+func (b *replayBuffer) shrink() {
+	n := 0
+	for b.size > b.capacity && n < len(b.frames) {
+		b.first += uint64(len(b.frames[n]))
+		b.size -= len(b.frames[n])
+		n++
+	}
+	b.discard(n)
+}
+
+func (b *replayBuffer) discard(n int) {
+	if n < 1 {
+		return
+	}
+	for i := 0; i < n; i++ {
+		b.frames[i] = nil
+	}
+	b.frames = b.frames[n:]
+}
+
+// replayFrame is a writeable frame which holds the raw bytes of a frame sent before.
+type replayFrame []byte
+
+func (r replayFrame) Header() core.FrameHeader {
+	return core.ParseFrameHeader(r)
+}
+
+func (r replayFrame) Len() int {
+	return len(r)
+}
+
+func (r replayFrame) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(r)
+	return int64(n), err
+}
+
+func (r replayFrame) Done() {
+}
+
+func (r replayFrame) HandleDone(func()) {
+}
+
+func encodeFrame(frame core.WriteableFrame) ([]byte, error) {
+	bf := bytes.NewBuffer(make([]byte, 0, frame.Len()))
+	if _, err := frame.WriteTo(bf); err != nil {
+		return nil, err
+	}
+	return bf.Bytes(), nil
+}
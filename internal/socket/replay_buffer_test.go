@@ -0,0 +1,190 @@
+package socket
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/rsocket/rsocket-go/internal/fragmentation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayBuffer(t *testing.T) {
+	b := newReplayBuffer(100)
+	for i := 0; i < 5; i++ {
+		b.append(make([]byte, 10))
+	}
+	first, last := b.positions()
+	assert.Equal(t, uint64(0), first)
+	assert.Equal(t, uint64(50), last)
+
+	// release the first two frames
+	b.release(25)
+	first, _ = b.positions()
+	assert.Equal(t, uint64(20), first)
+
+	// position is out of range
+	_, ok := b.since(10)
+	assert.False(t, ok)
+	_, ok = b.since(60)
+	assert.False(t, ok)
+	// position is not at the boundary of frames
+	_, ok = b.since(35)
+	assert.False(t, ok)
+
+	frames, ok := b.since(40)
+	assert.True(t, ok)
+	assert.Len(t, frames, 1)
+
+	frames, ok = b.since(50)
+	assert.True(t, ok)
+	assert.Empty(t, frames)
+}
+
+func TestReplayBuffer_Capacity(t *testing.T) {
+	b := newReplayBuffer(30)
+	for i := 0; i < 5; i++ {
+		b.append(make([]byte, 10))
+	}
+	first, last := b.positions()
+	assert.Equal(t, uint64(20), first)
+	assert.Equal(t, uint64(50), last)
+	_, ok := b.since(10)
+	assert.False(t, ok)
+
+	b.setCapacity(10)
+	first, _ = b.positions()
+	assert.Equal(t, uint64(40), first)
+}
+
+func TestReplayFrame(t *testing.T) {
+	origin := framing.NewWriteablePayloadFrame(1, []byte("foo"), []byte("bar"), core.FlagNext)
+	raw, err := encodeFrame(origin)
+	assert.NoError(t, err)
+	assert.Equal(t, origin.Len(), len(raw))
+
+	f := replayFrame(raw)
+	assert.Equal(t, origin.Header(), f.Header())
+	assert.Equal(t, origin.Len(), f.Len())
+	bf := &bytes.Buffer{}
+	_, err = f.WriteTo(bf)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, bf.Bytes())
+	origin.Done()
+}
+
+type recordConn struct {
+	frames []core.FrameHeader
+}
+
+func (r *recordConn) Close() error {
+	return nil
+}
+
+func (r *recordConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (r *recordConn) SetCounter(*core.TrafficCounter) {
+}
+
+func (r *recordConn) Read() (core.BufferedFrame, error) {
+	return nil, io.EOF
+}
+
+func (r *recordConn) Write(frame core.WriteableFrame) error {
+	r.frames = append(r.frames, frame.Header())
+	return nil
+}
+
+func (r *recordConn) Flush() error {
+	return nil
+}
+
+func TestDuplexConnection_ResumeServer(t *testing.T) {
+	dc := NewServerDuplexConnection(context.Background(), nil, nil, fragmentation.MaxFragment, nil)
+	defer dc.Close()
+
+	dc.enableResume()
+	for i := 0; i < 2; i++ {
+		raw, err := encodeFrame(framing.NewWriteablePayloadFrame(2, []byte("foo"), nil, core.FlagNext))
+		assert.NoError(t, err)
+		dc.replay.append(raw)
+	}
+	_, last := dc.replay.positions()
+
+	// server frames are unavailable
+	conn := &recordConn{}
+	err := dc.resumeServer(transport.NewTransport(conn), last+1, 0)
+	assert.Error(t, err)
+	assert.Empty(t, conn.frames)
+
+	// client frames are unavailable
+	err = dc.resumeServer(transport.NewTransport(conn), 0, 1)
+	assert.Error(t, err)
+	assert.Empty(t, conn.frames)
+
+	err = dc.resumeServer(transport.NewTransport(conn), 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, conn.frames, 3)
+	assert.Equal(t, core.FrameTypeResumeOK, conn.frames[0].Type())
+	assert.Equal(t, core.FrameTypePayload, conn.frames[1].Type())
+	assert.Equal(t, core.FrameTypePayload, conn.frames[2].Type())
+}
+
+func TestDuplexConnection_ResumeClient(t *testing.T) {
+	dc := NewClientDuplexConnection(context.Background(), nil, nil, fragmentation.MaxFragment, time.Hour)
+	defer dc.Close()
+
+	err := dc.resumeClient(transport.NewTransport(&recordConn{}), 0)
+	assert.Error(t, err, "should fail without resume")
+
+	dc.enableResume()
+	raw, err := encodeFrame(framing.NewWriteablePayloadFrame(1, []byte("foo"), nil, core.FlagNext))
+	assert.NoError(t, err)
+	dc.replay.append(raw)
+
+	conn := &recordConn{}
+	err = dc.resumeClient(transport.NewTransport(conn), uint64(len(raw)+1))
+	assert.Error(t, err)
+	assert.Empty(t, conn.frames)
+
+	err = dc.resumeClient(transport.NewTransport(conn), 0)
+	assert.NoError(t, err)
+	assert.Len(t, conn.frames, 1)
+}
+
+func TestDuplexConnection_SendResumable(t *testing.T) {
+	dc := NewClientDuplexConnection(context.Background(), nil, nil, fragmentation.MaxFragment, time.Hour)
+	defer dc.Close()
+	dc.enableResume()
+
+	conn := &recordConn{}
+	tp := transport.NewTransport(conn)
+	dc.SetTransport(tp)
+
+	var done int
+	f := framing.NewWriteablePayloadFrame(1, []byte("foo"), []byte("bar"), core.FlagNext)
+	f.HandleDone(func() {
+		done++
+	})
+	size := f.Len()
+
+	// frames to a replaced transport should be kept for later
+	err := dc.sendTo(transport.NewTransport(&recordConn{}), f, false)
+	assert.Equal(t, errTransportChanged, err)
+	assert.Equal(t, 0, done)
+
+	err = dc.sendTo(tp, f, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, done)
+	assert.Len(t, conn.frames, 1)
+	assert.Equal(t, core.FrameTypePayload, conn.frames[0].Type())
+	_, last := dc.replay.positions()
+	assert.Equal(t, uint64(size), last)
+}
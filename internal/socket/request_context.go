@@ -0,0 +1,374 @@
+package socket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/fragmentation"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+)
+
+var (
+	_ payload.Payload      = (*requestPayload)(nil)
+	_ common.Releasable    = (*requestPayload)(nil)
+	_ payload.SetupPayload = (*setupPayload)(nil)
+)
+
+type requestContextKey struct{}
+
+// requestInfo is the information of a request carried by the request context.
+type requestInfo struct {
+	sid       uint32
+	setup     *SetupInfo
+	addr      string
+	composite bool
+	// metadata is the raw metadata of request, it's decoded at the first call of CompositeMetadataFromContext.
+	metadata []byte
+	once     sync.Once
+	entries  []extension.CompositeMetadataEntry
+}
+
+// requestPayload is a payload which carries the context of request.
+// It keeps the reference count of the original payload, so the payload can be sent back as response.
+// The context of a received request is created at the first call of RequestContext, unless the request has a timeout.
+type requestPayload struct {
+	payload.Payload
+	dc         *DuplexConnection
+	sid        uint32
+	stream     bool
+	timeout    time.Duration
+	hasTimeout bool
+	mu         sync.Mutex
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       bool
+}
+
+func (r *requestPayload) IncRef() int32 {
+	if releasable, ok := r.Payload.(common.Releasable); ok {
+		return releasable.IncRef()
+	}
+	return 0
+}
+
+func (r *requestPayload) RefCnt() int32 {
+	if releasable, ok := r.Payload.(common.Releasable); ok {
+		return releasable.RefCnt()
+	}
+	return 0
+}
+
+func (r *requestPayload) Release() {
+	common.TryRelease(r.Payload)
+}
+
+// context returns the context of request, it's created if it doesn't exist.
+func (r *requestPayload) context() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx != nil || r.dc == nil {
+		return r.ctx
+	}
+	r.ctx = context.WithValue(r.dc.ctx, requestContextKey{}, r.dc.newRequestInfo(r.sid, r.Payload))
+	if !r.stream {
+		return r.ctx
+	}
+	if r.hasTimeout {
+		r.ctx, r.cancel = context.WithTimeout(r.ctx, r.timeout)
+	} else {
+		r.ctx, r.cancel = context.WithCancel(r.ctx)
+	}
+	if r.done {
+		r.cancel()
+	}
+	return r.ctx
+}
+
+// finish cancels the context of request, the context created later is cancelled already.
+func (r *requestPayload) finish() {
+	r.mu.Lock()
+	r.done = true
+	cancel := r.cancel
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// withDeadline returns a subscriber which will be terminated once the timeout of request passes.
+func (r *requestPayload) withDeadline(actual rx.Subscriber, onExpired func()) rx.Subscriber {
+	if !r.hasTimeout {
+		return actual
+	}
+	return withDeadline(r.context(), actual, onExpired)
+}
+
+// WithContext returns a payload which carries the given context.
+// The deadline of context will be sent as the timeout of request instead of the deadline of subscriber context.
+func WithContext(ctx context.Context, message payload.Payload) payload.Payload {
+	if r, ok := message.(*requestPayload); ok {
+		message = r.Payload
+	}
+	return &requestPayload{
+		Payload: message,
+		ctx:     ctx,
+	}
+}
+
+// RequestContext returns the context carried by the payload.
+// It returns context.Background() if the payload doesn't carry any context.
+func RequestContext(message payload.Payload) context.Context {
+	if r, ok := message.(*requestPayload); ok {
+		return r.context()
+	}
+	return context.Background()
+}
+
+func requestInfoFrom(ctx context.Context) (*requestInfo, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	info, ok := ctx.Value(requestContextKey{}).(*requestInfo)
+	return info, ok
+}
+
+// StreamIDFromContext returns the stream id of request context.
+func StreamIDFromContext(ctx context.Context) (uint32, bool) {
+	if info, ok := requestInfoFrom(ctx); ok {
+		return info.sid, true
+	}
+	return 0, false
+}
+
+// SetupPayloadFromContext returns the SetupPayload of the connection which request context belongs to.
+func SetupPayloadFromContext(ctx context.Context) (payload.SetupPayload, bool) {
+	if info, ok := requestInfoFrom(ctx); ok && info.setup != nil {
+		return info.setup.Payload(), true
+	}
+	return nil, false
+}
+
+// PeerAddrFromContext returns the peer address of request context.
+func PeerAddrFromContext(ctx context.Context) (string, bool) {
+	if info, ok := requestInfoFrom(ctx); ok && len(info.addr) > 0 {
+		return info.addr, true
+	}
+	return "", false
+}
+
+// CompositeMetadataFromContext returns the decoded composite metadata of request context.
+// It returns false if the metadata MIME type of the connection is not composite metadata.
+func CompositeMetadataFromContext(ctx context.Context) ([]extension.CompositeMetadataEntry, bool) {
+	info, ok := requestInfoFrom(ctx)
+	if !ok || !info.composite {
+		return nil, false
+	}
+	info.once.Do(func() {
+		if len(info.metadata) < 1 {
+			return
+		}
+		// clone metadata because the frame will be released after the request is done.
+		entries, err := extension.NewCompositeMetadataBytes(common.CloneBytes(info.metadata)).Entries()
+		if err == nil {
+			info.entries = entries
+		}
+		info.metadata = nil
+	})
+	return info.entries, true
+}
+
+// newRequestInfo returns the information of a request.
+func (dc *DuplexConnection) newRequestInfo(sid uint32, req payload.Payload) *requestInfo {
+	info := &requestInfo{
+		sid:   sid,
+		setup: dc.setupInfo(),
+	}
+	if tp := dc.currentTransport(); tp != nil {
+		info.addr, _ = tp.Addr()
+	}
+	if info.setup != nil && string(info.setup.MetadataMimeType) == extension.MessageCompositeMetadata.String() {
+		info.composite = true
+		info.metadata, _ = req.Metadata()
+	}
+	return info
+}
+
+// withRequestContext wraps the request with a context which will be cancelled when the stream is cancelled or finished.
+// The context is created lazily, except that the request carries a timeout, then the deadline of context is set.
+func (dc *DuplexConnection) withRequestContext(sid uint32, req fragmentation.HeaderAndPayload) *requestPayload {
+	r := &requestPayload{
+		Payload: req,
+		dc:      dc,
+		sid:     sid,
+		stream:  true,
+	}
+	r.timeout, r.hasTimeout = dc.requestTimeout(req)
+	dc.contexts.Store(sid, r)
+	if r.hasTimeout {
+		// create the context now, so the stream is terminated once the deadline passes.
+		r.context()
+	}
+	return r
+}
+
+// withConnectionContext wraps the request with a context which will be cancelled when the connection is closed.
+// It is used by the requests without stream, eg: FireAndForget and MetadataPush.
+func (dc *DuplexConnection) withConnectionContext(sid uint32, req fragmentation.HeaderAndPayload) *requestPayload {
+	return &requestPayload{
+		Payload: req,
+		dc:      dc,
+		sid:     sid,
+	}
+}
+
+// requestTimeout returns the timeout entry of request, it returns false if the request has no timeout.
+func (dc *DuplexConnection) requestTimeout(req payload.Payload) (timeout time.Duration, ok bool) {
+	if setup := dc.setupInfo(); setup == nil || string(setup.MetadataMimeType) != extension.MessageCompositeMetadata.String() {
+		return
+	}
+	metadata, _ := req.Metadata()
+	if len(metadata) < 1 {
+		return
+	}
+	raw, ok, err := extension.CompositeMetadata(metadata).Lookup(extension.TimeoutMimeType)
+	if err != nil || !ok {
+		return 0, false
+	}
+	timeout, err = extension.ParseTimeout(raw)
+	if err != nil {
+		return 0, false
+	}
+	return timeout, true
+}
+
+// requestMetadata returns the metadata of request to be sent.
+// If the context of subscriber has a deadline, and the metadata MIME type of connection is composite metadata,
+// the remaining time is appended as the timeout entry, and any existing timeout entry is replaced.
+// The context carried by the request overrides the context of subscriber, see WithContext.
+func (dc *DuplexConnection) requestMetadata(ctx context.Context, req payload.Payload) []byte {
+	metadata, _ := req.Metadata()
+	if r, ok := req.(*requestPayload); ok {
+		ctx = r.context()
+	}
+	if ctx == nil {
+		return metadata
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return metadata
+	}
+	if setup := dc.setupInfo(); setup == nil || string(setup.MetadataMimeType) != extension.MessageCompositeMetadata.String() {
+		return metadata
+	}
+	entries, err := extension.NewCompositeMetadataBytes(metadata).Entries()
+	if err != nil {
+		return metadata
+	}
+	builder := extension.NewCompositeMetadataBuilder()
+	for _, it := range entries {
+		if it.MimeType != extension.TimeoutMimeType {
+			builder.Push(it.MimeType, it.Metadata)
+		}
+	}
+	builder.Push(extension.TimeoutMimeType, extension.EncodeTimeout(time.Until(deadline)))
+	if composed, err := builder.Build(); err == nil {
+		metadata = composed
+	}
+	return metadata
+}
+
+func (dc *DuplexConnection) cancelRequestContext(sid uint32) {
+	if r, ok := dc.contexts.LoadAndDelete(sid); ok {
+		r.(*requestPayload).finish()
+	}
+}
+
+func (dc *DuplexConnection) destroyRequestContexts() {
+	dc.contexts.Range(func(sid, r interface{}) bool {
+		dc.contexts.Delete(sid)
+		r.(*requestPayload).finish()
+		return true
+	})
+}
+
+// SetSetupInfo sets the setup info of current connection.
+func (dc *DuplexConnection) SetSetupInfo(setup *SetupInfo) {
+	dc.locker.Lock()
+	dc.setup = setup
+	dc.locker.Unlock()
+}
+
+func (dc *DuplexConnection) setupInfo() (setup *SetupInfo) {
+	dc.locker.RLock()
+	setup = dc.setup
+	dc.locker.RUnlock()
+	return
+}
+
+// NewSetupInfo returns a SetupInfo copied from SETUP frame.
+func NewSetupInfo(frame *framing.SetupFrame) *SetupInfo {
+	metadata, _ := frame.Metadata()
+	return &SetupInfo{
+		Lease:             frame.HasFlag(core.FlagLease),
+		Version:           frame.Version(),
+		KeepaliveInterval: frame.TimeBetweenKeepalive(),
+		KeepaliveLifetime: frame.MaxLifetime(),
+		Token:             common.CloneBytes(frame.Token()),
+		DataMimeType:      []byte(frame.DataMimeType()),
+		Data:              common.CloneBytes(frame.Data()),
+		MetadataMimeType:  []byte(frame.MetadataMimeType()),
+		Metadata:          common.CloneBytes(metadata),
+	}
+}
+
+// Payload returns the SetupInfo as a SetupPayload.
+func (p *SetupInfo) Payload() payload.SetupPayload {
+	return setupPayload{p}
+}
+
+// setupPayload is the SetupPayload of a SetupInfo.
+type setupPayload struct {
+	*SetupInfo
+}
+
+func (s setupPayload) Metadata() ([]byte, bool) {
+	return s.SetupInfo.Metadata, len(s.SetupInfo.Metadata) > 0
+}
+
+func (s setupPayload) MetadataUTF8() (string, bool) {
+	return string(s.SetupInfo.Metadata), len(s.SetupInfo.Metadata) > 0
+}
+
+func (s setupPayload) Data() []byte {
+	return s.SetupInfo.Data
+}
+
+func (s setupPayload) DataUTF8() string {
+	return string(s.SetupInfo.Data)
+}
+
+func (s setupPayload) DataMimeType() string {
+	return string(s.SetupInfo.DataMimeType)
+}
+
+func (s setupPayload) MetadataMimeType() string {
+	return string(s.SetupInfo.MetadataMimeType)
+}
+
+func (s setupPayload) TimeBetweenKeepalive() time.Duration {
+	return s.KeepaliveInterval
+}
+
+func (s setupPayload) MaxLifetime() time.Duration {
+	return s.KeepaliveLifetime
+}
+
+func (s setupPayload) Version() core.Version {
+	return s.SetupInfo.Version
+}
@@ -0,0 +1,246 @@
+package socket
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSetupInfo(t *testing.T) {
+	frame := framing.NewSetupFrame(core.DefaultVersion, time.Second, 3*time.Second, []byte("token"), []byte("text/plain"), []byte("application/json"), []byte("data"), []byte("metadata"), true)
+	defer frame.Release()
+	setup := NewSetupInfo(frame).Payload()
+	assert.Equal(t, core.DefaultVersion, setup.Version())
+	assert.Equal(t, time.Second, setup.TimeBetweenKeepalive())
+	assert.Equal(t, 3*time.Second, setup.MaxLifetime())
+	assert.Equal(t, "text/plain", setup.MetadataMimeType())
+	assert.Equal(t, "application/json", setup.DataMimeType())
+	assert.Equal(t, "data", setup.DataUTF8())
+	metadata, ok := setup.MetadataUTF8()
+	assert.True(t, ok)
+	assert.Equal(t, "metadata", metadata)
+}
+
+func TestRequestContext(t *testing.T) {
+	ctx := RequestContext(payload.NewString("foo", "bar"))
+	assert.Equal(t, context.Background(), ctx)
+	_, ok := StreamIDFromContext(ctx)
+	assert.False(t, ok)
+	_, ok = SetupPayloadFromContext(ctx)
+	assert.False(t, ok)
+	_, ok = PeerAddrFromContext(ctx)
+	assert.False(t, ok)
+	_, ok = CompositeMetadataFromContext(ctx)
+	assert.False(t, ok)
+
+	dc := NewServerDuplexConnection(context.Background(), nil, nil, 0, nil)
+	defer dc.Close()
+	dc.SetSetupInfo(&SetupInfo{
+		MetadataMimeType: []byte(extension.MessageCompositeMetadata.String()),
+	})
+
+	metadata, err := extension.NewCompositeMetadataBuilder().PushWellKnownString(extension.TextPlain, "hello").Build()
+	require.NoError(t, err)
+	frame := framing.NewRequestResponseFrame(1, []byte("data"), metadata, 0)
+	req := dc.withRequestContext(1, frame)
+	assert.Equal(t, frame.RefCnt(), req.RefCnt())
+
+	ctx = RequestContext(req)
+	sid, ok := StreamIDFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), sid)
+	setup, ok := SetupPayloadFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, extension.MessageCompositeMetadata.String(), setup.MetadataMimeType())
+	entries, ok := CompositeMetadataFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, []extension.CompositeMetadataEntry{{MimeType: extension.TextPlain.String(), Metadata: []byte("hello")}}, entries)
+
+	// context should be cancelled by CANCEL frame.
+	assert.NoError(t, ctx.Err())
+	req.Release()
+	assert.NoError(t, dc.onFrameCancel(framing.NewCancelFrame(1)))
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	// context should be cancelled after the stream is done.
+	stream := framing.NewRequestStreamFrame(3, 1, []byte("data"), nil, 0)
+	defer stream.Release()
+	ctx = RequestContext(dc.withRequestContext(3, stream))
+	dc.unregister(3)
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	// context should be cancelled after the connection is closed.
+	ctx = RequestContext(dc.withRequestContext(5, stream))
+	_ = dc.Close()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestRequestContext_Lazy(t *testing.T) {
+	dc := NewServerDuplexConnection(context.Background(), nil, nil, 0, nil)
+	defer dc.Close()
+	dc.SetSetupInfo(&SetupInfo{
+		MetadataMimeType: []byte(extension.MessageCompositeMetadata.String()),
+	})
+
+	metadata, err := extension.NewCompositeMetadataBuilder().PushWellKnownString(extension.TextPlain, "hello").Build()
+	require.NoError(t, err)
+	frame := framing.NewRequestResponseFrame(1, []byte("data"), metadata, 0)
+	defer frame.Release()
+
+	// the context is not created until it's required.
+	req := dc.withRequestContext(1, frame)
+	assert.Nil(t, req.ctx)
+	ctx := RequestContext(req)
+	assert.NotNil(t, req.ctx)
+	info, ok := requestInfoFrom(ctx)
+	require.True(t, ok)
+	assert.Nil(t, info.entries, "metadata should be decoded lazily")
+	entries, ok := CompositeMetadataFromContext(ctx)
+	assert.True(t, ok)
+	assert.Len(t, entries, 1)
+
+	// the context created after the stream is done is cancelled already.
+	req = dc.withRequestContext(3, frame)
+	dc.unregister(3)
+	assert.Equal(t, context.Canceled, RequestContext(req).Err())
+
+	// the context is created at once if the request has a timeout.
+	metadata, err = extension.NewCompositeMetadataBuilder().Push(extension.TimeoutMimeType, extension.EncodeTimeout(time.Second)).Build()
+	require.NoError(t, err)
+	timed := framing.NewRequestResponseFrame(5, []byte("data"), metadata, 0)
+	defer timed.Release()
+	req = dc.withRequestContext(5, timed)
+	require.NotNil(t, req.ctx)
+	_, ok = req.ctx.Deadline()
+	assert.True(t, ok)
+	dc.unregister(5)
+}
+
+func TestDuplexConnection_RequestMetadata(t *testing.T) {
+	dc := NewClientDuplexConnection(context.Background(), nil, nil, 0, time.Hour)
+	defer dc.Close()
+
+	routing, err := extension.EncodeRouting("foo")
+	require.NoError(t, err)
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageRouting, routing).
+		Push(extension.TimeoutMimeType, extension.EncodeTimeout(time.Hour)).
+		Build()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := WithContext(ctx, payload.New([]byte("data"), metadata))
+	assert.Equal(t, ctx, RequestContext(req))
+
+	// metadata is kept if metadata MIME type is not composite metadata.
+	assert.Equal(t, []byte(metadata), dc.requestMetadata(context.Background(), req))
+
+	dc.SetSetupInfo(&SetupInfo{
+		MetadataMimeType: []byte(extension.MessageCompositeMetadata.String()),
+	})
+	assert.Equal(t, []byte(metadata), dc.requestMetadata(context.Background(), payload.New([]byte("data"), metadata)))
+
+	// the deadline of subscriber context is sent if the request doesn't carry a context.
+	entries, err := extension.NewCompositeMetadataBytes(dc.requestMetadata(ctx, payload.New([]byte("data"), metadata))).Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, extension.TimeoutMimeType, entries[1].MimeType)
+
+	entries, err = extension.NewCompositeMetadataBytes(dc.requestMetadata(context.Background(), req)).Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, extension.MessageRouting.String(), entries[0].MimeType)
+	assert.Equal(t, extension.TimeoutMimeType, entries[1].MimeType)
+	timeout, err := extension.ParseTimeout(entries[1].Metadata)
+	require.NoError(t, err)
+	assert.True(t, timeout > 0 && timeout <= time.Second, "bad timeout: %s", timeout)
+
+	// the responder context has the deadline of request.
+	frame := framing.NewRequestResponseFrame(2, []byte("data"), dc.requestMetadata(context.Background(), req), 0)
+	defer frame.Release()
+	deadline, ok := RequestContext(dc.withRequestContext(2, frame)).Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(timeout), deadline, 100*time.Millisecond)
+	dc.unregister(2)
+}
+
+type recordSubscriber struct {
+	mu        sync.Mutex
+	values    []string
+	err       error
+	completed bool
+	done      chan struct{}
+}
+
+func (r *recordSubscriber) OnNext(next payload.Payload) {
+	r.mu.Lock()
+	r.values = append(r.values, next.DataUTF8())
+	r.mu.Unlock()
+}
+
+func (r *recordSubscriber) OnError(err error) {
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+	close(r.done)
+}
+
+func (r *recordSubscriber) OnComplete() {
+	r.mu.Lock()
+	r.completed = true
+	r.mu.Unlock()
+	close(r.done)
+}
+
+func (r *recordSubscriber) OnSubscribe(ctx context.Context, su rx.Subscription) {
+	su.Request(rx.RequestMax)
+}
+
+func TestDeadlineSubscriber(t *testing.T) {
+	ctx := context.Background()
+	actual := &recordSubscriber{done: make(chan struct{})}
+	assert.Equal(t, actual, withDeadline(ctx, actual, nil), "should return actual subscriber without deadline")
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	expired := make(chan struct{})
+	// the publisher blocks in subscribing until it is released.
+	go flux.
+		Create(func(ctx context.Context, sink flux.Sink) {
+			sink.Next(payload.NewString("foo", ""))
+			<-release
+			sink.Next(payload.NewString("bar", ""))
+			sink.Complete()
+		}).
+		SubscribeWith(context.Background(), withDeadline(ctx, actual, func() {
+			close(expired)
+		}))
+
+	select {
+	case <-expired:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "subscriber should be terminated by deadline")
+	}
+	<-actual.done
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+
+	actual.mu.Lock()
+	defer actual.mu.Unlock()
+	assert.Equal(t, []string{"foo"}, actual.values)
+	assert.Equal(t, errRequestTimeout, actual.err)
+	assert.False(t, actual.completed)
+}
@@ -13,22 +13,85 @@ import (
 	"go.uber.org/atomic"
 )
 
-const _resumeReconnectDelay = 1 * time.Second
-const _resumeTimeout = 10 * time.Second
+const (
+	_resumeReconnectDelay = 1 * time.Second
+	_resumeTimeout        = 10 * time.Second
+)
+
+var (
+	errResumeTimeout   = errors.New("resume timeout")
+	errResumeBroken    = errors.New("connection closed before resume")
+	errResumeExhausted = errors.New("resume attempts exhausted")
+)
+
+// ResumeOptions controls how a resumable client reconnects after the connection is lost.
+type ResumeOptions struct {
+	// Backoff returns the delay before the given reconnect attempt, the first attempt is 1.
+	// Default is a fixed delay of 1s.
+	Backoff func(attempt int) time.Duration
+	// MaxAttempts is the max number of reconnect attempts, zero means no limit.
+	MaxAttempts int
+	// MaxWindow is the max duration since the connection is lost, zero means no limit.
+	MaxWindow time.Duration
+	// Timeout is the duration of waiting for RESUME_OK, default is 10s.
+	Timeout time.Duration
+	// OnAttempt will be called before each reconnect attempt.
+	OnAttempt func(attempt int)
+	// OnResumed will be called after the session is resumed.
+	OnResumed func(attempt int)
+	// OnRejected will be called when the server rejects resuming.
+	OnRejected func(err error)
+}
+
+func (o *ResumeOptions) backoff(attempt int) time.Duration {
+	if o.Backoff == nil {
+		return _resumeReconnectDelay
+	}
+	return o.Backoff(attempt)
+}
+
+func (o *ResumeOptions) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return _resumeTimeout
+	}
+	return o.Timeout
+}
 
 type resumeClientSocket struct {
 	*BaseSocket
 	connects *atomic.Int32
 	setup    *SetupInfo
 	tp       transport.ClientTransporter
+	opts     *ResumeOptions
 }
 
 func (r *resumeClientSocket) Setup(ctx context.Context, timeout time.Duration, setup *SetupInfo) error {
 	r.setup = setup
+	r.setMimeTypes(setup)
+	r.socket.SetSetupInfo(setup)
 	go func(ctx context.Context) {
 		_ = r.socket.LoopWrite(ctx)
 	}(ctx)
-	return r.connect(ctx, timeout)
+	r.connects.Inc()
+	tp, err := r.createTransport(ctx, timeout)
+	if err != nil {
+		return err
+	}
+	tp.Handle(transport.OnErrorWithZeroStreamID, func(frame core.BufferedFrame) (err error) {
+		defer frame.Release()
+		r.socket.SetError(frame.(*framing.ErrorFrame).ToError())
+		r.markAsClosing()
+		return
+	})
+	stopped := r.start(ctx, tp)
+	go r.keepConnected(ctx, timeout, stopped)
+	err = tp.Send(r.setup.toFrame(), true)
+	r.socket.SetTransport(tp)
+	// destroy if setup failed
+	if err != nil {
+		_ = r.close(false)
+	}
+	return err
 }
 
 func (r *resumeClientSocket) Close() (err error) {
@@ -49,65 +112,116 @@ func (r *resumeClientSocket) createTransport(ctx context.Context, timeout time.D
 		defer cancel()
 		tpCtx = c
 	}
-	return r.tp(tpCtx)
-}
-
-func (r *resumeClientSocket) connect(ctx context.Context, timeout time.Duration) (err error) {
-	connects := r.connects.Inc()
-	if connects < 0 {
-		_ = r.Close()
-		return
-	}
-	tp, err := r.createTransport(ctx, timeout)
+	tp, err := r.tp(tpCtx)
 	if err != nil {
-		if connects == 1 {
-			return
-		}
-		time.Sleep(_resumeReconnectDelay)
-		_ = r.connect(ctx, timeout)
-		return
+		return nil, err
 	}
 	tp.Connection().SetCounter(r.socket.counter)
 	tp.SetLifetime(r.setup.KeepaliveLifetime)
+	return tp, nil
+}
 
-	go func(ctx context.Context, tp *transport.Transport) {
-		defer func() {
-			r.socket.clearTransport()
-			if r.isClosed() {
-				_ = r.Close()
-				return
-			}
-			time.Sleep(_resumeReconnectDelay)
-			_ = r.connect(ctx, timeout)
-		}()
+// start starts the transport, the returned channel will be closed after the transport stopped.
+func (r *resumeClientSocket) start(ctx context.Context, tp *transport.Transport) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func(ctx context.Context) {
+		defer close(stopped)
 		err := tp.Start(ctx)
 		if err != nil && logger.IsDebugEnabled() {
 			logger.Debugf("resumable client stopped: %s\n", err)
 		}
-	}(ctx, tp)
+	}(ctx)
+	return stopped
+}
+
+// keepConnected resumes the session each time the current transport stopped, until the socket is closed.
+func (r *resumeClientSocket) keepConnected(ctx context.Context, timeout time.Duration, stopped <-chan struct{}) {
+	defer func() {
+		_ = r.Close()
+	}()
+	for {
+		<-stopped
+		r.socket.clearTransport()
+		if r.isClosed() {
+			return
+		}
+		var ok bool
+		if stopped, ok = r.reconnect(ctx, timeout); !ok {
+			return
+		}
+	}
+}
 
-	// connect first time.
-	if len(r.setup.Token) < 1 || connects == 1 {
-		tp.Handle(transport.OnErrorWithZeroStreamID, func(frame core.BufferedFrame) (err error) {
-			defer frame.Release()
-			r.socket.SetError(frame.(*framing.ErrorFrame).ToError())
+func (r *resumeClientSocket) reconnect(ctx context.Context, timeout time.Duration) (stopped <-chan struct{}, ok bool) {
+	lost := time.Now()
+	for attempt := 1; ; attempt++ {
+		if r.opts.MaxAttempts > 0 && attempt > r.opts.MaxAttempts {
+			r.socket.SetError(errResumeExhausted)
+			return
+		}
+		delay := r.opts.backoff(attempt)
+		if r.opts.MaxWindow > 0 && time.Since(lost)+delay > r.opts.MaxWindow {
+			r.socket.SetError(errResumeExhausted)
+			return
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if r.isClosed() {
+			return
+		}
+		if r.opts.OnAttempt != nil {
+			r.opts.OnAttempt(attempt)
+		}
+		var (
+			fatal bool
+			err   error
+		)
+		stopped, fatal, err = r.resume(ctx, timeout)
+		if err == nil {
+			if r.opts.OnResumed != nil {
+				r.opts.OnResumed(attempt)
+			}
+			ok = true
+			return
+		}
+		logger.Errorf("resume failed: %s\n", err.Error())
+		if fatal {
+			r.socket.SetError(err)
 			r.markAsClosing()
 			return
-		})
-		err = tp.Send(r.setup.toFrame(), true)
-		r.socket.SetTransport(tp)
-		// destroy if setup failed
-		if err != nil {
-			_ = r.close(false)
 		}
+	}
+}
+
+// resume sends RESUME by a new transport, fatal will be true if the session cannot be resumed anymore.
+func (r *resumeClientSocket) resume(ctx context.Context, timeout time.Duration) (stopped <-chan struct{}, fatal bool, err error) {
+	if r.connects.Inc() < 0 {
+		err = errResumeBroken
+		fatal = true
+		return
+	}
+	tp, err := r.createTransport(ctx, timeout)
+	if err != nil {
 		return
 	}
 
-	resumeErr := make(chan error)
+	// Register frame handlers before resuming, the frames retransmitted by server may arrive right after RESUME_OK.
+	r.socket.bindHandlers(tp)
+
+	resumeOK := make(chan uint64, 1)
+	resumeErr := make(chan error, 1)
 
 	tp.Handle(transport.OnResumeOK, func(frame core.BufferedFrame) (err error) {
 		defer frame.Release()
-		close(resumeErr)
+		select {
+		case resumeOK <- frame.(*framing.ResumeOKFrame).LastReceivedClientPosition():
+		default:
+		}
 		return
 	})
 
@@ -116,42 +230,48 @@ func (r *resumeClientSocket) connect(ctx context.Context, timeout time.Duration)
 		// TODO: process other error with zero StreamID
 		errFrame := frame.(*framing.ErrorFrame)
 		if errFrame.ErrorCode() == core.ErrorCodeRejectedResume {
-			defer func() {
-				if err := recover(); err != nil {
-					logger.Warnf("handle reject resume failed: %s\n", err)
-				}
-			}()
-			resumeErr <- errFrame.ToError()
-			close(resumeErr)
+			select {
+			case resumeErr <- errFrame.ToError():
+			default:
+			}
 		}
 		return nil
 	})
 
+	stopped = r.start(ctx, tp)
+
+	lastReceived, firstAvailable := r.socket.resumePositions()
 	err = tp.Send(framing.NewWriteableResumeFrame(
 		core.DefaultVersion,
 		r.setup.Token,
-		r.socket.counter.WriteBytes(),
-		r.socket.counter.ReadBytes(),
+		firstAvailable,
+		lastReceived,
 	), true)
-
 	if err != nil {
-		// destroy if resume failed
-		_ = r.close(false)
-		return err
+		_ = tp.Close()
+		return
 	}
 
+	timer := time.NewTimer(r.opts.timeout())
+	defer timer.Stop()
+
 	select {
-	case <-time.After(_resumeTimeout):
-		err = errors.New("resume timeout")
-		// destroy if resume failed
-		_ = r.close(false)
-	case reject, ok := <-resumeErr:
-		if ok {
-			logger.Errorf("resume failed: %s\n", reject.Error())
-			r.markAsClosing()
-			err = r.connect(ctx, timeout)
-		} else {
-			r.socket.SetTransport(tp)
+	case <-timer.C:
+		err = errResumeTimeout
+		_ = tp.Close()
+	case <-stopped:
+		err = errResumeBroken
+	case err = <-resumeErr:
+		fatal = true
+		if r.opts.OnRejected != nil {
+			r.opts.OnRejected(err)
+		}
+		_ = tp.Close()
+	case position := <-resumeOK:
+		if err = r.socket.resumeClient(tp, position); err != nil {
+			fatal = true
+			_ = tp.Send(framing.NewWriteableErrorFrame(0, core.ErrorCodeConnectionError, []byte(err.Error())), true)
+			_ = tp.Close()
 		}
 	}
 	return
@@ -166,10 +286,16 @@ func (r *resumeClientSocket) isClosed() bool {
 }
 
 // NewResumableClientSocket creates a client-side socket with resume support.
-func NewResumableClientSocket(tp transport.ClientTransporter, socket *DuplexConnection) ClientSocket {
+// Nil opts means reconnecting every second without any limit.
+func NewResumableClientSocket(tp transport.ClientTransporter, socket *DuplexConnection, opts *ResumeOptions) ClientSocket {
+	if opts == nil {
+		opts = &ResumeOptions{}
+	}
+	socket.enableResume()
 	return &resumeClientSocket{
 		BaseSocket: NewBaseSocket(socket),
 		connects:   atomic.NewInt32(0),
 		tp:         tp,
+		opts:       opts,
 	}
 }
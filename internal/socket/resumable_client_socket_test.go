@@ -53,7 +53,7 @@ func TestNewResumableClientSocket(t *testing.T) {
 
 	rcs := socket.NewResumableClientSocket(func(ctx context.Context) (*transport.Transport, error) {
 		return tp, nil
-	}, ds)
+	}, ds, nil)
 
 	onCloseCalled := atomic.NewBool(false)
 	rcs.OnClose(func(err error) {
@@ -112,7 +112,7 @@ func TestResumeClientSocket_Setup_Broken(t *testing.T) {
 	c := socket.NewClientDuplexConnection(context.Background(), nil, nil, fragmentation.MaxFragment, 90*time.Second)
 	s := socket.NewResumableClientSocket(func(ctx context.Context) (*transport.Transport, error) {
 		return nil, fakeErr
-	}, c)
+	}, c, nil)
 	defer s.Close()
 	err := s.Setup(context.Background(), 0, fakeResumableSetup)
 	assert.Error(t, err)
@@ -153,7 +153,7 @@ func TestResumeClientSocket_Setup(t *testing.T) {
 		readChanChan <- readChan
 
 		return tp, nil
-	}, ds)
+	}, ds, nil)
 
 	onCloseCalled := atomic.NewBool(false)
 	rcs.OnClose(func(err error) {
@@ -184,3 +184,131 @@ func TestResumeClientSocket_Setup(t *testing.T) {
 	close(readChan)
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestResumeClientSocket_MaxAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ds := socket.NewClientDuplexConnection(context.Background(), nil, nil, fragmentation.MaxFragment, 90*time.Second)
+
+	readChan := make(chan core.BufferedFrame, 64)
+	createTimes := atomic.NewInt32(0)
+	attempts := atomic.NewInt32(0)
+	var delays []int
+	rcs := socket.NewResumableClientSocket(func(ctx context.Context) (*transport.Transport, error) {
+		if createTimes.Inc() > 1 {
+			return nil, fakeErr
+		}
+		conn, tp := InitTransportWithController(ctrl)
+		conn.EXPECT().Close().AnyTimes()
+		conn.EXPECT().SetCounter(gomock.Any()).Times(1)
+		conn.EXPECT().Write(gomock.Any()).Return(nil).AnyTimes()
+		conn.EXPECT().Flush().AnyTimes()
+		conn.EXPECT().Read().DoAndReturn(func() (core.BufferedFrame, error) {
+			next, ok := <-readChan
+			if !ok {
+				return nil, io.EOF
+			}
+			return next, nil
+		}).AnyTimes()
+		conn.EXPECT().SetDeadline(gomock.Any()).AnyTimes()
+		return tp, nil
+	}, ds, &socket.ResumeOptions{
+		Backoff: func(attempt int) time.Duration {
+			delays = append(delays, attempt)
+			return 10 * time.Millisecond
+		},
+		MaxAttempts: 3,
+		OnAttempt: func(attempt int) {
+			attempts.Inc()
+		},
+	})
+
+	closed := make(chan error, 1)
+	rcs.OnClose(func(err error) {
+		closed <- err
+	})
+
+	err := rcs.Setup(context.Background(), 0, fakeResumableSetup)
+	assert.NoError(t, err)
+
+	close(readChan)
+
+	select {
+	case err := <-closed:
+		assert.Error(t, err)
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "client should be closed after all attempts failed")
+	}
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Equal(t, []int{1, 2, 3}, delays)
+}
+
+func TestResumeClientSocket_Rejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ds := socket.NewClientDuplexConnection(context.Background(), nil, nil, fragmentation.MaxFragment, 90*time.Second)
+
+	readChanChan := make(chan chan core.BufferedFrame, 64)
+	rejected := make(chan error, 1)
+	resumed := atomic.NewBool(false)
+
+	rcs := socket.NewResumableClientSocket(func(ctx context.Context) (*transport.Transport, error) {
+		conn, tp := InitTransportWithController(ctrl)
+		readChan := make(chan core.BufferedFrame, 64)
+		conn.EXPECT().Close().AnyTimes()
+		conn.EXPECT().SetCounter(gomock.Any()).Times(1)
+		conn.EXPECT().Write(gomock.Any()).Return(nil).AnyTimes()
+		conn.EXPECT().Flush().AnyTimes()
+		conn.EXPECT().Read().DoAndReturn(func() (core.BufferedFrame, error) {
+			next, ok := <-readChan
+			if !ok {
+				return nil, io.EOF
+			}
+			return next, nil
+		}).AnyTimes()
+		conn.EXPECT().SetDeadline(gomock.Any()).AnyTimes()
+		readChanChan <- readChan
+		return tp, nil
+	}, ds, &socket.ResumeOptions{
+		Backoff: func(attempt int) time.Duration {
+			return 10 * time.Millisecond
+		},
+		OnResumed: func(attempt int) {
+			resumed.Store(true)
+		},
+		OnRejected: func(err error) {
+			rejected <- err
+		},
+	})
+
+	closed := make(chan struct{})
+	rcs.OnClose(func(err error) {
+		close(closed)
+	})
+
+	err := rcs.Setup(context.Background(), 0, fakeResumableSetup)
+	assert.NoError(t, err)
+
+	close(<-readChanChan)
+
+	readChan := <-readChanChan
+	readChan <- framing.NewErrorFrame(0, core.ErrorCodeRejectedResume, []byte("fake reject error"))
+
+	select {
+	case err := <-rejected:
+		assert.Equal(t, core.ErrorCodeRejectedResume, err.(core.CustomError).ErrorCode())
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "resume should be rejected")
+	}
+	close(readChan)
+
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "client should be closed after rejection")
+	}
+	assert.False(t, resumed.Load())
+	assert.Len(t, readChanChan, 0, "should not reconnect after rejection")
+}
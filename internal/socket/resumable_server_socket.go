@@ -3,6 +3,7 @@ package socket
 import (
 	"context"
 
+	"github.com/rsocket/rsocket-go/core/framing"
 	"github.com/rsocket/rsocket-go/core/transport"
 )
 
@@ -16,11 +17,17 @@ func (p *resumeServerSocket) Pause() bool {
 	return true
 }
 
+func (p *resumeServerSocket) Resume(frame *framing.ResumeFrame, tp *transport.Transport) error {
+	tp.Connection().SetCounter(p.socket.counter)
+	return p.socket.resumeServer(tp, frame.LastReceivedServerPosition(), frame.FirstAvailableClientPosition())
+}
+
 func (p *resumeServerSocket) SetResponder(responder Responder) {
 	p.socket.SetResponder(responder)
 }
 
 func (p *resumeServerSocket) SetTransport(tp *transport.Transport) {
+	tp.Connection().SetCounter(p.socket.counter)
 	p.socket.SetTransport(tp)
 }
 
@@ -38,6 +45,9 @@ func (p *resumeServerSocket) Start(ctx context.Context) error {
 
 // NewResumableServerSocket creates a new server-side socket with resume support.
 func NewResumableServerSocket(socket *DuplexConnection, token []byte) ServerSocket {
+	if socket != nil {
+		socket.enableResume()
+	}
 	return &resumeServerSocket{
 		BaseSocket: NewBaseSocket(socket),
 		token:      token,
@@ -12,7 +12,8 @@ import (
 
 type simpleClientSocket struct {
 	*BaseSocket
-	tp transport.ClientTransporter
+	tp         transport.ClientTransporter
+	failOnLost bool
 }
 
 func (p *simpleClientSocket) Setup(ctx context.Context, connectTimeout time.Duration, setup *SetupInfo) (err error) {
@@ -22,6 +23,8 @@ func (p *simpleClientSocket) Setup(ctx context.Context, connectTimeout time.Dura
 	}
 	tp.Connection().SetCounter(p.socket.counter)
 	tp.SetLifetime(setup.KeepaliveLifetime)
+	p.setMimeTypes(setup)
+	p.socket.SetSetupInfo(setup)
 
 	p.socket.SetTransport(tp)
 
@@ -35,7 +38,8 @@ func (p *simpleClientSocket) Setup(ctx context.Context, connectTimeout time.Dura
 	}
 
 	tp.Handle(transport.OnErrorWithZeroStreamID, func(frame core.BufferedFrame) (err error) {
-		p.socket.SetError(frame.(*framing.ErrorFrame))
+		defer frame.Release()
+		p.socket.SetError(frame.(*framing.ErrorFrame).ToError())
 		return
 	})
 
@@ -43,6 +47,10 @@ func (p *simpleClientSocket) Setup(ctx context.Context, connectTimeout time.Dura
 		if err := tp.Start(ctx); err != nil {
 			logger.Warnf("client exit failed: %+v\n", err)
 		}
+		// Fail in-flight requests with a typed error if the transport dropped before closing socket.
+		if p.failOnLost && !p.socket.closed.Load() && p.socket.GetError() == nil {
+			p.socket.SetError(core.ErrConnectionLost)
+		}
 		_ = p.Close()
 	}(ctx, tp)
 
@@ -67,6 +75,16 @@ func (p *simpleClientSocket) createTransport(ctx context.Context, connectTimeout
 	return p.tp(tpCtx)
 }
 
+// NewReconnectableClient creates a simple client-side socket which will be replaced by a new one after the connection is lost.
+// In-flight requests will fail with core.ErrConnectionLost when the transport dropped.
+func NewReconnectableClient(tp transport.ClientTransporter, socket *DuplexConnection) ClientSocket {
+	return &simpleClientSocket{
+		BaseSocket: NewBaseSocket(socket),
+		tp:         tp,
+		failOnLost: true,
+	}
+}
+
 // NewClient create a simple client-side socket.
 func NewClient(tp transport.ClientTransporter, socket *DuplexConnection) ClientSocket {
 	return &simpleClientSocket{
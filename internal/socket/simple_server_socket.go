@@ -3,6 +3,7 @@ package socket
 import (
 	"context"
 
+	"github.com/rsocket/rsocket-go/core/framing"
 	"github.com/rsocket/rsocket-go/core/transport"
 )
 
@@ -14,6 +15,10 @@ func (p *simpleServerSocket) Pause() bool {
 	return false
 }
 
+func (p *simpleServerSocket) Resume(_ *framing.ResumeFrame, _ *transport.Transport) error {
+	return errResumeUnavailable
+}
+
 func (p *simpleServerSocket) SetResponder(responder Responder) {
 	p.socket.SetResponder(responder)
 }
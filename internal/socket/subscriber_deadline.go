@@ -0,0 +1,126 @@
+package socket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+)
+
+var errRequestTimeout = core.NewCustomError(core.ErrorCodeCanceled, []byte("rsocket: request deadline exceeded"))
+
+// deadlineSubscriber terminates the actual subscriber with errRequestTimeout once the deadline passes.
+// The signals of actual subscriber are serialized, and the signals after termination are dropped.
+type deadlineSubscriber struct {
+	actual     rx.Subscriber
+	onExpired  func()
+	mu         sync.Mutex
+	su         rx.Subscription
+	subscribed bool
+	expired    bool
+	terminated bool
+}
+
+// withDeadline returns a subscriber which will be terminated once the deadline of request context passes.
+// The onExpired will be called after the actual subscriber is terminated by the deadline.
+// The request context must be cancelled after the request is done.
+func withDeadline(ctx context.Context, actual rx.Subscriber, onExpired func()) rx.Subscriber {
+	if _, ok := ctx.Deadline(); !ok {
+		return actual
+	}
+	s := &deadlineSubscriber{
+		actual:    actual,
+		onExpired: onExpired,
+	}
+	go func() {
+		<-ctx.Done()
+		if ctx.Err() == context.DeadlineExceeded {
+			s.expire()
+		}
+	}()
+	return s
+}
+
+func (s *deadlineSubscriber) OnNext(next payload.Payload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.terminated {
+		return
+	}
+	s.actual.OnNext(next)
+}
+
+func (s *deadlineSubscriber) OnError(err error) {
+	if !s.terminate() {
+		return
+	}
+	s.actual.OnError(err)
+}
+
+func (s *deadlineSubscriber) OnComplete() {
+	if !s.terminate() {
+		return
+	}
+	s.actual.OnComplete()
+}
+
+func (s *deadlineSubscriber) OnSubscribe(ctx context.Context, su rx.Subscription) {
+	s.mu.Lock()
+	s.su = su
+	expired := s.expired
+	s.mu.Unlock()
+	if expired {
+		su.Cancel()
+	}
+
+	// The actual subscriber may request synchronously, so don't hold the lock here.
+	s.actual.OnSubscribe(ctx, su)
+
+	s.mu.Lock()
+	s.subscribed = true
+	expired = s.expired
+	s.mu.Unlock()
+	if expired {
+		s.fail()
+	}
+}
+
+func (s *deadlineSubscriber) expire() {
+	s.mu.Lock()
+	if s.terminated {
+		s.mu.Unlock()
+		return
+	}
+	s.terminated = true
+	s.expired = true
+	su, subscribed := s.su, s.subscribed
+	s.mu.Unlock()
+
+	if su != nil {
+		su.Cancel()
+	}
+	// The error will be sent after the actual subscriber is subscribed.
+	if subscribed {
+		s.fail()
+	}
+}
+
+func (s *deadlineSubscriber) fail() {
+	s.actual.OnError(errRequestTimeout)
+	if s.onExpired != nil {
+		s.onExpired()
+	}
+}
+
+// terminate marks current subscriber as terminated, it returns false if it has been terminated already.
+func (s *deadlineSubscriber) terminate() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.terminated {
+		return false
+	}
+	s.terminated = true
+	return true
+}
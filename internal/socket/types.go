@@ -5,6 +5,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/rsocket/rsocket-go/core/framing"
 	"github.com/rsocket/rsocket-go/core/transport"
 	"github.com/rsocket/rsocket-go/payload"
 	"github.com/rsocket/rsocket-go/rx/flux"
@@ -50,6 +51,9 @@ type ServerSocket interface {
 	SetTransport(tp *transport.Transport)
 	// Pause pause current socket.
 	Pause() bool
+	// Resume resumes current socket with a new transport.
+	// It returns error if the positions of RESUME frame cannot be satisfied.
+	Resume(frame *framing.ResumeFrame, tp *transport.Transport) error
 	// Start starts current socket.
 	Start(ctx context.Context) error
 	// Token returns token of socket.
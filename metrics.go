@@ -0,0 +1,179 @@
+package rsocket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/metrics"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+// connectionMetrics records the metrics of a connection.
+// On client side, the connection is bound to the first transport, so it keeps the same labels after resuming.
+type connectionMetrics struct {
+	m         *metrics.Metrics
+	composite bool
+	mu        sync.RWMutex
+	conn      *metrics.Connection
+}
+
+func newConnectionMetrics(m *metrics.Metrics, metadataMimeType string) *connectionMetrics {
+	if m == nil {
+		return nil
+	}
+	return &connectionMetrics{
+		m:         m,
+		composite: metadataMimeType == extension.MessageCompositeMetadata.String(),
+	}
+}
+
+// observe binds the connection with the given transport.
+func (c *connectionMetrics) observe(tp *transport.Transport) {
+	c.mu.Lock()
+	if c.conn == nil {
+		addr, _ := tp.Addr()
+		c.conn = c.m.Connection(addr)
+	}
+	conn := c.conn
+	c.mu.Unlock()
+	tp.Observe(conn)
+}
+
+// transporter returns a ClientTransporter whose transports are observed.
+func (c *connectionMetrics) transporter(gen transport.ClientTransporter) transport.ClientTransporter {
+	if c == nil {
+		return gen
+	}
+	return func(ctx context.Context) (*transport.Transport, error) {
+		tp, err := gen(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.observe(tp)
+		return tp, nil
+	}
+}
+
+func (c *connectionMetrics) connection() *metrics.Connection {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
+
+func (c *connectionMetrics) resumeAttempt() {
+	if conn := c.connection(); conn != nil {
+		conn.ResumeAttempt()
+	}
+}
+
+func (c *connectionMetrics) close(error) {
+	if conn := c.connection(); conn != nil {
+		conn.Close()
+	}
+}
+
+// intercept returns a copy of interceptors whose outermost interceptors record the metrics of interactions.
+func (c *connectionMetrics) intercept(interceptors *interceptorChains) *interceptorChains {
+	if c == nil {
+		return interceptors
+	}
+	return interceptors.prepend(c.measure(metrics.RoleRequester), c.measure(metrics.RoleResponder))
+}
+
+func (c *connectionMetrics) measure(role metrics.Role) Interceptor {
+	return func(rs RSocket) RSocket {
+		start := func(model core.FrameType, msg payload.Payload) *metrics.Stream {
+			conn := c.connection()
+			if conn == nil {
+				return nil
+			}
+			var route string
+			if c.composite {
+				route = routeOf(msg)
+			}
+			return conn.Request(role, model, route)
+		}
+		return NewAbstractSocket(
+			FireAndForget(func(msg payload.Payload) {
+				if s := start(core.FrameTypeRequestFNF, msg); s != nil {
+					s.End(nil)
+				}
+				rs.FireAndForget(msg)
+			}),
+			MetadataPush(func(msg payload.Payload) {
+				if s := start(core.FrameTypeMetadataPush, msg); s != nil {
+					s.End(nil)
+				}
+				rs.MetadataPush(msg)
+			}),
+			RequestResponse(func(msg payload.Payload) mono.Mono {
+				s := start(core.FrameTypeRequestResponse, msg)
+				return measureMono(s, rs.RequestResponse(msg))
+			}),
+			RequestStream(func(msg payload.Payload) flux.Flux {
+				s := start(core.FrameTypeRequestStream, msg)
+				return measureFlux(s, rs.RequestStream(msg))
+			}),
+			RequestChannel(func(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+				s := start(core.FrameTypeRequestChannel, initialMessage)
+				return measureFlux(s, rs.RequestChannel(initialMessage, messages))
+			}),
+		)
+	}
+}
+
+func measureMono(s *metrics.Stream, m mono.Mono) mono.Mono {
+	if s == nil {
+		return m
+	}
+	if m == nil {
+		s.End(nil)
+		return nil
+	}
+	return m.
+		DoOnError(func(e error) {
+			s.End(e)
+		}).
+		DoFinally(func(rx.SignalType) {
+			s.End(nil)
+		})
+}
+
+func measureFlux(s *metrics.Stream, f flux.Flux) flux.Flux {
+	if s == nil {
+		return f
+	}
+	if f == nil {
+		s.End(nil)
+		return nil
+	}
+	return f.
+		DoOnError(func(e error) {
+			s.End(e)
+		}).
+		DoFinally(func(rx.SignalType) {
+			s.End(nil)
+		})
+}
+
+// routeOf returns the first routing tag in CompositeMetadata.
+func routeOf(msg payload.Payload) (route string) {
+	metadata, ok := msg.Metadata()
+	if !ok || len(metadata) < 1 {
+		return
+	}
+	data, ok, err := extension.CompositeMetadata(metadata).Lookup(extension.MessageRouting.String())
+	if err != nil || !ok {
+		return
+	}
+	if tags, err := extension.ParseRoutingTags(data); err == nil && len(tags) > 0 {
+		route = tags[0]
+	}
+	return
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+var (
+	_ Registry = (*MemoryRegistry)(nil)
+	_ Remover  = (*MemoryRegistry)(nil)
+	_ Gatherer = (*MemoryRegistry)(nil)
+)
+
+// MemoryRegistry is a Registry which keeps all metrics in memory.
+type MemoryRegistry struct {
+	mu       sync.RWMutex
+	families map[string]*memoryFamily
+}
+
+type memoryFamily struct {
+	name    string
+	help    string
+	typ     Type
+	buckets []float64
+	metrics map[string]*memoryMetric // key=labels string
+}
+
+type memoryMetric struct {
+	mu      sync.Mutex
+	labels  Labels
+	value   float64
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// NewMemoryRegistry creates a new MemoryRegistry.
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		families: make(map[string]*memoryFamily),
+	}
+}
+
+// Counter returns a Counter, it panics if the name is registered as another type.
+func (r *MemoryRegistry) Counter(name, help string, labels Labels) Counter {
+	return (*memoryCounter)(r.metric(TypeCounter, name, help, nil, labels))
+}
+
+// Gauge returns a Gauge, it panics if the name is registered as another type.
+func (r *MemoryRegistry) Gauge(name, help string, labels Labels) Gauge {
+	return (*memoryGauge)(r.metric(TypeGauge, name, help, nil, labels))
+}
+
+// Histogram returns a Histogram, it panics if the name is registered as another type.
+// Nil buckets means DefaultBuckets, the buckets of first registration are used for the same name.
+func (r *MemoryRegistry) Histogram(name, help string, buckets []float64, labels Labels) Histogram {
+	if len(buckets) < 1 {
+		buckets = DefaultBuckets
+	}
+	return (*memoryHistogram)(r.metric(TypeHistogram, name, help, buckets, labels))
+}
+
+// Remove removes all metrics whose labels contain the given labels.
+func (r *MemoryRegistry) Remove(labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.families {
+		for k, m := range f.metrics {
+			if m.labels.contains(labels) {
+				delete(f.metrics, k)
+			}
+		}
+	}
+}
+
+// Gather returns the metric families sorted by name, metrics in a family are sorted by labels.
+func (r *MemoryRegistry) Gather() []Family {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	families := make([]Family, 0, len(r.families))
+	for _, f := range r.families {
+		if len(f.metrics) < 1 {
+			continue
+		}
+		keys := make([]string, 0, len(f.metrics))
+		for k := range f.metrics {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		family := Family{
+			Name:    f.name,
+			Help:    f.help,
+			Type:    f.typ,
+			Metrics: make([]Metric, 0, len(keys)),
+		}
+		for _, k := range keys {
+			family.Metrics = append(family.Metrics, f.metrics[k].snapshot())
+		}
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+	return families
+}
+
+func (r *MemoryRegistry) metric(typ Type, name, help string, buckets []float64, labels Labels) *memoryMetric {
+	key := labels.String()
+	r.mu.RLock()
+	f, ok := r.families[name]
+	if ok && f.typ == typ {
+		if m, ok := f.metrics[key]; ok {
+			r.mu.RUnlock()
+			return m
+		}
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok = r.families[name]
+	if !ok {
+		f = &memoryFamily{
+			name:    name,
+			help:    help,
+			typ:     typ,
+			buckets: buckets,
+			metrics: make(map[string]*memoryMetric),
+		}
+		r.families[name] = f
+	} else if f.typ != typ {
+		panic(fmt.Sprintf("metrics: %s is registered as %s already", name, f.typ))
+	}
+	m, ok := f.metrics[key]
+	if !ok {
+		m = &memoryMetric{
+			labels: copyLabels(labels),
+		}
+		if typ == TypeHistogram {
+			m.buckets = f.buckets
+			m.counts = make([]uint64, len(f.buckets))
+		}
+		f.metrics[key] = m
+	}
+	return m
+}
+
+func (m *memoryMetric) snapshot() Metric {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := Metric{
+		Labels: m.labels,
+		Value:  m.value,
+		Count:  m.count,
+		Sum:    m.sum,
+	}
+	if len(m.buckets) > 0 {
+		s.Buckets = make([]Bucket, len(m.buckets))
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += m.counts[i]
+			s.Buckets[i] = Bucket{UpperBound: upper, Count: cumulative}
+		}
+	}
+	return s
+}
+
+type memoryCounter memoryMetric
+
+func (c *memoryCounter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+type memoryGauge memoryMetric
+
+func (g *memoryGauge) Add(delta float64) {
+	g.mu.Lock()
+	g.value += delta
+	g.mu.Unlock()
+}
+
+func (g *memoryGauge) Set(value float64) {
+	g.mu.Lock()
+	g.value = value
+	g.mu.Unlock()
+}
+
+type memoryHistogram memoryMetric
+
+func (h *memoryHistogram) Observe(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.sum += value
+	// values greater than the last upper bound are only counted in +Inf.
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		h.counts[i]++
+	}
+}
+
+func copyLabels(labels Labels) Labels {
+	cloned := make(Labels, len(labels))
+	for k, v := range labels {
+		cloned[k] = v
+	}
+	return cloned
+}
@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/rsocket/rsocket-go/core"
+)
+
+// Names of metrics.
+const (
+	FramesSent       = "rsocket_frames_sent_total"
+	FramesReceived   = "rsocket_frames_received_total"
+	Requests         = "rsocket_requests_total"
+	ActiveStreams    = "rsocket_active_streams"
+	RequestDuration  = "rsocket_request_duration_seconds"
+	Errors           = "rsocket_errors_total"
+	KeepaliveRTT     = "rsocket_keepalive_rtt_seconds"
+	LeaseTicketsSent = "rsocket_lease_tickets_sent_total"
+	LeaseTicketsRcvd = "rsocket_lease_tickets_received_total"
+	ResumeAttempts   = "rsocket_resume_attempts_total"
+)
+
+// Names of labels.
+const (
+	LabelConnection = "connection"
+	LabelFrameType  = "type"
+	LabelRole       = "role"
+	LabelModel      = "model"
+	LabelRoute      = "route"
+	LabelCode       = "code"
+)
+
+// Role is the role of RSocket in an interaction.
+type Role string
+
+// All roles
+const (
+	RoleRequester Role = "requester"
+	RoleResponder Role = "responder"
+)
+
+// Option configures Metrics.
+type Option func(*Metrics)
+
+// WithBuckets sets the bucket upper bounds in seconds of request duration and keepalive RTT, default is DefaultBuckets.
+func WithBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		m.buckets = buckets
+	}
+}
+
+// WithRoutes sets the routes which are recorded in the route label, other routes are recorded as an empty route.
+// The route label is always empty by default, since routes with variables may have unbounded values.
+func WithRoutes(routes ...string) Option {
+	return func(m *Metrics) {
+		m.routes = make(map[string]struct{}, len(routes))
+		for _, route := range routes {
+			m.routes[route] = struct{}{}
+		}
+	}
+}
+
+// Metrics records the metrics of RSocket connections into a Registry.
+type Metrics struct {
+	registry Registry
+	buckets  []float64
+	routes   map[string]struct{}
+}
+
+// New creates a new Metrics, nil registry means a new MemoryRegistry.
+func New(registry Registry, opts ...Option) *Metrics {
+	if registry == nil {
+		registry = NewMemoryRegistry()
+	}
+	m := &Metrics{
+		registry: registry,
+		buckets:  DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Registry returns the Registry.
+func (m *Metrics) Registry() Registry {
+	return m.registry
+}
+
+func (m *Metrics) route(route string) string {
+	if _, ok := m.routes[route]; ok {
+		return route
+	}
+	return ""
+}
+
+// Connection returns the metrics of a connection, the name is used as the connection label.
+func (m *Metrics) Connection(name string) *Connection {
+	return &Connection{
+		m:         m,
+		name:      name,
+		keepalive: atomic.NewInt64(0),
+	}
+}
+
+// Connection records the metrics of a connection.
+// It can be used as the FrameObserver of transport.
+type Connection struct {
+	m         *Metrics
+	name      string
+	keepalive *atomic.Int64 // unix nanos of the last KEEPALIVE which requires respond
+}
+
+// Name returns the connection label.
+func (c *Connection) Name() string {
+	return c.name
+}
+
+// FrameSent records a frame sent.
+func (c *Connection) FrameSent(frame core.Frame) {
+	h := frame.Header()
+	c.m.registry.Counter(FramesSent, "Total number of frames sent.", c.labels(LabelFrameType, h.Type().String())).Add(1)
+	switch h.Type() {
+	case core.FrameTypeKeepalive:
+		if h.Flag().Check(core.FlagRespond) {
+			c.keepalive.Store(time.Now().UnixNano())
+		}
+	case core.FrameTypeLease:
+		if lease, ok := frame.(leaseFrame); ok {
+			c.m.registry.Counter(LeaseTicketsSent, "Total number of lease tickets sent.", c.labels()).Add(float64(lease.NumberOfRequests()))
+		}
+	}
+}
+
+// FrameReceived records a frame received.
+func (c *Connection) FrameReceived(frame core.Frame) {
+	h := frame.Header()
+	c.m.registry.Counter(FramesReceived, "Total number of frames received.", c.labels(LabelFrameType, h.Type().String())).Add(1)
+	switch h.Type() {
+	case core.FrameTypeKeepalive:
+		if h.Flag().Check(core.FlagRespond) {
+			return
+		}
+		if sent := c.keepalive.Swap(0); sent > 0 {
+			rtt := time.Duration(time.Now().UnixNano() - sent)
+			c.m.registry.Histogram(KeepaliveRTT, "Round-trip time of keepalive.", c.m.buckets, c.labels()).Observe(rtt.Seconds())
+		}
+	case core.FrameTypeLease:
+		if lease, ok := frame.(leaseFrame); ok {
+			c.m.registry.Counter(LeaseTicketsRcvd, "Total number of lease tickets received.", c.labels()).Add(float64(lease.NumberOfRequests()))
+		}
+	}
+}
+
+// Request records the start of a request, the model is the frame type of request.
+// The route is recorded only if it's one of WithRoutes.
+// The returned Stream should be ended when the interaction terminates.
+func (c *Connection) Request(role Role, model core.FrameType, route string) *Stream {
+	labels := c.labels(LabelRole, string(role), LabelModel, model.String(), LabelRoute, c.m.route(route))
+	c.m.registry.Counter(Requests, "Total number of requests.", labels).Add(1)
+	s := &Stream{
+		c:      c,
+		labels: labels,
+		start:  time.Now(),
+	}
+	switch model {
+	case core.FrameTypeRequestResponse, core.FrameTypeRequestStream, core.FrameTypeRequestChannel:
+		s.active = true
+		c.m.registry.Gauge(ActiveStreams, "Number of active streams.", labels).Add(1)
+	}
+	return s
+}
+
+// ResumeAttempt records an attempt of resuming.
+func (c *Connection) ResumeAttempt() {
+	c.m.registry.Counter(ResumeAttempts, "Total number of resume attempts.", c.labels()).Add(1)
+}
+
+// Close removes the metrics of connection if the Registry is a Remover.
+func (c *Connection) Close() {
+	if r, ok := c.m.registry.(Remover); ok {
+		r.Remove(c.labels())
+	}
+}
+
+func (c *Connection) labels(pairs ...string) Labels {
+	labels := Labels{LabelConnection: c.name}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels[pairs[i]] = pairs[i+1]
+	}
+	return labels
+}
+
+// Stream records the metrics of an interaction.
+type Stream struct {
+	c      *Connection
+	labels Labels
+	start  time.Time
+	active bool
+	once   sync.Once
+}
+
+// End records the end of interaction, nil error means the interaction completes successfully.
+// It's a noop after the first call.
+func (s *Stream) End(err error) {
+	s.once.Do(func() {
+		r := s.c.m.registry
+		if s.active {
+			r.Gauge(ActiveStreams, "Number of active streams.", s.labels).Add(-1)
+			r.Histogram(RequestDuration, "Duration of requests in seconds.", s.c.m.buckets, s.labels).Observe(time.Since(s.start).Seconds())
+		}
+		if err == nil {
+			return
+		}
+		code := core.ErrorCodeApplicationError
+		if ce, ok := err.(core.CustomError); ok {
+			code = ce.ErrorCode()
+		}
+		labels := copyLabels(s.labels)
+		labels[LabelCode] = code.String()
+		r.Counter(Errors, "Total number of errors by error code.", labels).Add(1)
+	})
+}
+
+type leaseFrame interface {
+	NumberOfRequests() uint32
+}
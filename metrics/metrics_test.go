@@ -0,0 +1,168 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/framing"
+	"github.com/rsocket/rsocket-go/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func find(t *testing.T, g metrics.Gatherer, name string, labels metrics.Labels) metrics.Metric {
+	for _, f := range g.Gather() {
+		if f.Name != name {
+			continue
+		}
+		for _, m := range f.Metrics {
+			if m.Labels.String() == labels.String() {
+				return m
+			}
+		}
+	}
+	require.Failf(t, "metric not found", "%s%s", name, labels)
+	return metrics.Metric{}
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	r := metrics.NewMemoryRegistry()
+	labels := metrics.Labels{"foo": "bar"}
+
+	r.Counter("c", "counter", labels).Add(1)
+	r.Counter("c", "counter", labels).Add(2)
+	r.Counter("c", "counter", metrics.Labels{"foo": "baz"}).Add(1)
+	assert.Equal(t, 3.0, find(t, r, "c", labels).Value)
+	assert.Panics(t, func() {
+		r.Counter("c", "counter", labels).Add(-1)
+	})
+
+	g := r.Gauge("g", "gauge", labels)
+	g.Add(3)
+	g.Add(-1)
+	assert.Equal(t, 2.0, find(t, r, "g", labels).Value)
+	g.Set(10)
+	assert.Equal(t, 10.0, find(t, r, "g", labels).Value)
+
+	h := r.Histogram("h", "histogram", []float64{1, 2}, labels)
+	h.Observe(0.5)
+	h.Observe(1.5)
+	h.Observe(3)
+	m := find(t, r, "h", labels)
+	assert.Equal(t, uint64(3), m.Count)
+	assert.Equal(t, 5.0, m.Sum)
+	assert.Equal(t, []metrics.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}}, m.Buckets)
+
+	assert.Panics(t, func() {
+		r.Gauge("c", "counter", labels)
+	})
+
+	families := r.Gather()
+	require.Len(t, families, 3)
+	assert.Equal(t, "c", families[0].Name)
+	assert.Equal(t, metrics.TypeCounter, families[0].Type)
+	assert.Len(t, families[0].Metrics, 2)
+	assert.Equal(t, "g", families[1].Name)
+	assert.Equal(t, "h", families[2].Name)
+
+	r.Remove(metrics.Labels{"foo": "bar"})
+	families = r.Gather()
+	require.Len(t, families, 1)
+	assert.Equal(t, "baz", families[0].Metrics[0].Labels["foo"])
+}
+
+func TestWriteText(t *testing.T) {
+	r := metrics.NewMemoryRegistry()
+	r.Counter("requests_total", "Total requests.\nWith \\ escaped.", metrics.Labels{"route": `a"b`, "code": "OK"}).Add(2)
+	r.Gauge("active", "", nil).Set(1.5)
+	r.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, metrics.Labels{"route": "r"}).Observe(0.5)
+
+	b := &bytes.Buffer{}
+	require.NoError(t, metrics.WriteText(b, r.Gather()))
+	assert.Equal(t, `# TYPE active gauge
+active 1.5
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1",route="r"} 0
+latency_seconds_bucket{le="1",route="r"} 1
+latency_seconds_bucket{le="+Inf",route="r"} 1
+latency_seconds_sum{route="r"} 0.5
+latency_seconds_count{route="r"} 1
+# HELP requests_total Total requests.\nWith \\ escaped.
+# TYPE requests_total counter
+requests_total{code="OK",route="a\"b"} 2
+`, b.String())
+
+	rec := httptest.NewRecorder()
+	metrics.Handler(r).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, metrics.ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, b.String(), rec.Body.String())
+}
+
+func TestConnection(t *testing.T) {
+	r := metrics.NewMemoryRegistry()
+	m := metrics.New(r, metrics.WithBuckets(1), metrics.WithRoutes("users.get"))
+	assert.Equal(t, r, m.Registry())
+	c := m.Connection("127.0.0.1:7878")
+	assert.Equal(t, "127.0.0.1:7878", c.Name())
+	conn := metrics.Labels{metrics.LabelConnection: c.Name()}
+
+	c.FrameSent(framing.NewWriteableKeepaliveFrame(0, nil, true))
+	time.Sleep(10 * time.Millisecond)
+	ka := framing.NewKeepaliveFrame(0, nil, false)
+	c.FrameReceived(ka)
+	ka.Release()
+	c.FrameSent(framing.NewWriteableLeaseFrame(time.Second, 10, nil))
+	lease := framing.NewLeaseFrame(time.Second, 5, nil)
+	c.FrameReceived(lease)
+	lease.Release()
+	c.ResumeAttempt()
+
+	assert.Equal(t, 1.0, find(t, r, metrics.FramesSent, metrics.Labels{metrics.LabelConnection: c.Name(), metrics.LabelFrameType: "KEEPALIVE"}).Value)
+	assert.Equal(t, 1.0, find(t, r, metrics.FramesReceived, metrics.Labels{metrics.LabelConnection: c.Name(), metrics.LabelFrameType: "LEASE"}).Value)
+	rtt := find(t, r, metrics.KeepaliveRTT, conn)
+	assert.Equal(t, uint64(1), rtt.Count)
+	assert.True(t, rtt.Sum >= 0.01, "rtt should be at least 10ms: %v", rtt.Sum)
+	assert.Equal(t, 10.0, find(t, r, metrics.LeaseTicketsSent, conn).Value)
+	assert.Equal(t, 5.0, find(t, r, metrics.LeaseTicketsRcvd, conn).Value)
+	assert.Equal(t, 1.0, find(t, r, metrics.ResumeAttempts, conn).Value)
+
+	rr := metrics.Labels{
+		metrics.LabelConnection: c.Name(),
+		metrics.LabelRole:       string(metrics.RoleResponder),
+		metrics.LabelModel:      "REQUEST_RESPONSE",
+		metrics.LabelRoute:      "users.get",
+	}
+	s := c.Request(metrics.RoleResponder, core.FrameTypeRequestResponse, "users.get")
+	assert.Equal(t, 1.0, find(t, r, metrics.ActiveStreams, rr).Value)
+	s.End(core.NewCustomError(core.ErrorCodeRejected, nil))
+	s.End(nil)
+	c.Request(metrics.RoleResponder, core.FrameTypeRequestResponse, "users.get").End(errors.New("oops"))
+	assert.Equal(t, 2.0, find(t, r, metrics.Requests, rr).Value)
+	assert.Equal(t, 0.0, find(t, r, metrics.ActiveStreams, rr).Value)
+	assert.Equal(t, uint64(2), find(t, r, metrics.RequestDuration, rr).Count)
+	for _, code := range []string{"REJECTED", "APPLICATION_ERROR"} {
+		labels := metrics.Labels{metrics.LabelCode: code}
+		for k, v := range rr {
+			labels[k] = v
+		}
+		assert.Equal(t, 1.0, find(t, r, metrics.Errors, labels).Value)
+	}
+
+	c.Request(metrics.RoleRequester, core.FrameTypeRequestFNF, "").End(nil)
+	// routes out of WithRoutes are recorded as an empty route.
+	c.Request(metrics.RoleRequester, core.FrameTypeRequestFNF, "users.42").End(nil)
+	b := &bytes.Buffer{}
+	require.NoError(t, metrics.WriteText(b, r.Gather()))
+	assert.Contains(t, b.String(), `rsocket_requests_total{connection="127.0.0.1:7878",model="REQUEST_FNF",role="requester",route=""} 2`)
+	assert.NotContains(t, b.String(), `rsocket_active_streams{connection="127.0.0.1:7878",model="REQUEST_FNF"`)
+
+	c.Close()
+	assert.Empty(t, r.Gather())
+}
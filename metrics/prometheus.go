@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// WriteText writes metric families in Prometheus text exposition format.
+func WriteText(w io.Writer, families []Family) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		if len(f.Help) > 0 {
+			bw.WriteString("# HELP ")
+			bw.WriteString(f.Name)
+			bw.WriteByte(' ')
+			bw.WriteString(helpEscaper.Replace(f.Help))
+			bw.WriteByte('\n')
+		}
+		bw.WriteString("# TYPE ")
+		bw.WriteString(f.Name)
+		bw.WriteByte(' ')
+		bw.WriteString(f.Type.String())
+		bw.WriteByte('\n')
+		for _, m := range f.Metrics {
+			if f.Type != TypeHistogram {
+				writeSample(bw, f.Name, m.Labels, m.Value)
+				continue
+			}
+			for _, b := range m.Buckets {
+				writeSample(bw, f.Name+"_bucket", withLabel(m.Labels, "le", formatFloat(b.UpperBound)), float64(b.Count))
+			}
+			writeSample(bw, f.Name+"_bucket", withLabel(m.Labels, "le", "+Inf"), float64(m.Count))
+			writeSample(bw, f.Name+"_sum", m.Labels, m.Sum)
+			writeSample(bw, f.Name+"_count", m.Labels, float64(m.Count))
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler returns a http.Handler which exports the gathered metrics in Prometheus text exposition format.
+func Handler(gatherer Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = WriteText(w, gatherer.Gather())
+	})
+}
+
+func writeSample(w *bufio.Writer, name string, labels Labels, value float64) {
+	w.WriteString(name)
+	w.WriteString(labels.String())
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func withLabel(labels Labels, name, value string) Labels {
+	l := copyLabels(labels)
+	l[name] = value
+	return l
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultBuckets is the default upper bounds of histogram buckets, in seconds.
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Type is the type of metric.
+type Type int8
+
+// All metric types
+const (
+	TypeCounter Type = iota
+	TypeGauge
+	TypeHistogram
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeCounter:
+		return "counter"
+	case TypeGauge:
+		return "gauge"
+	case TypeHistogram:
+		return "histogram"
+	default:
+		return "untyped"
+	}
+}
+
+// Labels is the label pairs of a metric.
+type Labels map[string]string
+
+// Names returns the sorted label names.
+func (l Labels) Names() []string {
+	names := make([]string, 0, len(l))
+	for k := range l {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns labels in the form of `{k1="v1",k2="v2"}`, sorted by names.
+func (l Labels) String() string {
+	if len(l) < 1 {
+		return ""
+	}
+	sb := strings.Builder{}
+	sb.WriteByte('{')
+	for i, name := range l.Names() {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(l[name]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func (l Labels) contains(other Labels) bool {
+	for k, v := range other {
+		if actual, ok := l[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Counter is a metric which only goes up.
+type Counter interface {
+	// Add adds the given non-negative value.
+	Add(delta float64)
+}
+
+// Gauge is a metric which can go up and down.
+type Gauge interface {
+	// Add adds the given value, it can be negative.
+	Add(delta float64)
+	// Set sets the value.
+	Set(value float64)
+}
+
+// Histogram samples observations into buckets.
+type Histogram interface {
+	// Observe adds a single observation.
+	Observe(value float64)
+}
+
+// Registry creates metrics by name and labels, the same metric is returned for the same name and labels.
+// The implementation should be safe for concurrent use.
+type Registry interface {
+	// Counter returns a Counter.
+	Counter(name, help string, labels Labels) Counter
+	// Gauge returns a Gauge.
+	Gauge(name, help string, labels Labels) Gauge
+	// Histogram returns a Histogram with given bucket upper bounds.
+	Histogram(name, help string, buckets []float64, labels Labels) Histogram
+}
+
+// Remover is an optional interface of Registry, which can remove the metrics of closed connections.
+type Remover interface {
+	// Remove removes all metrics whose labels contain the given labels.
+	Remove(labels Labels)
+}
+
+// Gatherer gathers the snapshots of metrics.
+type Gatherer interface {
+	// Gather returns the metric families sorted by name.
+	Gather() []Family
+}
+
+// Family is a snapshot of metrics with the same name.
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Metrics []Metric
+}
+
+// Metric is a snapshot of single metric.
+// Value is used by counter and gauge, Buckets, Count and Sum are used by histogram.
+type Metric struct {
+	Labels  Labels
+	Value   float64
+	Buckets []Bucket
+	Count   uint64
+	Sum     float64
+}
+
+// Bucket is a cumulative histogram bucket.
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
@@ -0,0 +1,137 @@
+package rsocket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/metrics"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findMetric(registry *metrics.MemoryRegistry, name string, labels metrics.Labels) (metrics.Metric, bool) {
+	for _, f := range registry.Gather() {
+		if f.Name != name {
+			continue
+		}
+		for _, m := range f.Metrics {
+			matched := true
+			for k, v := range labels {
+				if m.Labels[k] != v {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return m, true
+			}
+		}
+	}
+	return metrics.Metric{}, false
+}
+
+func TestMetrics(t *testing.T) {
+	const port = 9820
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverRegistry := metrics.NewMemoryRegistry()
+	started := make(chan struct{})
+	go func() {
+		err := Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Metrics(metrics.New(serverRegistry, metrics.WithRoutes("echo"))).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponse(func(request payload.Payload) mono.Mono {
+						if request.DataUTF8() == "fail" {
+							return mono.Error(core.NewCustomError(core.ErrorCodeRejected, []byte("rejected")))
+						}
+						return mono.Just(payload.Clone(request))
+					}),
+					RequestStream(func(request payload.Payload) flux.Flux {
+						return flux.Just(payload.Clone(request), payload.Clone(request))
+					}),
+				), nil
+			}).
+			Transport(TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+			Serve(ctx)
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	clientRegistry := metrics.NewMemoryRegistry()
+	cli, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Metrics(metrics.New(clientRegistry, metrics.WithRoutes("echo"))).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+
+	routing, err := extension.EncodeRouting("echo")
+	require.NoError(t, err)
+	metadata, err := extension.NewCompositeMetadataBuilder().PushWellKnown(extension.MessageRouting, routing).Build()
+	require.NoError(t, err)
+
+	_, err = cli.RequestResponse(payload.New([]byte("hello"), metadata)).Block(ctx)
+	require.NoError(t, err)
+	_, err = cli.RequestResponse(payload.New([]byte("fail"), metadata)).Block(ctx)
+	require.Error(t, err)
+	results, err := cli.RequestStream(payload.New([]byte("hello"), metadata)).BlockSlice(ctx)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	conn := metrics.Labels{metrics.LabelConnection: "127.0.0.1:9820"}
+
+	m, ok := findMetric(clientRegistry, metrics.FramesSent, metrics.Labels{metrics.LabelConnection: "127.0.0.1:9820", metrics.LabelFrameType: "SETUP"})
+	require.True(t, ok)
+	assert.Equal(t, 1.0, m.Value)
+	m, ok = findMetric(clientRegistry, metrics.FramesSent, metrics.Labels{metrics.LabelFrameType: "REQUEST_RESPONSE"})
+	require.True(t, ok)
+	assert.Equal(t, 2.0, m.Value)
+	m, ok = findMetric(clientRegistry, metrics.Requests, metrics.Labels{metrics.LabelRole: "requester", metrics.LabelModel: "REQUEST_RESPONSE", metrics.LabelRoute: "echo"})
+	require.True(t, ok)
+	assert.Equal(t, 2.0, m.Value)
+	m, ok = findMetric(clientRegistry, metrics.RequestDuration, metrics.Labels{metrics.LabelModel: "REQUEST_STREAM", metrics.LabelRoute: "echo"})
+	require.True(t, ok)
+	assert.Equal(t, uint64(1), m.Count)
+	m, ok = findMetric(clientRegistry, metrics.Errors, metrics.Labels{metrics.LabelRole: "requester", metrics.LabelCode: "REJECTED"})
+	require.True(t, ok)
+	assert.Equal(t, 1.0, m.Value)
+
+	m, ok = findMetric(serverRegistry, metrics.FramesReceived, metrics.Labels{metrics.LabelFrameType: "SETUP"})
+	require.True(t, ok)
+	assert.Equal(t, 1.0, m.Value)
+	assert.NotEqual(t, "", m.Labels[metrics.LabelConnection])
+	m, ok = findMetric(serverRegistry, metrics.Errors, metrics.Labels{metrics.LabelRole: "responder", metrics.LabelRoute: "echo", metrics.LabelCode: "REJECTED"})
+	require.True(t, ok)
+	assert.Equal(t, 1.0, m.Value)
+
+	// active streams go back to zero after the stream is done.
+	time.Sleep(100 * time.Millisecond)
+	for _, registry := range []*metrics.MemoryRegistry{clientRegistry, serverRegistry} {
+		for _, f := range registry.Gather() {
+			if f.Name != metrics.ActiveStreams {
+				continue
+			}
+			for _, m := range f.Metrics {
+				assert.Zero(t, m.Value, "active streams: %s", m.Labels)
+			}
+		}
+	}
+
+	_ = cli.Close()
+	time.Sleep(100 * time.Millisecond)
+	_, ok = findMetric(clientRegistry, metrics.FramesSent, conn)
+	assert.False(t, ok, "metrics of closed connection should be removed")
+	assert.Empty(t, serverRegistry.Gather())
+}
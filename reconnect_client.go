@@ -0,0 +1,356 @@
+package rsocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/deferred"
+	"github.com/rsocket/rsocket-go/internal/socket"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+var (
+	_ Client           = (*reconnectClient)(nil)
+	_ addressedRSocket = (*reconnectClient)(nil)
+	_ LeaseAware       = (*reconnectClient)(nil)
+	_ MimeTypeAware    = (*reconnectClient)(nil)
+)
+
+var errReconnectExhausted = errors.New("rsocket: reconnect attempts exhausted")
+
+// ReconnectPolicy controls how a non-resumable client reconnects after the connection is lost.
+type ReconnectPolicy struct {
+	// Backoff computes the delay before each reconnect attempt.
+	// Default is an exponential backoff starting from 1s to 30s with jitter.
+	Backoff Backoff
+	// MaxAttempts is the max number of reconnect attempts after the connection is lost.
+	// Client will be closed once all attempts failed. Zero means no limit.
+	MaxAttempts int
+	// WaitTimeout is the max duration that new requests wait for reconnecting.
+	// Zero means new requests fail fast with core.ErrConnectionLost while reconnecting.
+	WaitTimeout time.Duration
+}
+
+// reconnectClient is a client which re-runs SETUP on a new transport after the connection is lost.
+type reconnectClient struct {
+	cb         *clientBuilder
+	policy     ReconnectPolicy
+	onConnects []func(Client, error)
+	locker     sync.Mutex
+	cur        setupClientSocket
+	ready      chan struct{}
+	done       chan struct{}
+	once       sync.Once
+	closers    []func(error)
+	// self is the client exposed to users, which may be intercepted.
+	self         Client
+	tpGen        transport.ClientTransporter
+	interceptors *interceptorChains
+}
+
+func newReconnectClient(cb *clientBuilder, policy ReconnectPolicy, cm *connectionMetrics) *reconnectClient {
+	if policy.Backoff == nil {
+		policy.Backoff = DefaultBackoff()
+	}
+	rc := &reconnectClient{
+		cb:           cb,
+		policy:       policy,
+		onConnects:   cb.onConnects,
+		ready:        make(chan struct{}),
+		done:         make(chan struct{}),
+		tpGen:        cm.transporter(cb.tpGen),
+		interceptors: cm.intercept(cb.interceptors),
+	}
+	rc.self = rc
+	return rc
+}
+
+func (rc *reconnectClient) start(ctx context.Context) error {
+	cs, lost, err := rc.dial(ctx)
+	if err != nil {
+		return err
+	}
+	rc.bind(ctx, cs, lost)
+	return nil
+}
+
+// dial creates a new socket, the returned channel receives the error after the socket is closed.
+func (rc *reconnectClient) dial(ctx context.Context) (setupClientSocket, <-chan error, error) {
+	cb := rc.cb
+	conn := cb.newConnection(ctx)
+	cs := socket.NewReconnectableClient(rc.tpGen, conn)
+	conn.SetResponder(cb.responder(ctx, rc.interceptors, rc.self))
+	// Register closer before setup, the connection may be lost at any time.
+	lost := make(chan error, 1)
+	cs.OnClose(func(err error) {
+		lost <- err
+	})
+	if err := cs.Setup(ctx, cb.connectTimeout, cb.setup); err != nil {
+		return nil, nil, err
+	}
+	return cs, lost, nil
+}
+
+// bind makes the socket as current one, and starts reconnecting once it's closed.
+func (rc *reconnectClient) bind(ctx context.Context, cs setupClientSocket, lost <-chan error) {
+	rc.locker.Lock()
+	if rc.isClosed() {
+		rc.locker.Unlock()
+		_ = cs.Close()
+		return
+	}
+	rc.cur = cs
+	close(rc.ready)
+	rc.locker.Unlock()
+
+	if len(rc.onConnects) > 0 {
+		go func() {
+			for _, onConnect := range rc.onConnects {
+				onConnect(rc.self, nil)
+			}
+		}()
+	}
+
+	go func() {
+		err := <-lost
+		rc.locker.Lock()
+		if rc.cur == cs {
+			rc.cur = nil
+			rc.ready = make(chan struct{})
+		}
+		rc.locker.Unlock()
+		if rc.isClosed() {
+			return
+		}
+		logger.Warnf("connection lost, start reconnecting: %v\n", err)
+		rc.reconnect(ctx)
+	}()
+}
+
+func (rc *reconnectClient) reconnect(ctx context.Context) {
+	for attempt := 1; ; attempt++ {
+		if rc.policy.MaxAttempts > 0 && attempt > rc.policy.MaxAttempts {
+			_ = rc.close(errReconnectExhausted)
+			return
+		}
+		timer := time.NewTimer(rc.policy.Backoff.Next(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			_ = rc.close(ctx.Err())
+			return
+		case <-rc.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		cs, lost, err := rc.dial(ctx)
+		if err != nil {
+			logger.Warnf("reconnect failed: %v\n", err)
+			continue
+		}
+		if logger.IsDebugEnabled() {
+			logger.Debugf("reconnect success after %d attempt(s)\n", attempt)
+		}
+		rc.bind(ctx, cs, lost)
+		return
+	}
+}
+
+// current returns the current socket, it waits for reconnecting if WaitTimeout is set.
+// Requests call it when they are subscribed, so building a request never blocks, see send for the requests without stream.
+func (rc *reconnectClient) current(ctx context.Context) (setupClientSocket, error) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		rc.locker.Lock()
+		cur, ready := rc.cur, rc.ready
+		rc.locker.Unlock()
+		if rc.isClosed() {
+			return nil, core.ErrClientClosed
+		}
+		if cur != nil {
+			return cur, nil
+		}
+		if rc.policy.WaitTimeout <= 0 {
+			return nil, core.ErrConnectionLost
+		}
+		if timer == nil {
+			timer = time.NewTimer(rc.policy.WaitTimeout)
+		}
+		select {
+		case <-ready:
+		case <-rc.done:
+			return nil, core.ErrClientClosed
+		case <-timer.C:
+			return nil, core.ErrConnectionLost
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (rc *reconnectClient) isClosed() bool {
+	select {
+	case <-rc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (rc *reconnectClient) FireAndForget(message payload.Payload) {
+	rc.send("FireAndForget", message, func(cur setupClientSocket) {
+		cur.FireAndForget(message)
+	})
+}
+
+func (rc *reconnectClient) MetadataPush(message payload.Payload) {
+	rc.send("MetadataPush", message, func(cur setupClientSocket) {
+		cur.MetadataPush(message)
+	})
+}
+
+// send sends a request without stream on the current socket.
+// While reconnecting, the request waits for the new socket in background if WaitTimeout is set,
+// otherwise it's dropped with core.ErrConnectionLost, so the caller is never blocked.
+func (rc *reconnectClient) send(request string, message payload.Payload, fn func(setupClientSocket)) {
+	rc.locker.Lock()
+	cur := rc.cur
+	rc.locker.Unlock()
+	if rc.isClosed() {
+		logger.Warnf("request %s failed: %v\n", request, core.ErrClientClosed)
+		return
+	}
+	if cur != nil {
+		fn(cur)
+		return
+	}
+	if rc.policy.WaitTimeout <= 0 {
+		logger.Warnf("request %s failed: %v\n", request, core.ErrConnectionLost)
+		return
+	}
+	// the message may be released by the caller after returning, so keep a reference until it's sent.
+	releasable, isReleasable := message.(common.Releasable)
+	if isReleasable {
+		releasable.IncRef()
+	}
+	go func() {
+		if isReleasable {
+			defer releasable.Release()
+		}
+		cur, err := rc.current(context.Background())
+		if err != nil {
+			logger.Warnf("request %s failed: %v\n", request, err)
+			return
+		}
+		fn(cur)
+	}()
+}
+
+func (rc *reconnectClient) RequestResponse(message payload.Payload) mono.Mono {
+	return deferred.Mono(func(ctx context.Context) mono.Mono {
+		cur, err := rc.current(ctx)
+		if err != nil {
+			return mono.Error(err)
+		}
+		return cur.RequestResponse(message)
+	})
+}
+
+func (rc *reconnectClient) RequestStream(message payload.Payload) flux.Flux {
+	return deferred.Flux(func(ctx context.Context) flux.Flux {
+		cur, err := rc.current(ctx)
+		if err != nil {
+			return flux.Error(err)
+		}
+		return cur.RequestStream(message)
+	})
+}
+
+func (rc *reconnectClient) RequestChannel(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+	return deferred.Flux(func(ctx context.Context) flux.Flux {
+		cur, err := rc.current(ctx)
+		if err != nil {
+			return flux.Error(err)
+		}
+		return cur.RequestChannel(initialMessage, messages)
+	})
+}
+
+func (rc *reconnectClient) Addr() (string, bool) {
+	rc.locker.Lock()
+	cur := rc.cur
+	rc.locker.Unlock()
+	if cur == nil {
+		return "", false
+	}
+	if addressed, ok := cur.(addressedRSocket); ok {
+		return addressed.Addr()
+	}
+	return "", false
+}
+
+func (rc *reconnectClient) RemainingLease() (tickets int64, expiry time.Time, enabled bool) {
+	rc.locker.Lock()
+	cur := rc.cur
+	rc.locker.Unlock()
+	if leased, is := cur.(LeaseAware); is {
+		return leased.RemainingLease()
+	}
+	return
+}
+
+func (rc *reconnectClient) DataMimeType() string {
+	return string(rc.cb.setup.DataMimeType)
+}
+
+func (rc *reconnectClient) MetadataMimeType() string {
+	return string(rc.cb.setup.MetadataMimeType)
+}
+
+func (rc *reconnectClient) OnClose(fn func(error)) {
+	if fn == nil {
+		return
+	}
+	rc.locker.Lock()
+	rc.closers = append(rc.closers, fn)
+	rc.locker.Unlock()
+}
+
+func (rc *reconnectClient) Close() error {
+	return rc.close(nil)
+}
+
+func (rc *reconnectClient) close(cause error) (err error) {
+	rc.once.Do(func() {
+		rc.locker.Lock()
+		close(rc.done)
+		cur := rc.cur
+		rc.cur = nil
+		closers := rc.closers
+		rc.locker.Unlock()
+		err = cause
+		if cur != nil {
+			if e := cur.Close(); err == nil {
+				err = e
+			}
+		}
+		for i := len(closers); i > 0; i-- {
+			closers[i-1](err)
+		}
+	})
+	return
+}
@@ -0,0 +1,172 @@
+package rsocket_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func startReconnectServer(ctx context.Context, t *testing.T, port int, received chan<- string) {
+	started := make(chan struct{})
+	go func() {
+		err := Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					FireAndForget(func(request payload.Payload) {
+						if received != nil {
+							received <- string(request.Data())
+						}
+					}),
+					RequestResponse(func(request payload.Payload) mono.Mono {
+						return mono.Just(payload.Clone(request))
+					}),
+				), nil
+			}).
+			Transport(TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+			Serve(ctx)
+		assert.NoError(t, err)
+	}()
+	<-started
+}
+
+func TestReconnect(t *testing.T) {
+	const port = 9800
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a server which accepts the first connection and never responds
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	connects := atomic.NewInt32(0)
+	closed := make(chan error, 1)
+	cli, err := Connect().
+		Reconnect(ReconnectPolicy{
+			Backoff:     NewConstantBackoff(100 * time.Millisecond),
+			WaitTimeout: 5 * time.Second,
+		}).
+		OnConnect(func(client Client, err error) {
+			assert.NoError(t, err)
+			connects.Inc()
+		}).
+		OnClose(func(err error) {
+			closed <- err
+		}).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+
+	streamErr := make(chan error, 1)
+	cli.RequestStream(payload.NewString("stream", "")).
+		DoOnError(func(e error) {
+			streamErr <- e
+		}).
+		Subscribe(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+	_ = l.Close()
+	_ = (<-accepted).Close()
+
+	select {
+	case err := <-streamErr:
+		assert.Equal(t, core.ErrConnectionLost, err)
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "in-flight stream should fail")
+	}
+
+	// building a request doesn't wait, the socket is bound when it's subscribed.
+	begin := time.Now()
+	request := cli.RequestResponse(payload.NewString("world", ""))
+	assert.Less(t, time.Since(begin), 100*time.Millisecond)
+
+	time.Sleep(300 * time.Millisecond)
+
+	// fire-and-forget doesn't wait, the message is sent after reconnecting.
+	begin = time.Now()
+	cli.FireAndForget(payload.NewString("fnf", ""))
+	assert.Less(t, time.Since(begin), 100*time.Millisecond)
+
+	received := make(chan string, 1)
+	startReconnectServer(ctx, t, port, received)
+
+	// request waits for reconnecting
+	res, err := request.Block(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", res.DataUTF8())
+	assert.Eventually(t, func() bool {
+		return connects.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case data := <-received:
+		assert.Equal(t, "fnf", data)
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "fire-and-forget should be sent after reconnecting")
+	}
+
+	assert.NoError(t, cli.Close())
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		assert.Fail(t, "client should be closed")
+	}
+	_, err = cli.RequestResponse(payload.NewString("closed", "")).Block(ctx)
+	assert.Equal(t, core.ErrClientClosed, err)
+}
+
+func TestReconnect_FailFast(t *testing.T) {
+	const port = 9801
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverCtx, stopServer := context.WithCancel(ctx)
+	startReconnectServer(serverCtx, t, port, nil)
+
+	closed := make(chan error, 1)
+	cli, err := Connect().
+		Reconnect(ReconnectPolicy{
+			Backoff:     NewConstantBackoff(50 * time.Millisecond),
+			MaxAttempts: 2,
+		}).
+		OnClose(func(err error) {
+			closed <- err
+		}).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", port).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+
+	_, err = cli.RequestResponse(payload.NewString("hello", "")).Block(ctx)
+	assert.NoError(t, err)
+
+	stopServer()
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = cli.RequestResponse(payload.NewString("hello", "")).Block(ctx)
+	assert.Equal(t, core.ErrConnectionLost, err)
+
+	select {
+	case err := <-closed:
+		assert.Error(t, err, "should be closed after all attempts failed")
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "client should be closed")
+	}
+}
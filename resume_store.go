@@ -0,0 +1,276 @@
+package rsocket
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/internal/session"
+	"github.com/rsocket/rsocket-go/internal/socket"
+)
+
+var (
+	_ ResumeStore = (*memoryResumeStore)(nil)
+	_ ResumeStore = (*fileResumeStore)(nil)
+)
+
+var errUnavailableSession = errors.New("rsocket: session is unavailable")
+
+type (
+	// ResumeSession represents a paused connection of a resumable RSocket server.
+	ResumeSession interface {
+		io.Closer
+		// Token returns the resume token of session.
+		Token() []byte
+		// Deadline returns the time after which the session expires.
+		Deadline() time.Time
+	}
+
+	// ResumeStore stores paused sessions of a resumable RSocket server.
+	// You can implement your own store to customize the eviction and capacity policies.
+	ResumeStore interface {
+		// Save saves a paused session.
+		// The session will be closed by server if an error is returned.
+		Save(session ResumeSession) error
+		// Load returns the session with given token.
+		Load(token []byte) (session ResumeSession, ok bool)
+		// Evict removes the session with given token and returns it.
+		// Server evicts a session when it's resumed, expired or going to be destroyed.
+		Evict(token []byte) (session ResumeSession, ok bool)
+		// List returns all sessions in current store.
+		List() []ResumeSession
+		// Len returns the number of sessions in current store, it's checked by the max sessions limit.
+		Len() int
+	}
+)
+
+type serverSocketHolder interface {
+	Socket() socket.ServerSocket
+}
+
+type memoryResumeStore struct {
+	sm *session.Manager
+}
+
+// NewMemoryResumeStore creates a ResumeStore which keeps sessions in memory.
+// It's the default store of a resumable RSocket server.
+func NewMemoryResumeStore() ResumeStore {
+	return &memoryResumeStore{
+		sm: session.NewManager(),
+	}
+}
+
+func (m *memoryResumeStore) Save(session ResumeSession) error {
+	m.sm.Push(session)
+	return nil
+}
+
+func (m *memoryResumeStore) Load(token []byte) (ResumeSession, bool) {
+	return toResumeSession(m.sm.Load(token))
+}
+
+func (m *memoryResumeStore) Evict(token []byte) (ResumeSession, bool) {
+	return toResumeSession(m.sm.Remove(token))
+}
+
+func (m *memoryResumeStore) List() []ResumeSession {
+	return toResumeSessions(m.sm.List())
+}
+
+func (m *memoryResumeStore) Len() int {
+	return m.sm.Len()
+}
+
+func toResumeSessions(entries []session.Entry) []ResumeSession {
+	sessions := make([]ResumeSession, len(entries))
+	for i := range entries {
+		sessions[i] = entries[i].(ResumeSession)
+	}
+	return sessions
+}
+
+func toResumeSession(entry session.Entry, ok bool) (ResumeSession, bool) {
+	if !ok {
+		return nil, false
+	}
+	return entry.(ResumeSession), true
+}
+
+type sessionRecord struct {
+	Token    string    `json:"token"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// storedSession is a session restored from file.
+// The connection state cannot be persisted, so resuming it is rejected as unavailable.
+// It reserves its token and it's counted by the max sessions limit until it's evicted or expired.
+type storedSession struct {
+	token    []byte
+	deadline time.Time
+}
+
+func (s storedSession) Close() error {
+	return nil
+}
+
+func (s storedSession) Token() []byte {
+	return s.token
+}
+
+func (s storedSession) Deadline() time.Time {
+	return s.deadline
+}
+
+type fileResumeStore struct {
+	mu       sync.Mutex
+	path     string
+	sm       *session.Manager
+	restored map[string]storedSession
+}
+
+// NewFileResumeStore creates a ResumeStore which persists the tokens and deadlines of sessions into a file.
+// Paused connections are still kept in memory. After a restart, the unexpired sessions restored from the file
+// keep their tokens reserved and are counted by the max sessions limit, so the capacity survives restarts.
+// Resuming a restored session is rejected because its connection state is lost, and it's evicted once it expires.
+func NewFileResumeStore(path string) (ResumeStore, error) {
+	store := &fileResumeStore{
+		path:     path,
+		sm:       session.NewManager(),
+		restored: make(map[string]storedSession),
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read resume store failed")
+	}
+	if len(b) < 1 {
+		return store, nil
+	}
+	var records []sessionRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, errors.Wrap(err, "decode resume store failed")
+	}
+	now := time.Now()
+	for _, it := range records {
+		if !it.Deadline.After(now) {
+			continue
+		}
+		token, err := hex.DecodeString(it.Token)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode resume token failed")
+		}
+		store.restored[string(token)] = storedSession{
+			token:    token,
+			deadline: it.Deadline,
+		}
+	}
+	return store, nil
+}
+
+func (f *fileResumeStore) Save(session ResumeSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := string(session.Token())
+	restored, hasRestored := f.restored[key]
+	delete(f.restored, key)
+	f.sm.Push(session)
+	if err := f.flush(); err != nil {
+		f.sm.Remove(session.Token())
+		if hasRestored {
+			f.restored[key] = restored
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *fileResumeStore) Load(token []byte) (ResumeSession, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := toResumeSession(f.sm.Load(token)); ok {
+		return s, true
+	}
+	restored, ok := f.restored[string(token)]
+	return restored, ok
+}
+
+func (f *fileResumeStore) Evict(token []byte) (ResumeSession, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := toResumeSession(f.sm.Remove(token))
+	if !ok {
+		if restored, exist := f.restored[string(token)]; exist {
+			delete(f.restored, string(token))
+			s, ok = restored, true
+		}
+	}
+	if ok {
+		_ = f.flush()
+	}
+	return s, ok
+}
+
+func (f *fileResumeStore) List() []ResumeSession {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.list()
+}
+
+func (f *fileResumeStore) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sm.Len() + len(f.restored)
+}
+
+// list returns the sessions in memory and the restored ones, ordered by deadline.
+func (f *fileResumeStore) list() []ResumeSession {
+	sessions := toResumeSessions(f.sm.List())
+	if len(f.restored) < 1 {
+		return sessions
+	}
+	for _, it := range f.restored {
+		sessions = append(sessions, it)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Deadline().Before(sessions[j].Deadline())
+	})
+	return sessions
+}
+
+func (f *fileResumeStore) flush() error {
+	entries := f.list()
+	records := make([]sessionRecord, len(entries))
+	for i := range entries {
+		records[i] = sessionRecord{
+			Token:    hex.EncodeToString(entries[i].Token()),
+			Deadline: entries[i].Deadline(),
+		}
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "encode resume store failed")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "write resume store failed")
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "write resume store failed")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "write resume store failed")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), f.path), "write resume store failed")
+}
@@ -0,0 +1,177 @@
+package rsocket_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSession struct {
+	token    []byte
+	deadline time.Time
+	closed   bool
+}
+
+func (f *fakeSession) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeSession) Token() []byte {
+	return f.token
+}
+
+func (f *fakeSession) Deadline() time.Time {
+	return f.deadline
+}
+
+func testResumeStore(t *testing.T, store ResumeStore) {
+	now := time.Now()
+	first := &fakeSession{token: []byte("first"), deadline: now.Add(2 * time.Second)}
+	second := &fakeSession{token: []byte("second"), deadline: now.Add(1 * time.Second)}
+
+	assert.NoError(t, store.Save(first))
+	assert.NoError(t, store.Save(second))
+
+	s, ok := store.Load(first.token)
+	assert.True(t, ok)
+	assert.Equal(t, first, s)
+
+	list := store.List()
+	require.Len(t, list, 2)
+	// ordered by deadline
+	assert.Equal(t, second.token, list[0].Token())
+	assert.Equal(t, first.token, list[1].Token())
+
+	s, ok = store.Evict(second.token)
+	assert.True(t, ok)
+	assert.Equal(t, second, s)
+	assert.False(t, second.closed, "evict should not close session")
+
+	_, ok = store.Load(second.token)
+	assert.False(t, ok)
+	_, ok = store.Evict(second.token)
+	assert.False(t, ok)
+	assert.Len(t, store.List(), 1)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestMemoryResumeStore(t *testing.T) {
+	testResumeStore(t, NewMemoryResumeStore())
+}
+
+func TestFileResumeStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	store, err := NewFileResumeStore(path)
+	require.NoError(t, err)
+	testResumeStore(t, store)
+
+	// restore sessions from file
+	restored, err := NewFileResumeStore(path)
+	require.NoError(t, err)
+	list := restored.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, []byte("first"), list[0].Token())
+	// restored sessions reserve their tokens and are counted until they're evicted.
+	assert.Equal(t, 1, restored.Len())
+	s, ok := restored.Load([]byte("first"))
+	require.True(t, ok)
+	assert.Equal(t, []byte("first"), s.Token())
+
+	s, ok = restored.Evict([]byte("first"))
+	assert.True(t, ok)
+	assert.NoError(t, s.Close())
+	assert.Equal(t, 0, restored.Len())
+	_, ok = restored.Load([]byte("first"))
+	assert.False(t, ok)
+	_, ok = restored.Evict([]byte("first"))
+	assert.False(t, ok)
+
+	// an evicted token can be reused.
+	reused := &fakeSession{token: []byte("first"), deadline: time.Now().Add(time.Second)}
+	assert.NoError(t, restored.Save(reused))
+	assert.Equal(t, 1, restored.Len())
+	require.Len(t, restored.List(), 1)
+	s, ok = restored.Load([]byte("first"))
+	assert.True(t, ok)
+	assert.Equal(t, reused, s)
+	_, ok = restored.Evict([]byte("first"))
+	assert.True(t, ok)
+
+	restored, err = NewFileResumeStore(path)
+	require.NoError(t, err)
+	assert.Empty(t, restored.List())
+
+	// expired sessions are not restored.
+	expired := &fakeSession{token: []byte("expired"), deadline: time.Now().Add(10 * time.Millisecond)}
+	assert.NoError(t, restored.Save(expired))
+	time.Sleep(20 * time.Millisecond)
+	restored, err = NewFileResumeStore(path)
+	require.NoError(t, err)
+	assert.Empty(t, restored.List())
+	assert.Equal(t, 0, restored.Len())
+}
+
+func TestFileResumeStore_Broken(t *testing.T) {
+	_, err := NewFileResumeStore(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestResume_MaxSessions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	accepted := make(chan struct{}, 2)
+	go func(ctx context.Context) {
+		_ = Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Resume(WithServerResumeMaxSessions(1), WithServerResumeStore(NewMemoryResumeStore())).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				accepted <- struct{}{}
+				return fakeResponser, nil
+			}).
+			Transport(TCPServer().SetAddr(":9799").Build()).
+			Serve(ctx)
+	}(ctx)
+
+	<-started
+
+	first, err := Connect().
+		Resume().
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", 9799).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer first.Close()
+
+	<-accepted
+
+	closed := make(chan error, 1)
+	second, err := Connect().
+		Resume().
+		OnClose(func(err error) {
+			closed <- err
+		}).
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", 9799).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer second.Close()
+
+	select {
+	case err := <-closed:
+		require.Error(t, err)
+		assert.Equal(t, core.ErrorCodeRejectedSetup, err.(core.CustomError).ErrorCode())
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "second client should be rejected")
+	}
+	assert.Len(t, accepted, 0)
+}
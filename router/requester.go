@@ -0,0 +1,233 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/codec"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+var errNotCompositeMetadata = errors.New("metadata MIME type of client should be " + extension.MessageCompositeMetadata.String())
+
+// RequesterOption configures a Requester.
+type RequesterOption func(*Requester)
+
+// WithCodec registers a Codec of the MIME type for the Requester, it overrides the registry.
+func WithCodec(mimeType string, c codec.Codec) RequesterOption {
+	return func(r *Requester) {
+		r.codecs[mimeType] = c
+	}
+}
+
+// WithRegistry sets the codec Registry, default is codec.DefaultRegistry.
+func WithRegistry(registry *codec.Registry) RequesterOption {
+	return func(r *Requester) {
+		r.registry = registry
+	}
+}
+
+// WithDataMimeType sets the MIME type of data, default is the DataMimeType sent in SETUP.
+// It should be set if the client doesn't implement rsocket.MimeTypeAware, such as a balancer client.
+func WithDataMimeType(mimeType string) RequesterOption {
+	return func(r *Requester) {
+		r.dataMimeType = mimeType
+	}
+}
+
+// Requester sends routed requests by a client.
+// The data is encoded by the Codec of DataMimeType, bytes or strings are sent as is if there's no such codec.
+// The metadata is sent as CompositeMetadata, so the client should be started with
+// MetadataMimeType "message/x.rsocket.composite-metadata.v0".
+type Requester struct {
+	client       rsocket.Client
+	registry     *codec.Registry
+	codecs       map[string]codec.Codec
+	dataMimeType string
+}
+
+// NewRequester creates a new Requester.
+func NewRequester(client rsocket.Client, opts ...RequesterOption) *Requester {
+	r := &Requester{
+		client:   client,
+		registry: codec.DefaultRegistry,
+		codecs:   make(map[string]codec.Codec),
+	}
+	if aware, ok := client.(rsocket.MimeTypeAware); ok {
+		r.dataMimeType = aware.DataMimeType()
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Route starts building a request of the route.
+// The variables in route such as "{id}" will be replaced by args in order.
+func (r *Requester) Route(route string, args ...interface{}) *RequesterBuilder {
+	b := &RequesterBuilder{
+		r:        r,
+		metadata: extension.NewCompositeMetadataBuilder(),
+	}
+	if aware, ok := r.client.(rsocket.MimeTypeAware); ok && aware.MetadataMimeType() != extension.MessageCompositeMetadata.String() {
+		b.err = errNotCompositeMetadata
+		return b
+	}
+	expanded, err := expandRoute(route, args)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	routing, err := extension.EncodeRouting(expanded)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.metadata.PushWellKnown(extension.MessageRouting, routing)
+	return b
+}
+
+// Decode decodes the data of a response into the value pointed to by v.
+// The codec follows the MessageMimeType entry of response metadata if present, otherwise DataMimeType is used.
+func (r *Requester) Decode(msg payload.Payload, v interface{}) error {
+	metadata, _ := msg.Metadata()
+	// responders may send metadata which is not composite, just ignore it.
+	if mimeType, ok, err := codec.MessageMimeType(metadata); err == nil && ok {
+		return r.codecOf(mimeType).Decode(msg.Data(), v)
+	}
+	return r.Codec().Decode(msg.Data(), v)
+}
+
+// Codec returns the Codec of DataMimeType, it can be used with the typed helpers of package codec.
+func (r *Requester) Codec() codec.Codec {
+	return r.codecOf(r.dataMimeType)
+}
+
+func (r *Requester) codecOf(mimeType string) codec.Codec {
+	if c, ok := r.codecs[mimeType]; ok {
+		return c
+	}
+	if c, ok := r.registry.Get(mimeType); ok {
+		return c
+	}
+	return codec.Raw
+}
+
+// RequesterBuilder builds a routed request.
+type RequesterBuilder struct {
+	r        *Requester
+	metadata *extension.CompositeMetadataBuilder
+	data     interface{}
+	err      error
+}
+
+// Metadata adds an entry of CompositeMetadata.
+func (b *RequesterBuilder) Metadata(mimeType string, metadata []byte) *RequesterBuilder {
+	b.metadata.Push(mimeType, metadata)
+	return b
+}
+
+// Accept adds the MessageAcceptMimeTypes entry, the MIME types of response data are ordered by preference.
+func (b *RequesterBuilder) Accept(mimeType string, otherMimeTypes ...string) *RequesterBuilder {
+	raw, err := extension.EncodeAcceptMimeTypes(mimeType, otherMimeTypes...)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	b.metadata.PushWellKnown(extension.MessageAcceptMimeTypes, raw)
+	return b
+}
+
+// Data sets the value of data, it will be encoded by the Codec of DataMimeType.
+func (b *RequesterBuilder) Data(v interface{}) *RequesterBuilder {
+	b.data = v
+	return b
+}
+
+// Payload builds the request payload.
+func (b *RequesterBuilder) Payload() (payload.Payload, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	data, err := b.r.Codec().Encode(b.data)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode data failed")
+	}
+	metadata, err := b.metadata.Build()
+	if err != nil {
+		return nil, err
+	}
+	return payload.New(data, metadata), nil
+}
+
+// RetrieveMono sends the request as RequestResponse.
+func (b *RequesterBuilder) RetrieveMono() mono.Mono {
+	req, err := b.Payload()
+	if err != nil {
+		return mono.Error(err)
+	}
+	return b.r.client.RequestResponse(req)
+}
+
+// RetrieveFlux sends the request as RequestStream.
+func (b *RequesterBuilder) RetrieveFlux() flux.Flux {
+	req, err := b.Payload()
+	if err != nil {
+		return flux.Error(err)
+	}
+	return b.r.client.RequestStream(req)
+}
+
+// RetrieveChannel sends the request as the initial message of RequestChannel.
+func (b *RequesterBuilder) RetrieveChannel(messages flux.Flux) flux.Flux {
+	req, err := b.Payload()
+	if err != nil {
+		return flux.Error(err)
+	}
+	return b.r.client.RequestChannel(req, messages)
+}
+
+// Send sends the request as FireAndForget.
+func (b *RequesterBuilder) Send() error {
+	req, err := b.Payload()
+	if err != nil {
+		return err
+	}
+	b.r.client.FireAndForget(req)
+	return nil
+}
+
+// expandRoute replaces the variables in route by args in order.
+func expandRoute(route string, args []interface{}) (string, error) {
+	var sb strings.Builder
+	n, rest := 0, route
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", errors.Errorf("invalid route %q: unclosed variable", route)
+		}
+		if n >= len(args) {
+			return "", errors.Errorf("invalid route %q: missing argument %d", route, n)
+		}
+		sb.WriteString(rest[:start])
+		sb.WriteString(fmt.Sprint(args[n]))
+		n++
+		rest = rest[start+end+1:]
+	}
+	if n != len(args) {
+		return "", errors.Errorf("too many route arguments: expect %d, got %d", n, len(args))
+	}
+	sb.WriteString(rest)
+	return sb.String(), nil
+}
@@ -0,0 +1,212 @@
+package router_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/codec"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/router"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// metadataOf returns the first entry of the MIME type in CompositeMetadata.
+func metadataOf(msg payload.Payload, mimeType string) string {
+	metadata, _ := msg.Metadata()
+	scanner := extension.NewCompositeMetadataBytes(metadata).Scanner()
+	for scanner.Scan() {
+		mime, data, err := scanner.MetadataUTF8()
+		if err == nil && mime == mimeType {
+			return data
+		}
+	}
+	return ""
+}
+
+func startRequesterServer(ctx context.Context, port int) {
+	r := router.New().
+		RequestResponse("user.{id}", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			var user testUser
+			if err := json.Unmarshal(msg.Data(), &user); err != nil {
+				return mono.Error(err)
+			}
+			user.ID = vars["id"]
+			data, _ := json.Marshal(user)
+			return mono.Just(payload.New(data, []byte(metadataOf(msg, "application/x.trace"))))
+		}).
+		RequestStream("user.{id}.orders", func(msg payload.Payload, vars router.Vars) flux.Flux {
+			return flux.Just(
+				payload.NewString(`"`+vars["id"]+`-1"`, ""),
+				payload.NewString(`"`+vars["id"]+`-2"`, ""),
+			)
+		}).
+		FireAndForget("log", func(msg payload.Payload, vars router.Vars) {
+		})
+	started := make(chan struct{})
+	go func() {
+		_ = rsocket.Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+				return r.RSocket(), nil
+			}).
+			Transport(rsocket.TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+			Serve(ctx)
+	}()
+	<-started
+}
+
+func TestRequester(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	startRequesterServer(ctx, 9811)
+
+	client, err := rsocket.Connect().
+		DataMimeType(extension.ApplicationJSON.String()).
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", 9811).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	requester := router.NewRequester(client)
+
+	res, err := requester.Route("user.{id}", 42).
+		Metadata("application/x.trace", []byte("trace-1")).
+		Data(testUser{Name: "foo"}).
+		RetrieveMono().
+		Block(ctx)
+	require.NoError(t, err)
+	var user testUser
+	require.NoError(t, requester.Decode(res, &user))
+	assert.Equal(t, testUser{ID: "42", Name: "foo"}, user)
+	metadata, _ := res.MetadataUTF8()
+	assert.Equal(t, "trace-1", metadata)
+
+	orders, err := requester.Route("user.{id}.orders", "7").RetrieveFlux().BlockSlice(ctx)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	var order string
+	require.NoError(t, requester.Decode(orders[1], &order))
+	assert.Equal(t, "7-2", order)
+
+	assert.NoError(t, requester.Route("log").Data("hello").Send())
+
+	_, err = requester.Route("user.{id}").RetrieveMono().Block(ctx)
+	assert.Error(t, err, "should fail with missing argument")
+	_, err = requester.Route("user.{id}", 1, 2).RetrieveFlux().BlockLast(ctx)
+	assert.Error(t, err, "should fail with too many arguments")
+	assert.Error(t, requester.Route("log").Data(func() {}).Send(), "should fail to encode")
+}
+
+func TestRequester_Raw(t *testing.T) {
+	var sent payload.Payload
+	client := rsocket.NewAbstractSocket(rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+		sent = payload.Clone(msg)
+		return mono.Just(payload.NewString("pong", ""))
+	}))
+	requester := router.NewRequester(&fakeClient{RSocket: client})
+
+	res, err := requester.Route("ping").Data("ping").RetrieveMono().Block(context.Background())
+	require.NoError(t, err)
+	var s string
+	require.NoError(t, requester.Decode(res, &s))
+	assert.Equal(t, "pong", s)
+	assert.Equal(t, "ping", sent.DataUTF8())
+	assert.Equal(t, string([]byte{4})+"ping", metadataOf(sent, extension.MessageRouting.String()))
+
+	_, err = requester.Route("ping").Data(1).RetrieveMono().Block(context.Background())
+	assert.Error(t, err, "raw codec should not encode int")
+}
+
+func TestRequester_NotComposite(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	startRequesterServer(ctx, 9812)
+
+	client, err := rsocket.Connect().
+		Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", 9812).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = router.NewRequester(client).Route("user.1").RetrieveMono().Block(ctx)
+	assert.Error(t, err)
+}
+
+func TestRequester_Accept(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	started := make(chan struct{})
+	go func() {
+		_ = rsocket.Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+				echo := codec.RequestResponse(codec.DefaultRegistry.Selector(setup), func(ctx context.Context, req testUser) (testUser, error) {
+					return req, nil
+				})
+				return router.New().
+					RequestResponse("echo", func(msg payload.Payload, vars router.Vars) mono.Mono {
+						return echo(msg)
+					}).
+					RSocket(), nil
+			}).
+			Transport(rsocket.TCPServer().SetHostAndPort("127.0.0.1", 9813).Build()).
+			Serve(ctx)
+	}()
+	<-started
+
+	client, err := rsocket.Connect().
+		DataMimeType(extension.ApplicationJSON.String()).
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", 9813).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	requester := router.NewRequester(client)
+	res, err := requester.Route("echo").
+		Accept(extension.ApplicationCBOR.String()).
+		Data(testUser{Name: "foo"}).
+		RetrieveMono().
+		Block(ctx)
+	require.NoError(t, err)
+	metadata, _ := res.Metadata()
+	mimeType, ok, err := codec.MessageMimeType(metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, extension.ApplicationCBOR.String(), mimeType)
+	var user testUser
+	require.NoError(t, requester.Decode(res, &user))
+	assert.Equal(t, "foo", user.Name)
+
+	_, err = requester.Route("echo").Accept("").RetrieveMono().Block(ctx)
+	assert.Error(t, err, "should fail with empty MIME type")
+}
+
+type fakeClient struct {
+	rsocket.RSocket
+}
+
+func (f *fakeClient) OnClose(func(error)) {
+}
+
+func (f *fakeClient) Close() error {
+	return nil
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// segment kinds, ordered by specificity.
+const (
+	kindMultiWildcard = iota
+	kindWildcard
+	kindVar
+	kindLiteral
+)
+
+// Vars are the variables captured from route, such as "id" of pattern "user.{id}.orders".
+type Vars map[string]string
+
+type segment struct {
+	kind  int
+	value string
+}
+
+// pattern is a compiled route pattern, segments are separated by dot.
+type pattern struct {
+	raw      string
+	segments []segment
+}
+
+func compilePattern(raw string) (*pattern, error) {
+	if raw == "" {
+		return nil, errors.New("route pattern cannot be empty")
+	}
+	parts := strings.Split(raw, ".")
+	p := &pattern{
+		raw:      raw,
+		segments: make([]segment, 0, len(parts)),
+	}
+	names := make(map[string]struct{})
+	for i, part := range parts {
+		switch {
+		case part == "":
+			return nil, errors.Errorf("invalid route pattern %s: empty segment", raw)
+		case part == "**":
+			if i != len(parts)-1 {
+				return nil, errors.Errorf("invalid route pattern %s: ** must be the last segment", raw)
+			}
+			p.segments = append(p.segments, segment{kind: kindMultiWildcard})
+		case part == "*":
+			p.segments = append(p.segments, segment{kind: kindWildcard})
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name := part[1 : len(part)-1]
+			if name == "" || strings.ContainsAny(name, "{}*") {
+				return nil, errors.Errorf("invalid route pattern %s: bad variable %s", raw, part)
+			}
+			if _, ok := names[name]; ok {
+				return nil, errors.Errorf("invalid route pattern %s: duplicated variable %s", raw, name)
+			}
+			names[name] = struct{}{}
+			p.segments = append(p.segments, segment{kind: kindVar, value: name})
+		case strings.ContainsAny(part, "{}*"):
+			return nil, errors.Errorf("invalid route pattern %s: bad segment %s", raw, part)
+		default:
+			p.segments = append(p.segments, segment{kind: kindLiteral, value: part})
+		}
+	}
+	return p, nil
+}
+
+// match returns the variables if the route matches.
+func (p *pattern) match(route string) (Vars, bool) {
+	parts := strings.Split(route, ".")
+	var vars Vars
+	for i, seg := range p.segments {
+		if seg.kind == kindMultiWildcard {
+			return vars, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch seg.kind {
+		case kindLiteral:
+			if parts[i] != seg.value {
+				return nil, false
+			}
+		case kindVar:
+			if vars == nil {
+				vars = make(Vars)
+			}
+			vars[seg.value] = parts[i]
+		}
+	}
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	return vars, true
+}
+
+// moreSpecific returns true if p should be matched before other.
+func (p *pattern) moreSpecific(other *pattern) bool {
+	for i := 0; i < len(p.segments) && i < len(other.segments); i++ {
+		if a, b := p.segments[i].kind, other.segments[i].kind; a != b {
+			return a > b
+		}
+	}
+	return len(p.segments) > len(other.segments)
+}
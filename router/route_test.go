@@ -0,0 +1,55 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompilePattern(t *testing.T) {
+	for _, bad := range []string{"", "a..b", "a.**.b", "a.{}", "a.{id}.{id}", "a.b*", "a.{x"} {
+		_, err := compilePattern(bad)
+		assert.Error(t, err, "pattern %q should be invalid", bad)
+	}
+}
+
+func TestPattern_Match(t *testing.T) {
+	for _, it := range []struct {
+		pattern string
+		route   string
+		ok      bool
+		vars    Vars
+	}{
+		{"user.list", "user.list", true, nil},
+		{"user.list", "user.get", false, nil},
+		{"user.{id}.orders", "user.42.orders", true, Vars{"id": "42"}},
+		{"user.{id}.orders", "user.42", false, nil},
+		{"user.{id}.orders.{oid}", "user.42.orders.7", true, Vars{"id": "42", "oid": "7"}},
+		{"user.*.orders", "user.42.orders", true, nil},
+		{"user.*", "user.42.orders", false, nil},
+		{"user.**", "user.42.orders", true, nil},
+		{"user.**", "user", true, nil},
+		{"**", "anything.at.all", true, nil},
+	} {
+		p, err := compilePattern(it.pattern)
+		require.NoError(t, err)
+		vars, ok := p.match(it.route)
+		assert.Equal(t, it.ok, ok, "%s should match %s: %v", it.pattern, it.route, it.ok)
+		if it.ok {
+			assert.Equal(t, it.vars, vars)
+		}
+	}
+}
+
+func TestPattern_MoreSpecific(t *testing.T) {
+	must := func(raw string) *pattern {
+		p, err := compilePattern(raw)
+		require.NoError(t, err)
+		return p
+	}
+	assert.True(t, must("user.me").moreSpecific(must("user.{id}")))
+	assert.True(t, must("user.{id}").moreSpecific(must("user.*")))
+	assert.True(t, must("user.*").moreSpecific(must("user.**")))
+	assert.False(t, must("user.**").moreSpecific(must("user.{id}")))
+}
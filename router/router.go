@@ -0,0 +1,229 @@
+// Package router dispatches requests to handlers by the routing metadata.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Routing.md
+package router
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+var errMissingRouting = core.NewCustomError(core.ErrorCodeInvalid, []byte("missing routing metadata"))
+
+type (
+	// FireAndForgetHandler handles FireAndForget requests of a route.
+	FireAndForgetHandler = func(msg payload.Payload, vars Vars)
+	// MetadataPushHandler handles MetadataPush requests of a route.
+	MetadataPushHandler = func(msg payload.Payload, vars Vars)
+	// RequestResponseHandler handles RequestResponse requests of a route.
+	RequestResponseHandler = func(msg payload.Payload, vars Vars) mono.Mono
+	// RequestStreamHandler handles RequestStream requests of a route.
+	RequestStreamHandler = func(msg payload.Payload, vars Vars) flux.Flux
+	// RequestChannelHandler handles RequestChannel requests of a route.
+	RequestChannelHandler = func(initialMessage payload.Payload, messages flux.Flux, vars Vars) flux.Flux
+)
+
+// route is a pattern with handlers of each interaction model.
+type route struct {
+	*pattern
+	fnf     FireAndForgetHandler
+	push    MetadataPushHandler
+	rr      RequestResponseHandler
+	stream  RequestStreamHandler
+	channel RequestChannelHandler
+}
+
+// Router registers handlers by route patterns and interaction models.
+// A pattern consists of segments separated by dot, a segment can be:
+//   - a literal, such as "user".
+//   - a variable, such as "{id}", which matches any single segment and is captured into Vars.
+//   - "*", which matches any single segment.
+//   - "**", which matches the rest segments, it can only be the last one.
+//
+// The most specific pattern wins if a route matches multiple patterns, literal is more specific than
+// variable, and variable is more specific than wildcard.
+// The route is read from the routing entry in CompositeMetadata, requests without routing or with an
+// unknown route are rejected with ErrorCodeInvalid, and requests whose route has no handler of the
+// interaction model are rejected with ErrorCodeRejected.
+type Router struct {
+	mu     sync.RWMutex
+	routes []*route
+}
+
+// New creates a new Router.
+func New() *Router {
+	return &Router{}
+}
+
+// FireAndForget registers a FireAndForget handler, it panics if the pattern is invalid.
+func (r *Router) FireAndForget(pattern string, fn FireAndForgetHandler) *Router {
+	r.register(pattern, func(rt *route) {
+		rt.fnf = fn
+	})
+	return r
+}
+
+// MetadataPush registers a MetadataPush handler, it panics if the pattern is invalid.
+func (r *Router) MetadataPush(pattern string, fn MetadataPushHandler) *Router {
+	r.register(pattern, func(rt *route) {
+		rt.push = fn
+	})
+	return r
+}
+
+// RequestResponse registers a RequestResponse handler, it panics if the pattern is invalid.
+func (r *Router) RequestResponse(pattern string, fn RequestResponseHandler) *Router {
+	r.register(pattern, func(rt *route) {
+		rt.rr = fn
+	})
+	return r
+}
+
+// RequestStream registers a RequestStream handler, it panics if the pattern is invalid.
+func (r *Router) RequestStream(pattern string, fn RequestStreamHandler) *Router {
+	r.register(pattern, func(rt *route) {
+		rt.stream = fn
+	})
+	return r
+}
+
+// RequestChannel registers a RequestChannel handler, it panics if the pattern is invalid.
+func (r *Router) RequestChannel(pattern string, fn RequestChannelHandler) *Router {
+	r.register(pattern, func(rt *route) {
+		rt.channel = fn
+	})
+	return r
+}
+
+func (r *Router) register(raw string, set func(*route)) {
+	p, err := compilePattern(raw)
+	if err != nil {
+		panic(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, it := range r.routes {
+		if it.raw == raw {
+			set(it)
+			return
+		}
+	}
+	rt := &route{pattern: p}
+	set(rt)
+	r.routes = append(r.routes, rt)
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return r.routes[i].moreSpecific(r.routes[j].pattern)
+	})
+}
+
+// RSocket returns a RSocket which dispatches requests to the registered handlers.
+// It can be returned by ServerAcceptor or ClientSocketAcceptor.
+func (r *Router) RSocket() rsocket.RSocket {
+	return rsocket.NewAbstractSocket(
+		rsocket.FireAndForget(func(msg payload.Payload) {
+			rt, vars, err := r.lookup(msg, func(rt *route) bool {
+				return rt.fnf != nil
+			})
+			if err != nil {
+				logger.Warnf("route FireAndForget failed: %v\n", err)
+				return
+			}
+			rt.fnf(msg, vars)
+		}),
+		rsocket.MetadataPush(func(msg payload.Payload) {
+			rt, vars, err := r.lookup(msg, func(rt *route) bool {
+				return rt.push != nil
+			})
+			if err != nil {
+				logger.Warnf("route MetadataPush failed: %v\n", err)
+				return
+			}
+			rt.push(msg, vars)
+		}),
+		rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+			rt, vars, err := r.lookup(msg, func(rt *route) bool {
+				return rt.rr != nil
+			})
+			if err != nil {
+				return mono.Error(err)
+			}
+			return rt.rr(msg, vars)
+		}),
+		rsocket.RequestStream(func(msg payload.Payload) flux.Flux {
+			rt, vars, err := r.lookup(msg, func(rt *route) bool {
+				return rt.stream != nil
+			})
+			if err != nil {
+				return flux.Error(err)
+			}
+			return rt.stream(msg, vars)
+		}),
+		rsocket.RequestChannel(func(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+			rt, vars, err := r.lookup(initialMessage, func(rt *route) bool {
+				return rt.channel != nil
+			})
+			if err != nil {
+				return flux.Error(err)
+			}
+			return rt.channel(initialMessage, messages, vars)
+		}),
+	)
+}
+
+// lookup finds the most specific route which matches the routing tags and has the handler.
+func (r *Router) lookup(msg payload.Payload, has func(*route) bool) (*route, Vars, error) {
+	tags, err := routingTags(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matched := false
+	for _, tag := range tags {
+		for _, rt := range r.routes {
+			vars, ok := rt.match(tag)
+			if !ok {
+				continue
+			}
+			if has(rt) {
+				return rt, vars, nil
+			}
+			matched = true
+		}
+	}
+	if matched {
+		return nil, nil, core.NewCustomError(core.ErrorCodeRejected, []byte(fmt.Sprintf("unsupported interaction for route %v", tags)))
+	}
+	return nil, nil, core.NewCustomError(core.ErrorCodeInvalid, []byte(fmt.Sprintf("no such route %v", tags)))
+}
+
+// routingTags reads the tags of routing entry in CompositeMetadata.
+func routingTags(msg payload.Payload) (tags []string, err error) {
+	metadata, ok := msg.Metadata()
+	if !ok || len(metadata) < 1 {
+		return nil, errMissingRouting
+	}
+	data, ok, err := extension.CompositeMetadata(metadata).Lookup(extension.MessageRouting.String())
+	if err != nil {
+		return nil, core.NewCustomError(core.ErrorCodeInvalid, []byte(errors.Wrap(err, "bad composite metadata").Error()))
+	}
+	if !ok {
+		return nil, errMissingRouting
+	}
+	if tags, err = extension.ParseRoutingTags(data); err != nil {
+		return nil, core.NewCustomError(core.ErrorCodeInvalid, []byte(err.Error()))
+	}
+	if len(tags) < 1 {
+		return nil, errMissingRouting
+	}
+	return tags, nil
+}
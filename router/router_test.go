@@ -0,0 +1,164 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/router"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routeRequest(t *testing.T, data string, tags ...string) payload.Payload {
+	routing, err := extension.EncodeRouting(tags[0], tags[1:]...)
+	require.NoError(t, err)
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnownString(extension.TextPlain, "ignored").
+		PushWellKnown(extension.MessageRouting, routing).
+		Build()
+	require.NoError(t, err)
+	return payload.New([]byte(data), metadata)
+}
+
+func assertErrorCode(t *testing.T, code core.ErrorCode, err error) {
+	require.Error(t, err)
+	customErr, ok := err.(core.CustomError)
+	require.True(t, ok, "should be a custom error: %v", err)
+	assert.Equal(t, code, customErr.ErrorCode())
+}
+
+func newTestRouter() *router.Router {
+	return router.New().
+		RequestResponse("user.{id}", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return mono.Just(payload.NewString("user "+vars["id"], ""))
+		}).
+		RequestResponse("user.me", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return mono.Just(payload.NewString("me", ""))
+		}).
+		RequestStream("user.{id}.orders", func(msg payload.Payload, vars router.Vars) flux.Flux {
+			return flux.Just(
+				payload.NewString(vars["id"]+"-1", ""),
+				payload.NewString(vars["id"]+"-2", ""),
+			)
+		}).
+		RequestChannel("echo.**", func(initialMessage payload.Payload, messages flux.Flux, vars router.Vars) flux.Flux {
+			return messages
+		}).
+		RequestResponse("*.ping", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return mono.Just(payload.NewString("pong", ""))
+		})
+}
+
+func TestRouter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rs := newTestRouter().RSocket()
+
+	res, err := rs.RequestResponse(routeRequest(t, "", "user.42")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "user 42", res.DataUTF8())
+
+	// literal is more specific than variable
+	res, err = rs.RequestResponse(routeRequest(t, "", "user.me")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "me", res.DataUTF8())
+
+	res, err = rs.RequestResponse(routeRequest(t, "", "health.ping")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", res.DataUTF8())
+
+	// the first tag which matches
+	res, err = rs.RequestResponse(routeRequest(t, "", "unknown", "user.7")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "user 7", res.DataUTF8())
+
+	orders, err := rs.RequestStream(routeRequest(t, "", "user.42.orders")).BlockSlice(ctx)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, "42-1", orders[0].DataUTF8())
+	assert.Equal(t, "42-2", orders[1].DataUTF8())
+
+	echoed, err := rs.RequestChannel(routeRequest(t, "", "echo.a.b"), flux.Just(payload.NewString("x", ""))).BlockSlice(ctx)
+	require.NoError(t, err)
+	require.Len(t, echoed, 1)
+	assert.Equal(t, "x", echoed[0].DataUTF8())
+
+	_, err = rs.RequestResponse(routeRequest(t, "", "order.42")).Block(ctx)
+	assertErrorCode(t, core.ErrorCodeInvalid, err)
+	_, err = rs.RequestStream(routeRequest(t, "", "user.42")).BlockLast(ctx)
+	assertErrorCode(t, core.ErrorCodeRejected, err)
+	_, err = rs.RequestResponse(payload.NewString("no routing", "")).Block(ctx)
+	assertErrorCode(t, core.ErrorCodeInvalid, err)
+	_, err = rs.RequestResponse(payload.New(nil, []byte{0x80, 0xFF})).Block(ctx)
+	assertErrorCode(t, core.ErrorCodeInvalid, err)
+}
+
+func TestRouter_FireAndForget(t *testing.T) {
+	fnf := make(chan string, 1)
+	push := make(chan string, 1)
+	rs := router.New().
+		FireAndForget("log.{level}", func(msg payload.Payload, vars router.Vars) {
+			fnf <- vars["level"]
+		}).
+		MetadataPush("config.reload", func(msg payload.Payload, vars router.Vars) {
+			push <- "reload"
+		}).
+		RSocket()
+	rs.FireAndForget(routeRequest(t, "", "log.info"))
+	assert.Equal(t, "info", <-fnf)
+	rs.MetadataPush(routeRequest(t, "", "config.reload"))
+	assert.Equal(t, "reload", <-push)
+	// ignore unknown routes
+	rs.FireAndForget(routeRequest(t, "", "unknown"))
+	assert.Len(t, fnf, 0)
+}
+
+func TestRouter_InvalidPattern(t *testing.T) {
+	assert.Panics(t, func() {
+		router.New().RequestResponse("user.{id}.{id}", nil)
+	})
+}
+
+func TestRouter_Server(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	r := newTestRouter()
+	go func() {
+		_ = rsocket.Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+				return r.RSocket(), nil
+			}).
+			Transport(rsocket.TCPServer().SetHostAndPort("127.0.0.1", 9810).Build()).
+			Serve(ctx)
+	}()
+	<-started
+
+	client, err := rsocket.Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", 9810).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	res, err := client.RequestResponse(routeRequest(t, "", "user.42")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "user 42", res.DataUTF8())
+
+	_, err = client.RequestResponse(routeRequest(t, "", "order.42")).Block(ctx)
+	assertErrorCode(t, core.ErrorCodeInvalid, err)
+	_, err = client.RequestStream(routeRequest(t, "", "user.42")).BlockLast(ctx)
+	assertErrorCode(t, core.ErrorCodeRejected, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/rsocket/rsocket-go/core"
+	"github.com/rsocket/rsocket-go/extension"
 	"github.com/rsocket/rsocket-go/internal/socket"
 	"github.com/rsocket/rsocket-go/payload"
 	"github.com/rsocket/rsocket-go/rx/flux"
@@ -126,6 +127,92 @@ func RequestChannel(fn func(initialRequest payload.Payload, requests flux.Flux)
 	}
 }
 
+// MetadataPushWithContext register request handler for MetadataPush.
+// The context carries the information of request, it is cancelled when the connection is closed.
+func MetadataPushWithContext(fn func(ctx context.Context, request payload.Payload)) OptAbstractSocket {
+	return func(opts *socket.AbstractRSocket) {
+		opts.MP = func(request payload.Payload) {
+			fn(socket.RequestContext(request), request)
+		}
+	}
+}
+
+// FireAndForgetWithContext register request handler for FireAndForget.
+// The context carries the information of request, it is cancelled when the connection is closed.
+func FireAndForgetWithContext(fn func(ctx context.Context, request payload.Payload)) OptAbstractSocket {
+	return func(opts *socket.AbstractRSocket) {
+		opts.FF = func(request payload.Payload) {
+			fn(socket.RequestContext(request), request)
+		}
+	}
+}
+
+// RequestResponseWithContext register request handler for RequestResponse.
+// The context carries the information of request, it is cancelled when the stream is cancelled or finished.
+func RequestResponseWithContext(fn func(ctx context.Context, request payload.Payload) (response mono.Mono)) OptAbstractSocket {
+	return func(opts *socket.AbstractRSocket) {
+		opts.RR = func(request payload.Payload) mono.Mono {
+			return fn(socket.RequestContext(request), request)
+		}
+	}
+}
+
+// RequestStreamWithContext register request handler for RequestStream.
+// The context carries the information of request, it is cancelled when the stream is cancelled or finished.
+func RequestStreamWithContext(fn func(ctx context.Context, request payload.Payload) (responses flux.Flux)) OptAbstractSocket {
+	return func(opts *socket.AbstractRSocket) {
+		opts.RS = func(request payload.Payload) flux.Flux {
+			return fn(socket.RequestContext(request), request)
+		}
+	}
+}
+
+// RequestChannelWithContext register request handler for RequestChannel.
+// The context carries the information of request, it is cancelled when the stream is cancelled or finished.
+func RequestChannelWithContext(fn func(ctx context.Context, initialRequest payload.Payload, requests flux.Flux) (responses flux.Flux)) OptAbstractSocket {
+	return func(opts *socket.AbstractRSocket) {
+		opts.RC = func(initialRequest payload.Payload, requests flux.Flux) flux.Flux {
+			return fn(socket.RequestContext(initialRequest), initialRequest, requests)
+		}
+	}
+}
+
+// WithContext returns a request message which carries the given context.
+// Requests are sent with the deadline of the context which they are subscribed with, eg: Block(ctx) or mono.Timeout,
+// if the metadata MIME type of connection is CompositeMetadata the remaining time is sent as the timeout entry of
+// CompositeMetadata, see extension.TimeoutMimeType. The context carried by the message overrides the subscriber context.
+// The responder cancels the handler context and terminates the stream with ErrorCodeCanceled once the deadline passes.
+// The messages received by handlers carry the handler context already, so the deadline is propagated when forwarding them.
+func WithContext(ctx context.Context, message payload.Payload) payload.Payload {
+	return socket.WithContext(ctx, message)
+}
+
+// ContextOf returns the context carried by the message, or context.Background() if there is no context.
+func ContextOf(message payload.Payload) context.Context {
+	return socket.RequestContext(message)
+}
+
+// StreamIDFromContext returns the stream id of the request which handler context belongs to.
+func StreamIDFromContext(ctx context.Context) (uint32, bool) {
+	return socket.StreamIDFromContext(ctx)
+}
+
+// SetupPayloadFromContext returns the SetupPayload of the connection which handler context belongs to.
+func SetupPayloadFromContext(ctx context.Context) (payload.SetupPayload, bool) {
+	return socket.SetupPayloadFromContext(ctx)
+}
+
+// PeerAddrFromContext returns the peer address of the connection which handler context belongs to.
+func PeerAddrFromContext(ctx context.Context) (string, bool) {
+	return socket.PeerAddrFromContext(ctx)
+}
+
+// CompositeMetadataFromContext returns the decoded composite metadata of the request which handler context belongs to.
+// It returns false if the metadata MIME type of the connection is not composite metadata.
+func CompositeMetadataFromContext(ctx context.Context) ([]extension.CompositeMetadataEntry, bool) {
+	return socket.CompositeMetadataFromContext(ctx)
+}
+
 // GetAddr returns the address info of given RSocket.
 // Normally, the format is "IP:PORT".
 func GetAddr(rs RSocket) (string, bool) {
@@ -134,3 +221,8 @@ func GetAddr(rs RSocket) (string, bool) {
 	}
 	return "", false
 }
+
+// IsSocketClosedError returns true if the error is caused by a closed socket.
+func IsSocketClosedError(err error) bool {
+	return socket.IsSocketClosedError(err)
+}
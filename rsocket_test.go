@@ -6,6 +6,8 @@ import (
 	"io"
 	"log"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -133,6 +135,88 @@ func TestResume(t *testing.T) {
 	assert.Error(t, err, "should return error")
 }
 
+func TestResume_Replay(t *testing.T) {
+	const total = 20
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+
+	go func(ctx context.Context) {
+		_ = Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Resume().
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestStream(func(msg payload.Payload) flux.Flux {
+						return flux.Create(func(ctx context.Context, s flux.Sink) {
+							for i := 0; i < total; i++ {
+								time.Sleep(30 * time.Millisecond)
+								s.Next(payload.NewString(fmt.Sprintf("%d", i), ""))
+							}
+							s.Complete()
+						})
+					}),
+				), nil
+			}).
+			Transport(TCPServer().SetAddr(":9798").Build()).
+			Serve(ctx)
+	}(ctx)
+
+	<-started
+
+	ch := make(chan net.Listener, 1)
+
+	proxyAddr := ":7980"
+	upstreamAddr := "127.0.0.1:9798"
+	go startProxy(proxyAddr, ch, upstreamAddr)
+
+	time.Sleep(200 * time.Millisecond)
+
+	cli, err := Connect().
+		Resume().
+		Transport(TCPClient().SetHostAndPort("127.0.0.1", 7980).Build()).
+		Start(ctx)
+	require.NoError(t, err, "connect failed")
+	defer cli.Close()
+
+	var received []string
+	done := make(chan struct{})
+	cli.RequestStream(fakeRequest).
+		DoOnNext(func(input payload.Payload) error {
+			received = append(received, string(input.Data()))
+			if len(received) == 5 {
+				// break the connection, then frames in flight should be retransmitted after resume.
+				_ = (<-ch).Close()
+				go func() {
+					time.Sleep(200 * time.Millisecond)
+					startProxy(proxyAddr, ch, upstreamAddr)
+				}()
+			}
+			return nil
+		}).
+		DoFinally(func(s rx.SignalType) {
+			close(done)
+		}).
+		Subscribe(ctx)
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "stream timeout")
+	}
+	defer func() {
+		_ = (<-ch).Close()
+	}()
+
+	require.Len(t, received, total)
+	for i := 0; i < total; i++ {
+		assert.Equal(t, fmt.Sprintf("%d", i), received[i])
+	}
+}
+
 func TestReceiveWithBadArgs(t *testing.T) {
 	err := Receive().
 		Fragment(-999).
@@ -301,14 +385,39 @@ func TestSuite(t *testing.T) {
 	m := []string{
 		"tcp",
 		"websocket",
+		"local",
+		"pipe",
+		"websocket_handler",
 	}
+	pipe := newPipeListener()
+
+	mux := http.NewServeMux()
+	hs := httptest.NewUnstartedServer(mux)
+	handlerTp, handler := WebsocketServer().
+		SetHTTPServer(hs.Config).
+		SetCompression(0).
+		SetSubprotocols(transport.WebsocketSubprotocol).
+		BuildHandler()
+	mux.Handle("/rsocket", handler)
+	hs.Start()
+	defer hs.Close()
 	c := []transport.ClientTransporter{
 		TCPClient().SetHostAndPort("127.0.0.1", 7878).Build(),
 		WebsocketClient().SetURL("ws://127.0.0.1:8080/test").Build(),
+		LocalClient("test").Build(),
+		ConnClient(pipe.Dial).Build(),
+		WebsocketClient().
+			SetURL(fmt.Sprintf("ws://%s/rsocket", hs.Listener.Addr())).
+			SetCompression(0).
+			SetSubprotocols(transport.WebsocketSubprotocol).
+			Build(),
 	}
 	s := []transport.ServerTransporter{
 		TCPServer().SetAddr(":7878").Build(),
 		WebsocketServer().SetAddr("127.0.0.1:8080").SetPath("/test").Build(),
+		LocalServer("test").Build(),
+		ConnServer(pipe).Build(),
+		handlerTp,
 	}
 
 	for i := 0; i < len(m); i++ {
@@ -316,6 +425,62 @@ func TestSuite(t *testing.T) {
 	}
 }
 
+// pipeListener is a net.Listener which accepts the connections created by net.Pipe.
+type pipeListener struct {
+	conns chan net.Conn
+	once  sync.Once
+	done  chan struct{}
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (p *pipeListener) Dial(ctx context.Context) (net.Conn, error) {
+	c, s := net.Pipe()
+	select {
+	case p.conns <- s:
+		return c, nil
+	case <-p.done:
+		return nil, errors.New("pipe listener closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-p.conns:
+		return c, nil
+	case <-p.done:
+		return nil, errors.New("pipe listener closed")
+	}
+}
+
+func (p *pipeListener) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+	})
+	return nil
+}
+
+func (p *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string {
+	return "pipe"
+}
+
+func (pipeAddr) String() string {
+	return "pipe"
+}
+
 type ctxKey string
 
 func testAll(t *testing.T, proto string, clientTp transport.ClientTransporter, serverTp transport.ServerTransporter) {
@@ -532,6 +697,44 @@ func testRequestChannelOneByOne(ctx context.Context, cli Client, t *testing.T) {
 	<-done
 }
 
+// startEchoServer starts a tcp server which echoes requests, the stream responds streamElements copies of request.
+// The opts configure the ServerBuilder before serving.
+func startEchoServer(ctx context.Context, t *testing.T, port int, opts ...func(ServerBuilder) ServerBuilder) {
+	started := make(chan struct{})
+	var builder ServerBuilder = Receive()
+	for _, opt := range opts {
+		builder = opt(builder)
+	}
+	go func() {
+		err := builder.
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					FireAndForget(func(msg payload.Payload) {}),
+					MetadataPush(func(msg payload.Payload) {}),
+					RequestResponse(func(msg payload.Payload) mono.Mono {
+						return mono.Just(payload.Clone(msg))
+					}),
+					RequestStream(func(msg payload.Payload) flux.Flux {
+						d := msg.DataUTF8()
+						return flux.Create(func(ctx context.Context, s flux.Sink) {
+							for i := 0; i < int(streamElements); i++ {
+								s.Next(payload.NewString(d, fmt.Sprintf("%d", i)))
+							}
+							s.Complete()
+						})
+					}),
+				), nil
+			}).
+			Transport(TCPServer().SetHostAndPort("127.0.0.1", port).Build()).
+			Serve(ctx)
+		assert.NoError(t, err)
+	}()
+	<-started
+}
+
 // Starting a tcp proxy to simulate network broken
 func startProxy(addr string, ch chan net.Listener, upstreamAddr string) {
 	var (
@@ -59,6 +59,7 @@ type Mono interface {
 	// It also puts errors into another chan.
 	ToChan(ctx context.Context) (c <-chan payload.Payload, e <-chan error)
 	// Timeout sets the timeout value.
+	// The Mono is subscribed with a context whose deadline is the timeout, so requests send it to the responder.
 	Timeout(timeout time.Duration) Mono
 }
 
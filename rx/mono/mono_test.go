@@ -184,6 +184,8 @@ func TestCreate(t *testing.T) {
 
 func TestTimeout(t *testing.T) {
 	gen := func(ctx context.Context, sink Sink) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "should subscribe with deadline")
 		time.Sleep(100 * time.Millisecond)
 		sink.Success(payload.NewString("foobar", ""))
 	}
@@ -126,7 +126,7 @@ func (p proxy) SwitchValueIfError(alternative payload.Payload) Mono {
 }
 
 func (p proxy) Timeout(timeout time.Duration) Mono {
-	return newProxy(p.Mono.Timeout(timeout))
+	return newProxy(withDeadline(p.Mono, timeout).Timeout(timeout))
 }
 
 func (p proxy) ZipWith(alternative Mono, cmb Combinator2) Mono {
@@ -173,6 +173,6 @@ func (o *oneshotProxy) ToChan(ctx context.Context) (c <-chan payload.Payload, e
 }
 
 func (o *oneshotProxy) Timeout(timeout time.Duration) Mono {
-	o.Mono = o.Mono.Timeout(timeout)
+	o.Mono = withDeadline(o.Mono, timeout).Timeout(timeout)
 	return o
 }
@@ -2,6 +2,7 @@ package mono
 
 import (
 	"context"
+	"time"
 
 	"github.com/jjeffcaii/reactor-go"
 	"github.com/jjeffcaii/reactor-go/mono"
@@ -23,6 +24,32 @@ func FromFunc(gen func(context.Context) (payload.Payload, error)) Mono {
 	})
 }
 
+// withDeadline subscribes the source with a context which is done after the timeout,
+// so the requesters can send the deadline of request to the responder.
+func withDeadline(source mono.Mono, timeout time.Duration) mono.Mono {
+	return mono.Create(func(ctx context.Context, sink mono.Sink) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		var emitted bool
+		source.Subscribe(ctx,
+			reactor.OnNext(func(v reactor.Any) error {
+				emitted = true
+				sink.Success(v)
+				return nil
+			}),
+			reactor.OnComplete(func() {
+				cancel()
+				if !emitted {
+					sink.Success(nil)
+				}
+			}),
+			reactor.OnError(func(err error) {
+				cancel()
+				sink.Error(err)
+			}),
+		)
+	})
+}
+
 // IsSubscribeAsync returns true if target Mono will be subscribed async.
 func IsSubscribeAsync(m Mono) bool {
 	return mono.IsSubscribeAsync(m.Raw())
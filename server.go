@@ -2,13 +2,16 @@ package rsocket
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/jjeffcaii/reactor-go/scheduler"
 
+	"github.com/rsocket/rsocket-go/compression"
 	"github.com/rsocket/rsocket-go/core"
 	"github.com/rsocket/rsocket-go/core/framing"
 	"github.com/rsocket/rsocket-go/core/transport"
+	"github.com/rsocket/rsocket-go/extension"
 	"github.com/rsocket/rsocket-go/internal/bytesconv"
 	"github.com/rsocket/rsocket-go/internal/common"
 	"github.com/rsocket/rsocket-go/internal/fragmentation"
@@ -16,6 +19,8 @@ import (
 	"github.com/rsocket/rsocket-go/internal/socket"
 	"github.com/rsocket/rsocket-go/lease"
 	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/metrics"
+	"github.com/rsocket/rsocket-go/payload"
 )
 
 const (
@@ -28,6 +33,9 @@ var (
 	_errUnavailableLease     = "lease not supported"
 	_errDuplicatedSetupToken = "duplicated setup token"
 	_errInvalidFirstFrame    = "first frame must be setup or resume"
+	_errNoSuchSession        = "no such session"
+	_errTooManySessions      = "too many resumable sessions"
+	_errUnsupportedCompress  = "compression not supported: "
 )
 
 type (
@@ -44,7 +52,16 @@ type (
 		Lease(leases lease.Factory) ServerBuilder
 		// Resume enable resume for current server.
 		Resume(opts ...OpServerResume) ServerBuilder
+		// Interceptors adds interceptors of requester, responder and connection.
+		// The acceptor receives the intercepted requester, and the responder returned by acceptor will be intercepted.
+		Interceptors(opts ...InterceptorOption) ServerBuilder
+		// Metrics enables recording the metrics of connections, the connection label is the address of client.
+		Metrics(m *metrics.Metrics) ServerBuilder
+		// CompressionThreshold sets the threshold of the payload compression requested by clients, see ClientBuilder.Compression.
+		// The payload data larger than threshold will be compressed, zero threshold means compression.DefaultThreshold.
+		CompressionThreshold(threshold int) ServerBuilder
 		// Acceptor register server acceptor which is used to handle incoming RSockets.
+		// The SETUP will be rejected if acceptor returns an error, a core.CustomError is sent with its own code.
 		Acceptor(acceptor ServerAcceptor) ToServerStarter
 		// OnStart register a handler when serve success.
 		OnStart(onStart func()) ServerBuilder
@@ -68,6 +85,9 @@ type (
 type serverResumeOptions struct {
 	enable          bool
 	sessionDuration time.Duration
+	bufferSize      int
+	maxSessions     int
+	store           ResumeStore
 }
 
 var (
@@ -76,15 +96,19 @@ var (
 )
 
 type server struct {
-	reqSc, resSc scheduler.Scheduler
-	tp           transport.ServerTransporter
-	resumeOpts   *serverResumeOptions
-	fragment     int
-	acc          ServerAcceptor
-	sm           *session.Manager
-	done         chan struct{}
-	onServe      []func()
-	leases       lease.Factory
+	reqSc, resSc  scheduler.Scheduler
+	tp            transport.ServerTransporter
+	resumeOpts    *serverResumeOptions
+	fragment      int
+	acc           ServerAcceptor
+	actives       sync.Map // key=token, value=struct{}
+	activeLocker  sync.Mutex
+	done          chan struct{}
+	onServe       []func()
+	leases        lease.Factory
+	interceptors  *interceptorChains
+	metrics       *metrics.Metrics
+	compressLimit int
 }
 
 func (srv *server) Scheduler(req, res scheduler.Scheduler) ServerBuilder {
@@ -113,6 +137,21 @@ func (srv *server) Resume(opts ...OpServerResume) ServerBuilder {
 	return srv
 }
 
+func (srv *server) Interceptors(opts ...InterceptorOption) ServerBuilder {
+	srv.interceptors = newInterceptorChains(srv.interceptors, opts)
+	return srv
+}
+
+func (srv *server) Metrics(m *metrics.Metrics) ServerBuilder {
+	srv.metrics = m
+	return srv
+}
+
+func (srv *server) CompressionThreshold(threshold int) ServerBuilder {
+	srv.compressLimit = threshold
+	return srv
+}
+
 func (srv *server) Fragment(mtu int) ServerBuilder {
 	if mtu == 0 {
 		srv.fragment = fragmentation.MaxFragment
@@ -171,15 +210,19 @@ func (srv *server) Serve(ctx context.Context) error {
 				ssk.Pause()
 				deadline := time.Now().Add(srv.resumeOpts.sessionDuration)
 				s := session.NewSession(deadline, ssk)
-				srv.sm.Push(s)
-				if logger.IsDebugEnabled() {
-					logger.Debugf("store session: %s\n", s)
-				}
+				srv.saveSession(s)
 			default:
 			}
 			close(socketChan)
 		}()
 
+		var cm *connectionMetrics
+		if srv.metrics != nil {
+			cm = newConnectionMetrics(srv.metrics, "")
+			cm.observe(tp)
+			defer cm.close(nil)
+		}
+
 		first, err := tp.ReadFirst(ctx)
 		if err != nil {
 			logger.Errorf("read first frame failed: %s\n", err)
@@ -193,7 +236,7 @@ func (srv *server) Serve(ctx context.Context) error {
 		case *framing.ResumeFrame:
 			srv.doResume(frame, tp, socketChan)
 		case *framing.SetupFrame:
-			sendingSocket, err := srv.doSetup(ctx, frame, tp, socketChan)
+			sendingSocket, err := srv.doSetup(ctx, frame, tp, socketChan, cm)
 			if err != nil {
 				_ = tp.Send(err, true)
 				_ = tp.Close()
@@ -225,7 +268,24 @@ func (srv *server) Serve(ctx context.Context) error {
 	return t.Listen(ctx, notifier)
 }
 
-func (srv *server) doSetup(ctx context.Context, frame *framing.SetupFrame, tp *transport.Transport, socketChan chan<- socket.ServerSocket) (sendingSocket socket.ServerSocket, err *framing.WriteableErrorFrame) {
+// accept calls acceptor with interceptors.
+func (srv *server) accept(ctx context.Context, setup payload.SetupPayload, sendingSocket socket.ServerSocket, cm *connectionMetrics) (RSocket, error) {
+	if cm != nil {
+		cm.composite = setup.MetadataMimeType() == extension.MessageCompositeMetadata.String()
+	}
+	interceptors := cm.intercept(srv.interceptors)
+	requester := interceptors.wrapRequester(sendingSocket)
+	if err := interceptors.connect(ctx, setup, requester); err != nil {
+		return nil, err
+	}
+	responder, err := srv.acc(ctx, setup, requester)
+	if err != nil {
+		return nil, err
+	}
+	return interceptors.wrapResponder(responder), nil
+}
+
+func (srv *server) doSetup(ctx context.Context, frame *framing.SetupFrame, tp *transport.Transport, socketChan chan<- socket.ServerSocket, cm *connectionMetrics) (sendingSocket socket.ServerSocket, err *framing.WriteableErrorFrame) {
 	if frame.HasFlag(core.FlagLease) && srv.leases == nil {
 		err = framing.NewWriteableErrorFrame(0, core.ErrorCodeUnsupportedSetup, bytesconv.StringToBytes(_errUnavailableLease))
 		return
@@ -239,7 +299,20 @@ func (srv *server) doSetup(ctx context.Context, frame *framing.SetupFrame, tp *t
 		return
 	}
 
+	// reject the compression which is not registered.
+	setupMetadata, _ := frame.Metadata()
+	compressionName, hasCompression := compression.FromSetupMetadata(frame.MetadataMimeType(), setupMetadata)
+	compressor, _ := compression.Lookup(compressionName)
+	if hasCompression && compressor == nil {
+		err = framing.NewWriteableErrorFrame(0, core.ErrorCodeUnsupportedSetup, bytesconv.StringToBytes(_errUnsupportedCompress+compressionName))
+		return
+	}
+
 	rawSocket := socket.NewServerDuplexConnection(ctx, srv.reqSc, srv.resSc, srv.fragment, srv.leases)
+	rawSocket.SetSetupInfo(socket.NewSetupInfo(frame))
+	if compressor != nil {
+		rawSocket.SetCompression(compressor, srv.compressLimit)
+	}
 
 	// 2. no resume
 	if !isResume {
@@ -250,8 +323,12 @@ func (srv *server) doSetup(ctx context.Context, frame *framing.SetupFrame, tp *t
 			sendingSocket.SetAddr(addr)
 		}
 
-		if responder, e := srv.acc(ctx, frame, sendingSocket); e != nil {
-			err = framing.NewWriteableErrorFrame(0, core.ErrorCodeRejectedSetup, []byte(e.Error()))
+		if responder, e := srv.accept(ctx, frame, sendingSocket, cm); e != nil {
+			if ce, ok := e.(core.CustomError); ok {
+				err = framing.NewWriteableErrorFrame(0, ce.ErrorCode(), ce.ErrorData())
+			} else {
+				err = framing.NewWriteableErrorFrame(0, core.ErrorCodeRejectedSetup, []byte(e.Error()))
+			}
 		} else {
 			sendingSocket.SetResponder(responder)
 			sendingSocket.SetTransport(tp)
@@ -262,26 +339,28 @@ func (srv *server) doSetup(ctx context.Context, frame *framing.SetupFrame, tp *t
 
 	token := frame.Token()
 
-	// 3. resume reject because of duplicated token.
-	if _, ok := srv.sm.Load(token); ok {
-		err = framing.NewWriteableErrorFrame(0, core.ErrorCodeRejectedSetup, bytesconv.StringToBytes(_errDuplicatedSetupToken))
-		return
-	}
-
 	// clone token
 	token = common.CloneBytes(token)
 
+	// 3. resume reject because of duplicated token or too many sessions.
+	if reason, ok := srv.reserveSession(token); !ok {
+		err = framing.NewWriteableErrorFrame(0, core.ErrorCodeRejectedSetup, bytesconv.StringToBytes(reason))
+		return
+	}
+
 	// 4. resume success
 	sendingSocket = socket.NewResumableServerSocket(rawSocket, token)
+	rawSocket.SetResumeBufferSize(srv.resumeOpts.bufferSize)
 
 	// workaround: set address info
 	if addr, ok := tp.Addr(); ok {
 		sendingSocket.SetAddr(addr)
 	}
 
-	if responder, e := srv.acc(ctx, frame, sendingSocket); e != nil {
+	if responder, e := srv.accept(ctx, frame, sendingSocket, cm); e != nil {
+		srv.releaseSession(token)
 		switch vv := e.(type) {
-		case *framing.ErrorFrame:
+		case core.CustomError:
 			err = framing.NewWriteableErrorFrame(0, vv.ErrorCode(), vv.ErrorData())
 		default:
 			err = framing.NewWriteableErrorFrame(0, core.ErrorCodeInvalidSetup, []byte(e.Error()))
@@ -295,27 +374,95 @@ func (srv *server) doSetup(ctx context.Context, frame *framing.SetupFrame, tp *t
 }
 
 func (srv *server) doResume(frame *framing.ResumeFrame, tp *transport.Transport, socketChan chan<- socket.ServerSocket) {
-	var sending core.WriteableFrame
 	if !srv.resumeOpts.enable {
-		sending = framing.NewWriteableErrorFrame(0, core.ErrorCodeRejectedResume, bytesconv.StringToBytes(_errUnavailableResume))
-	} else if s, ok := srv.sm.Load(frame.Token()); ok {
-		sending = framing.NewWriteableResumeOKFrame(0)
-		s.Socket().SetTransport(tp)
-		socketChan <- s.Socket()
-		if logger.IsDebugEnabled() {
-			logger.Debugf("recover session: %s\n", s)
+		srv.rejectResume(tp, _errUnavailableResume)
+		return
+	}
+	s, ok := srv.resumeOpts.store.Evict(frame.Token())
+	if !ok {
+		srv.rejectResume(tp, _errNoSuchSession)
+		return
+	}
+	// The session may be expired before it's cleaned.
+	if !s.Deadline().After(time.Now()) {
+		srv.rejectResume(tp, _errNoSuchSession)
+		_ = s.Close()
+		return
+	}
+	holder, ok := s.(serverSocketHolder)
+	if !ok {
+		srv.rejectResume(tp, errUnavailableSession.Error())
+		_ = s.Close()
+		return
+	}
+	// RESUME_OK and the frames to be retransmitted will be sent if positions are valid.
+	if err := holder.Socket().Resume(frame, tp); err != nil {
+		logger.Warnf("reject resume %s: %s\n", s, err)
+		srv.rejectResume(tp, err.Error())
+		if err := s.Close(); err != nil {
+			logger.Warnf("close rejected session failed: %s\n", err)
 		}
-	} else {
-		sending = framing.NewWriteableErrorFrame(
-			0,
-			core.ErrorCodeRejectedResume,
-			[]byte("no such session"),
-		)
+		return
+	}
+	srv.actives.Store(string(s.Token()), struct{}{})
+	socketChan <- holder.Socket()
+	if logger.IsDebugEnabled() {
+		logger.Debugf("recover session: %s\n", s)
 	}
+}
+
+// reserveSession marks the token as an active resumable connection.
+// It returns false if the token is duplicated or the sessions exceed the limit.
+func (srv *server) reserveSession(token []byte) (reason string, ok bool) {
+	srv.activeLocker.Lock()
+	defer srv.activeLocker.Unlock()
+	key := string(token)
+	if _, exist := srv.actives.Load(key); exist {
+		reason = _errDuplicatedSetupToken
+		return
+	}
+	if _, exist := srv.resumeOpts.store.Load(token); exist {
+		reason = _errDuplicatedSetupToken
+		return
+	}
+	if limit := srv.resumeOpts.maxSessions; limit > 0 {
+		n := srv.resumeOpts.store.Len()
+		srv.actives.Range(func(_, _ interface{}) bool {
+			n++
+			return n < limit
+		})
+		if n >= limit {
+			reason = _errTooManySessions
+			return
+		}
+	}
+	srv.actives.Store(key, struct{}{})
+	ok = true
+	return
+}
+
+func (srv *server) releaseSession(token []byte) {
+	srv.actives.Delete(string(token))
+}
+
+func (srv *server) saveSession(s *session.Session) {
+	srv.releaseSession(s.Token())
+	if err := srv.resumeOpts.store.Save(s); err != nil {
+		logger.Warnf("store session %s failed: %s\n", s, err)
+		_ = s.Close()
+		return
+	}
+	if logger.IsDebugEnabled() {
+		logger.Debugf("store session: %s\n", s)
+	}
+}
+
+func (srv *server) rejectResume(tp *transport.Transport, reason string) {
+	sending := framing.NewWriteableErrorFrame(0, core.ErrorCodeRejectedResume, bytesconv.StringToBytes(reason))
 	if err := tp.Send(sending, true); err != nil {
 		logger.Errorf("send resume response failed: %s\n", err)
-		_ = tp.Close()
 	}
+	_ = tp.Close()
 }
 
 func (srv *server) loopCleanSession(ctx context.Context) (err error) {
@@ -340,38 +487,31 @@ L:
 }
 
 func (srv *server) destroySessions() {
-	for srv.sm.Len() > 0 {
-		nextSession := srv.sm.Pop()
-		if err := nextSession.Close(); err != nil {
-			logger.Warnf("kill session failed: %s\n", err)
-		} else if logger.IsDebugEnabled() {
-			logger.Debugf("kill session success: %s\n", nextSession)
-		}
+	for _, it := range srv.resumeOpts.store.List() {
+		srv.closeSession(it.Token())
 	}
 }
 
 func (srv *server) doCleanSession() {
-	deadSessions := make(chan *session.Session)
-	go func(deadSessions chan *session.Session) {
-		for it := range deadSessions {
-			if err := it.Close(); err != nil {
-				logger.Warnf("close dead session failed: %s\n", err)
-			} else if logger.IsDebugEnabled() {
-				logger.Debugf("close dead session success: %s\n", it)
-			}
-		}
-	}(deadSessions)
-	var cur *session.Session
-	for srv.sm.Len() > 0 {
-		cur = srv.sm.Pop()
-		// Push back if session is still alive.
-		if !cur.IsDead() {
-			srv.sm.Push(cur)
-			break
+	now := time.Now()
+	for _, it := range srv.resumeOpts.store.List() {
+		if it.Deadline().After(now) {
+			continue
 		}
-		deadSessions <- cur
+		srv.closeSession(it.Token())
+	}
+}
+
+func (srv *server) closeSession(token []byte) {
+	s, ok := srv.resumeOpts.store.Evict(token)
+	if !ok {
+		return
+	}
+	if err := s.Close(); err != nil {
+		logger.Warnf("close session failed: %s\n", err)
+	} else if logger.IsDebugEnabled() {
+		logger.Debugf("close session success: %s\n", s)
 	}
-	close(deadSessions)
 }
 
 // WithServerResumeSessionDuration sets resume session duration for RSocket server.
@@ -381,14 +521,41 @@ func WithServerResumeSessionDuration(duration time.Duration) OpServerResume {
 	}
 }
 
+// WithServerResumeBufferSize sets the max bytes of sent frames which will be kept for resume.
+// Resume will be rejected if frames which have not been received by client were discarded.
+func WithServerResumeBufferSize(size int) OpServerResume {
+	return func(o *serverResumeOptions) {
+		o.bufferSize = size
+	}
+}
+
+// WithServerResumeStore sets the store which keeps paused sessions.
+// Default store keeps sessions in memory, see NewMemoryResumeStore.
+func WithServerResumeStore(store ResumeStore) OpServerResume {
+	return func(o *serverResumeOptions) {
+		if store != nil {
+			o.store = store
+		}
+	}
+}
+
+// WithServerResumeMaxSessions sets the max number of resumable sessions, including active and paused ones.
+// New resumable setups will be rejected once the limit is reached. Zero means no limit.
+func WithServerResumeMaxSessions(n int) OpServerResume {
+	return func(o *serverResumeOptions) {
+		o.maxSessions = n
+	}
+}
+
 // Receive receives server connections from client RSockets.
 func Receive() ServerBuilder {
 	return &server{
 		fragment: fragmentation.MaxFragment,
-		sm:       session.NewManager(),
 		done:     make(chan struct{}),
 		resumeOpts: &serverResumeOptions{
 			sessionDuration: serverSessionDuration,
+			bufferSize:      socket.DefaultResumeBufferSize,
+			store:           NewMemoryResumeStore(),
 		},
 	}
 }
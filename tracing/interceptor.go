@@ -0,0 +1,251 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+// Attribute keys of spans.
+const (
+	AttrInteraction = "rsocket.interaction"
+	AttrRoute       = "rsocket.route"
+)
+
+const (
+	_modelFireAndForget   = "FireAndForget"
+	_modelMetadataPush    = "MetadataPush"
+	_modelRequestResponse = "RequestResponse"
+	_modelRequestStream   = "RequestStream"
+	_modelRequestChannel  = "RequestChannel"
+)
+
+// Option configures the tracing interceptors.
+type Option func(*options)
+
+type options struct {
+	spanName func(model, route string) string
+}
+
+// WithSpanName sets the function of naming spans, default is the route if present, otherwise the interaction model.
+func WithSpanName(fn func(model, route string) string) Option {
+	return func(o *options) {
+		o.spanName = fn
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		spanName: func(model, route string) string {
+			if len(route) > 0 {
+				return route
+			}
+			return model
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Requester returns a requester-side Interceptor, see ClientBuilder.Interceptors and ServerBuilder.Interceptors.
+// It starts a client span around each interaction and sends its SpanContext as the MessageZipkin entry,
+// so the MetadataMimeType of connection should be CompositeMetadata.
+// The span is a child of the span in the context of request, see rsocket.WithContext, so forwarding
+// a request received by a traced handler continues the upstream trace.
+func Requester(tracer Tracer, opts ...Option) rsocket.Interceptor {
+	o := newOptions(opts)
+	return func(rs rsocket.RSocket) rsocket.RSocket {
+		start := func(model string, msg payload.Payload) (Span, payload.Payload) {
+			route, _ := inspect(msg)
+			// the span in request context is the parent, eg: forwarding the request received by a handler.
+			ctx := rsocket.ContextOf(msg)
+			parent := SpanContextFromContext(ctx)
+			spanCtx, span := tracer.Start(ctx, o.spanName(model, route), WithSpanKind(SpanKindClient), WithAttributes(attributes(model, route)...))
+			return span, inject(spanCtx, msg, span.SpanContext(), parent)
+		}
+		return rsocket.NewAbstractSocket(
+			rsocket.FireAndForget(func(msg payload.Payload) {
+				span, msg := start(_modelFireAndForget, msg)
+				defer span.End()
+				rs.FireAndForget(msg)
+			}),
+			rsocket.MetadataPush(func(msg payload.Payload) {
+				span, msg := start(_modelMetadataPush, msg)
+				defer span.End()
+				rs.MetadataPush(msg)
+			}),
+			rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+				span, msg := start(_modelRequestResponse, msg)
+				return observeMono(span, rs.RequestResponse(msg))
+			}),
+			rsocket.RequestStream(func(msg payload.Payload) flux.Flux {
+				span, msg := start(_modelRequestStream, msg)
+				return observeFlux(span, rs.RequestStream(msg))
+			}),
+			rsocket.RequestChannel(func(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+				span, initialMessage := start(_modelRequestChannel, initialMessage)
+				return observeFlux(span, rs.RequestChannel(initialMessage, messages))
+			}),
+		)
+	}
+}
+
+// Responder returns a responder-side Interceptor, see ClientBuilder.Interceptors and ServerBuilder.Interceptors.
+// It starts a server span around each interaction, which is a child of the SpanContext in MessageZipkin entry.
+func Responder(tracer Tracer, opts ...Option) rsocket.Interceptor {
+	o := newOptions(opts)
+	return func(rs rsocket.RSocket) rsocket.RSocket {
+		start := func(model string, msg payload.Payload) (Span, payload.Payload) {
+			route, remote := inspect(msg)
+			ctx := rsocket.ContextOf(msg)
+			if remote.IsValid() {
+				ctx = ContextWithRemoteSpanContext(ctx, remote)
+			}
+			spanCtx, span := tracer.Start(ctx, o.spanName(model, route), WithSpanKind(SpanKindServer), WithAttributes(attributes(model, route)...))
+			// handlers can read the span from the request context, see rsocket.ContextOf.
+			return span, rsocket.WithContext(spanCtx, msg)
+		}
+		return rsocket.NewAbstractSocket(
+			rsocket.FireAndForget(func(msg payload.Payload) {
+				span, msg := start(_modelFireAndForget, msg)
+				defer span.End()
+				rs.FireAndForget(msg)
+			}),
+			rsocket.MetadataPush(func(msg payload.Payload) {
+				span, msg := start(_modelMetadataPush, msg)
+				defer span.End()
+				rs.MetadataPush(msg)
+			}),
+			rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+				span, msg := start(_modelRequestResponse, msg)
+				return observeMono(span, rs.RequestResponse(msg))
+			}),
+			rsocket.RequestStream(func(msg payload.Payload) flux.Flux {
+				span, msg := start(_modelRequestStream, msg)
+				return observeFlux(span, rs.RequestStream(msg))
+			}),
+			rsocket.RequestChannel(func(initialMessage payload.Payload, messages flux.Flux) flux.Flux {
+				span, initialMessage := start(_modelRequestChannel, initialMessage)
+				return observeFlux(span, rs.RequestChannel(initialMessage, messages))
+			}),
+		)
+	}
+}
+
+func attributes(model, route string) []Attribute {
+	attrs := []Attribute{{Key: AttrInteraction, Value: model}}
+	if len(route) > 0 {
+		attrs = append(attrs, Attribute{Key: AttrRoute, Value: route})
+	}
+	return attrs
+}
+
+func observeMono(span Span, m mono.Mono) mono.Mono {
+	if m == nil {
+		span.End()
+		return nil
+	}
+	return m.
+		DoOnError(func(e error) {
+			span.RecordError(e)
+		}).
+		DoFinally(func(s rx.SignalType) {
+			span.End()
+		})
+}
+
+func observeFlux(span Span, f flux.Flux) flux.Flux {
+	if f == nil {
+		span.End()
+		return nil
+	}
+	return f.
+		DoOnError(func(e error) {
+			span.RecordError(e)
+		}).
+		DoFinally(func(s rx.SignalType) {
+			span.End()
+		})
+}
+
+// inspect reads the first routing tag and the SpanContext in CompositeMetadata.
+func inspect(msg payload.Payload) (route string, sc SpanContext) {
+	metadata, ok := msg.Metadata()
+	if !ok || len(metadata) < 1 {
+		return
+	}
+	cm := extension.CompositeMetadata(metadata)
+	if data, ok, err := cm.Lookup(extension.MessageRouting.String()); err == nil && ok {
+		if tags, err := extension.ParseRoutingTags(data); err == nil && len(tags) > 0 {
+			route = tags[0]
+		}
+	}
+	if data, ok, err := cm.Lookup(extension.MessageZipkin.String()); err == nil && ok {
+		if tracing, err := extension.ParseTracing(data); err == nil {
+			sc = fromTracing(tracing)
+		}
+	}
+	return
+}
+
+// inject sets the SpanContext as the MessageZipkin entry of CompositeMetadata, and attaches ctx to the message.
+// The MessageZipkin entry received from upstream is replaced, so the peer sees the span of this hop as parent.
+// The message is sent without tracing if the metadata cannot be encoded.
+func inject(ctx context.Context, msg payload.Payload, sc, parent SpanContext) payload.Payload {
+	builder := extension.NewCompositeMetadataBuilder()
+	if metadata, ok := msg.Metadata(); ok && len(metadata) > 0 {
+		entries, err := extension.CompositeMetadata(metadata).Entries()
+		if err != nil {
+			logger.Warnf("encode tracing failed: %v\n", err)
+			return rsocket.WithContext(ctx, msg)
+		}
+		for _, entry := range entries {
+			if entry.MimeType != extension.MessageZipkin.String() {
+				builder.Push(entry.MimeType, entry.Metadata)
+			}
+		}
+	}
+	metadata, err := builder.
+		PushWellKnown(extension.MessageZipkin, toTracing(sc, parent).Bytes()).
+		Build()
+	if err != nil {
+		logger.Warnf("encode tracing failed: %v\n", err)
+		return rsocket.WithContext(ctx, msg)
+	}
+	// ctx is derived from the context of message, so its deadline can be propagated.
+	return rsocket.WithContext(ctx, payload.New(msg.Data(), metadata))
+}
+
+func toTracing(sc, parent SpanContext) extension.Tracing {
+	t := extension.Tracing{
+		TraceIDHigh: binary.BigEndian.Uint64(sc.TraceID[:8]),
+		TraceID:     binary.BigEndian.Uint64(sc.TraceID[8:]),
+		SpanID:      binary.BigEndian.Uint64(sc.SpanID[:]),
+		Sampling:    extension.TracingNotSampled,
+	}
+	if parent.IsValid() {
+		t.ParentID = binary.BigEndian.Uint64(parent.SpanID[:])
+	}
+	if sc.Sampled {
+		t.Sampling = extension.TracingSampled
+	}
+	return t
+}
+
+func fromTracing(t extension.Tracing) (sc SpanContext) {
+	binary.BigEndian.PutUint64(sc.TraceID[:8], t.TraceIDHigh)
+	binary.BigEndian.PutUint64(sc.TraceID[8:], t.TraceID)
+	binary.BigEndian.PutUint64(sc.SpanID[:], t.SpanID)
+	sc.Sampled = t.Sampling == extension.TracingSampled || t.Sampling == extension.TracingDebug
+	sc.Remote = true
+	return
+}
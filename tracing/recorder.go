@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+var (
+	_ Tracer = (*Recorder)(nil)
+	_ Span   = (*recordedSpan)(nil)
+)
+
+// RecordedSpan is a finished span in Recorder.
+type RecordedSpan struct {
+	Name        string
+	Kind        SpanKind
+	SpanContext SpanContext
+	// Parent is the SpanContext of parent span, it's invalid if the span is a root.
+	Parent     SpanContext
+	Attributes []Attribute
+	Errors     []error
+	Start      time.Time
+	End        time.Time
+}
+
+// Attribute returns the value of an attribute.
+func (s RecordedSpan) Attribute(key string) (string, bool) {
+	for _, it := range s.Attributes {
+		if it.Key == key {
+			return it.Value, true
+		}
+	}
+	return "", false
+}
+
+// Recorder is an in-memory Tracer which records finished spans, all new traces are sampled.
+type Recorder struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start creates a span, which is a child of the span in the context if present.
+func (r *Recorder) Start(ctx context.Context, name string, opts ...SpanStartOption) (context.Context, Span) {
+	cfg := NewSpanConfig(opts...)
+	parent := SpanContextFromContext(ctx)
+	span := &recordedSpan{
+		r: r,
+		data: RecordedSpan{
+			Name:       name,
+			Kind:       cfg.Kind,
+			Parent:     parent,
+			Attributes: cfg.Attributes,
+			Start:      time.Now(),
+		},
+	}
+	sc := SpanContext{
+		Sampled: true,
+	}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		_, _ = rand.Read(sc.TraceID[:])
+	}
+	_, _ = rand.Read(sc.SpanID[:])
+	span.data.SpanContext = sc
+	return ContextWithSpan(ctx, span), span
+}
+
+// Spans returns the finished spans in order of ending.
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make([]RecordedSpan, len(r.spans))
+	copy(spans, r.spans)
+	return spans
+}
+
+// Reset removes all finished spans.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
+
+type recordedSpan struct {
+	r    *Recorder
+	mu   sync.Mutex
+	data RecordedSpan
+	once sync.Once
+}
+
+func (s *recordedSpan) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+	s.mu.Unlock()
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Errors = append(s.data.Errors, err)
+	s.mu.Unlock()
+}
+
+func (s *recordedSpan) End() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.data.End = time.Now()
+		data := s.data
+		s.mu.Unlock()
+		s.r.mu.Lock()
+		s.r.spans = append(s.r.spans, data)
+		s.r.mu.Unlock()
+	})
+}
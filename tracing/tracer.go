@@ -0,0 +1,159 @@
+// Package tracing traces interactions and propagates the tracing context by the Zipkin tracing metadata.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Tracing-Zipkin.md
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+)
+
+type (
+	spanCtxKey       struct{}
+	remoteSpanCtxKey struct{}
+)
+
+// TraceID is a 128-bit trace id, 64-bit trace ids have zero high bytes.
+type TraceID [16]byte
+
+// IsValid returns true if the TraceID is not zero.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID is a 64-bit span id.
+type SpanID [8]byte
+
+// IsValid returns true if the SpanID is not zero.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is the propagated part of a span.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled reports whether the trace should be reported.
+	Sampled bool
+	// Remote reports whether the SpanContext is propagated from peer.
+	Remote bool
+}
+
+// IsValid returns true if both TraceID and SpanID are valid.
+func (s SpanContext) IsValid() bool {
+	return s.TraceID.IsValid() && s.SpanID.IsValid()
+}
+
+// SpanKind is the role of a span.
+type SpanKind int8
+
+const (
+	// SpanKindInternal means an internal operation.
+	SpanKindInternal SpanKind = iota
+	// SpanKindClient means the requester side of an interaction.
+	SpanKindClient
+	// SpanKindServer means the responder side of an interaction.
+	SpanKindServer
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindClient:
+		return "client"
+	case SpanKindServer:
+		return "server"
+	default:
+		return "internal"
+	}
+}
+
+// Attribute is a key-value pair of span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// SpanConfig is the config of starting a span.
+type SpanConfig struct {
+	Kind       SpanKind
+	Attributes []Attribute
+}
+
+// SpanStartOption configures a span.
+type SpanStartOption func(*SpanConfig)
+
+// WithSpanKind sets the kind of span.
+func WithSpanKind(kind SpanKind) SpanStartOption {
+	return func(c *SpanConfig) {
+		c.Kind = kind
+	}
+}
+
+// WithAttributes adds attributes to span.
+func WithAttributes(attrs ...Attribute) SpanStartOption {
+	return func(c *SpanConfig) {
+		c.Attributes = append(c.Attributes, attrs...)
+	}
+}
+
+// NewSpanConfig applies options to a SpanConfig.
+func NewSpanConfig(opts ...SpanStartOption) SpanConfig {
+	var c SpanConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// Span is a single operation of a trace.
+type Span interface {
+	// SpanContext returns the SpanContext of current span.
+	SpanContext() SpanContext
+	// SetAttributes sets attributes of current span.
+	SetAttributes(attrs ...Attribute)
+	// RecordError records an error of current span.
+	RecordError(err error)
+	// End completes current span.
+	End()
+}
+
+// Tracer creates spans, it's shaped like the Tracer of OpenTelemetry so it can be adapted with a few lines.
+// The parent is read from the context, see SpanContextFromContext.
+type Tracer interface {
+	// Start creates a span and a context containing it.
+	Start(ctx context.Context, name string, opts ...SpanStartOption) (context.Context, Span)
+}
+
+// ContextWithSpan returns a copy of ctx with the span.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanCtxKey{}, span)
+}
+
+// SpanFromContext returns the span in the context.
+func SpanFromContext(ctx context.Context) (span Span, ok bool) {
+	span, ok = ctx.Value(spanCtxKey{}).(Span)
+	return
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx with a SpanContext propagated from peer.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteSpanCtxKey{}, sc)
+}
+
+// SpanContextFromContext returns the SpanContext of the span in the context,
+// or the remote SpanContext if there's no span.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span, ok := SpanFromContext(ctx); ok {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteSpanCtxKey{}).(SpanContext)
+	return sc
+}
@@ -0,0 +1,194 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/router"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/rsocket/rsocket-go/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routed(t *testing.T, route string) payload.Payload {
+	routing, err := extension.EncodeRouting(route)
+	require.NoError(t, err)
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageRouting, routing).
+		Build()
+	require.NoError(t, err)
+	return payload.New([]byte("hello"), metadata)
+}
+
+func waitSpans(t *testing.T, r *tracing.Recorder, n int) []tracing.RecordedSpan {
+	require.Eventually(t, func() bool {
+		return len(r.Spans()) >= n
+	}, 3*time.Second, 10*time.Millisecond)
+	return r.Spans()
+}
+
+func TestTracing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	serverRec, clientRec, forwardRec := tracing.NewRecorder(), tracing.NewRecorder(), tracing.NewRecorder()
+	fnf := make(chan struct{}, 1)
+	forwarded := make(chan payload.Payload, 1)
+	forwarder := tracing.Requester(forwardRec)(rsocket.NewAbstractSocket(
+		rsocket.RequestResponse(func(msg payload.Payload) mono.Mono {
+			forwarded <- payload.Clone(msg)
+			return mono.Just(payload.NewString("forwarded", ""))
+		}),
+	))
+	responder := tracing.Responder(serverRec)(router.New().
+		RequestResponse("echo", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return mono.Just(payload.Clone(msg))
+		}).
+		RequestResponse("forward", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return forwarder.RequestResponse(msg)
+		}).
+		RequestResponse("fail", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return mono.Error(errors.New("oops"))
+		}).
+		RequestStream("numbers", func(msg payload.Payload, vars router.Vars) flux.Flux {
+			return flux.Just(payload.NewString("1", ""), payload.NewString("2", ""))
+		}).
+		FireAndForget("log", func(msg payload.Payload, vars router.Vars) {
+			fnf <- struct{}{}
+		}).
+		RSocket())
+
+	started := make(chan struct{})
+	go func() {
+		_ = rsocket.Receive().
+			OnStart(func() {
+				close(started)
+			}).
+			Acceptor(func(ctx context.Context, setup payload.SetupPayload, sendingSocket rsocket.CloseableRSocket) (rsocket.RSocket, error) {
+				return responder, nil
+			}).
+			Transport(rsocket.TCPServer().SetHostAndPort("127.0.0.1", 9814).Build()).
+			Serve(ctx)
+	}()
+	<-started
+
+	client, err := rsocket.Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Transport(rsocket.TCPClient().SetHostAndPort("127.0.0.1", 9814).Build()).
+		Start(ctx)
+	require.NoError(t, err)
+	defer client.Close()
+	requester := tracing.Requester(clientRec)(client)
+
+	res, err := requester.RequestResponse(routed(t, "echo")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", res.DataUTF8())
+
+	clientSpans, serverSpans := waitSpans(t, clientRec, 1), waitSpans(t, serverRec, 1)
+	clientSpan, serverSpan := clientSpans[0], serverSpans[0]
+	assert.Equal(t, "echo", clientSpan.Name)
+	assert.Equal(t, tracing.SpanKindClient, clientSpan.Kind)
+	assert.Equal(t, "echo", serverSpan.Name)
+	assert.Equal(t, tracing.SpanKindServer, serverSpan.Kind)
+	assert.True(t, serverSpan.Parent.Remote)
+	assert.Equal(t, clientSpan.SpanContext.TraceID, serverSpan.Parent.TraceID)
+	assert.Equal(t, clientSpan.SpanContext.SpanID, serverSpan.Parent.SpanID)
+	assert.Equal(t, clientSpan.SpanContext.TraceID, serverSpan.SpanContext.TraceID)
+	assert.NotEqual(t, clientSpan.SpanContext.SpanID, serverSpan.SpanContext.SpanID)
+	assert.True(t, serverSpan.SpanContext.Sampled)
+	route, _ := serverSpan.Attribute(tracing.AttrRoute)
+	assert.Equal(t, "echo", route)
+	model, _ := serverSpan.Attribute(tracing.AttrInteraction)
+	assert.Equal(t, "RequestResponse", model)
+
+	clientRec.Reset()
+	serverRec.Reset()
+	res, err = requester.RequestResponse(routed(t, "forward")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "forwarded", res.DataUTF8())
+	serverSpan, forwardSpan := waitSpans(t, serverRec, 1)[0], waitSpans(t, forwardRec, 1)[0]
+	assert.Equal(t, tracing.SpanKindClient, forwardSpan.Kind)
+	assert.Equal(t, serverSpan.SpanContext, forwardSpan.Parent, "forwarded request should continue the upstream trace")
+	assert.Equal(t, serverSpan.SpanContext.TraceID, forwardSpan.SpanContext.TraceID)
+	metadata, _ := (<-forwarded).Metadata()
+	entries, err := extension.CompositeMetadata(metadata).Entries()
+	require.NoError(t, err)
+	var sent []extension.Tracing
+	for _, entry := range entries {
+		if entry.MimeType == extension.MessageZipkin.String() {
+			tr, err := extension.ParseTracing(entry.Metadata)
+			require.NoError(t, err)
+			sent = append(sent, tr)
+		}
+	}
+	assert.Len(t, entries, 2, "the routing entry should be kept")
+	require.Len(t, sent, 1, "the upstream tracing should be replaced")
+	forwardTracing := sent[0]
+	assert.Equal(t, binary.BigEndian.Uint64(forwardSpan.SpanContext.SpanID[:]), forwardTracing.SpanID)
+	assert.Equal(t, binary.BigEndian.Uint64(serverSpan.SpanContext.SpanID[:]), forwardTracing.ParentID)
+
+	clientRec.Reset()
+	serverRec.Reset()
+	_, err = requester.RequestResponse(routed(t, "fail")).Block(ctx)
+	assert.Error(t, err)
+	clientSpans, serverSpans = waitSpans(t, clientRec, 1), waitSpans(t, serverRec, 1)
+	assert.Len(t, clientSpans[0].Errors, 1)
+	assert.Len(t, serverSpans[0].Errors, 1)
+
+	clientRec.Reset()
+	serverRec.Reset()
+	numbers, err := requester.RequestStream(routed(t, "numbers")).BlockSlice(ctx)
+	require.NoError(t, err)
+	assert.Len(t, numbers, 2)
+	clientSpans, serverSpans = waitSpans(t, clientRec, 1), waitSpans(t, serverRec, 1)
+	assert.Equal(t, clientSpans[0].SpanContext.SpanID, serverSpans[0].Parent.SpanID)
+	model, _ = serverSpans[0].Attribute(tracing.AttrInteraction)
+	assert.Equal(t, "RequestStream", model)
+
+	clientRec.Reset()
+	serverRec.Reset()
+	requester.FireAndForget(routed(t, "log"))
+	<-fnf
+	clientSpans, serverSpans = waitSpans(t, clientRec, 1), waitSpans(t, serverRec, 1)
+	assert.Equal(t, clientSpans[0].SpanContext.TraceID, serverSpans[0].SpanContext.TraceID)
+}
+
+func TestRecorder(t *testing.T) {
+	rec := tracing.NewRecorder()
+	ctx, parent := rec.Start(context.Background(), "parent")
+	_, child := rec.Start(ctx, "child", tracing.WithAttributes(tracing.Attribute{Key: "k", Value: "v"}))
+	child.SetAttributes(tracing.Attribute{Key: "k2", Value: "v2"})
+	child.RecordError(errors.New("oops"))
+	child.RecordError(nil)
+	child.End()
+	child.End()
+	parent.End()
+
+	spans := rec.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, tracing.SpanKindInternal, spans[0].Kind)
+	assert.Equal(t, parent.SpanContext(), spans[0].Parent)
+	assert.Equal(t, parent.SpanContext().TraceID, spans[0].SpanContext.TraceID)
+	assert.Len(t, spans[0].Errors, 1)
+	v, ok := spans[0].Attribute("k2")
+	assert.True(t, ok)
+	assert.Equal(t, "v2", v)
+	assert.True(t, spans[1].SpanContext.IsValid())
+	assert.Len(t, spans[1].SpanContext.TraceID.String(), 32)
+	assert.Len(t, spans[1].SpanContext.SpanID.String(), 16)
+
+	// not sampled parent from peer
+	remote := tracing.SpanContext{TraceID: tracing.TraceID{15: 1}, SpanID: tracing.SpanID{7: 2}}
+	_, span := rec.Start(tracing.ContextWithRemoteSpanContext(context.Background(), remote), "remote")
+	assert.False(t, span.SpanContext().Sampled)
+	assert.Equal(t, remote.TraceID, span.SpanContext().TraceID)
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 	"github.com/rsocket/rsocket-go/core/transport"
+	"go.uber.org/atomic"
 )
 
 const (
@@ -19,6 +23,8 @@ const (
 	DefaultPort = 7878
 )
 
+var errListenerServed = errors.New("rsocket: listener has been served already")
+
 // TCPClientBuilder provides builder which can be used to create a client-side TCP transport easily.
 type TCPClientBuilder struct {
 	addr   string
@@ -37,6 +43,7 @@ type WebsocketClientBuilder struct {
 	tlsCfg *tls.Config
 	header http.Header
 	proxy  func(*http.Request) (*url.URL, error)
+	opts   []transport.WebsocketOption
 }
 
 // WebsocketServerBuilder provides builder which can be used to create a server-side Websocket transport easily.
@@ -45,6 +52,8 @@ type WebsocketServerBuilder struct {
 	path      string
 	tlsConfig *tls.Config
 	upgrader  *websocket.Upgrader
+	server    *http.Server
+	opts      []transport.WebsocketOption
 }
 
 // UnixClientBuilder provides builder which can be used to create a client-side UDS transport easily.
@@ -57,6 +66,65 @@ type UnixServerBuilder struct {
 	path string
 }
 
+// LocalClientBuilder provides builder which can be used to create a client-side in-process transport easily.
+type LocalClientBuilder struct {
+	name string
+}
+
+// LocalServerBuilder provides builder which can be used to create a server-side in-process transport easily.
+type LocalServerBuilder struct {
+	name string
+}
+
+// Build builds and returns a new in-process ServerTransporter.
+func (ls *LocalServerBuilder) Build() transport.ServerTransporter {
+	return func(ctx context.Context) (transport.ServerTransport, error) {
+		return transport.NewLocalServerTransport(ls.name), nil
+	}
+}
+
+// Build builds and returns a new in-process ClientTransporter.
+func (lc *LocalClientBuilder) Build() transport.ClientTransporter {
+	return func(ctx context.Context) (*transport.Transport, error) {
+		return transport.NewLocalClientTransport(ctx, lc.name)
+	}
+}
+
+// ConnClientBuilder provides builder which can be used to create a client-side transport over any net.Conn.
+type ConnClientBuilder struct {
+	dialer func(context.Context) (net.Conn, error)
+}
+
+// ConnServerBuilder provides builder which can be used to create a server-side transport over any net.Listener.
+type ConnServerBuilder struct {
+	listener net.Listener
+	served   *atomic.Bool
+}
+
+// Build builds and returns a new ClientTransporter.
+func (cc *ConnClientBuilder) Build() transport.ClientTransporter {
+	return func(ctx context.Context) (*transport.Transport, error) {
+		conn, err := cc.dialer(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return transport.NewTCPClientTransport(conn), nil
+	}
+}
+
+// Build builds and returns a new ServerTransporter.
+// The listener can only be served once, since it's closed when the server is stopped.
+func (cs *ConnServerBuilder) Build() transport.ServerTransporter {
+	return func(ctx context.Context) (transport.ServerTransport, error) {
+		if !cs.served.CAS(false, true) {
+			return nil, errListenerServed
+		}
+		return transport.NewTCPServerTransport(func(ctx context.Context) (net.Listener, error) {
+			return cs.listener, nil
+		}), nil
+	}
+}
+
 // SetPath sets UDS sock file path.
 func (us *UnixServerBuilder) SetPath(path string) *UnixServerBuilder {
 	us.path = path
@@ -146,10 +214,83 @@ func (ws *WebsocketServerBuilder) SetUpgrader(upgrader *websocket.Upgrader) *Web
 // Build builds and returns a new websocket ServerTransporter.
 func (ws *WebsocketServerBuilder) Build() transport.ServerTransporter {
 	return func(ctx context.Context) (transport.ServerTransport, error) {
-		return transport.NewWebsocketServerTransportWithAddr(ws.addr, ws.path, ws.upgrader, ws.tlsConfig), nil
+		return transport.NewWebsocketServerTransportWithAddr(ws.addr, ws.path, ws.upgrader, ws.tlsConfig, ws.opts...), nil
 	}
 }
 
+// SetCompression enables the per-message compression negotiation (permessage-deflate).
+// The level is the compression level of flate, zero means the default compression level.
+func (ws *WebsocketServerBuilder) SetCompression(level int) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketCompression(level))
+	return ws
+}
+
+// SetReadLimit sets the max size in bytes of a message read from the client.
+func (ws *WebsocketServerBuilder) SetReadLimit(limit int64) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketReadLimit(limit))
+	return ws
+}
+
+// SetWriteBufferPool sets the pool of buffers for write operations.
+func (ws *WebsocketServerBuilder) SetWriteBufferPool(pool websocket.BufferPool) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketWriteBufferPool(pool))
+	return ws
+}
+
+// SetPingInterval sends a ping message to the client at the given interval.
+func (ws *WebsocketServerBuilder) SetPingInterval(interval time.Duration) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketPingInterval(interval))
+	return ws
+}
+
+// SetPingHandler sets the handler of ping messages received from the client.
+func (ws *WebsocketServerBuilder) SetPingHandler(h func(appData string) error) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketPingHandler(h))
+	return ws
+}
+
+// SetPongHandler sets the handler of pong messages received from the client.
+func (ws *WebsocketServerBuilder) SetPongHandler(h func(appData string) error) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketPongHandler(h))
+	return ws
+}
+
+// SetSubprotocols sets the supported subprotocols in order of preference, eg: transport.WebsocketSubprotocol.
+func (ws *WebsocketServerBuilder) SetSubprotocols(protocols ...string) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketSubprotocols(protocols...))
+	return ws
+}
+
+// SetCheckOrigin sets the function which checks the origin of requests.
+// You can use the helpers: transport.AllowAllOrigins, transport.SameOrigin and transport.AllowOrigins.
+func (ws *WebsocketServerBuilder) SetCheckOrigin(checkOrigin func(r *http.Request) bool) *WebsocketServerBuilder {
+	ws.opts = append(ws.opts, transport.WithWebsocketCheckOrigin(checkOrigin))
+	return ws
+}
+
+// SetHTTPServer attaches the transport built by BuildHandler to an existing http server.
+// The transport will be closed when the server is shut down.
+func (ws *WebsocketServerBuilder) SetHTTPServer(server *http.Server) *WebsocketServerBuilder {
+	ws.server = server
+	return ws
+}
+
+// BuildHandler builds and returns a new websocket ServerTransporter which doesn't own any listener,
+// and the http.Handler which accepts websocket connections for it.
+// The handler can be mounted at any path of an existing http server, the addr, path and tls config are ignored.
+//
+// Example:
+//
+//	tp, handler := rsocket.WebsocketServer().BuildHandler()
+//	mux.Handle("/rsocket", handler)
+//	go rsocket.Receive().Acceptor(acceptor).Transport(tp).Serve(ctx)
+func (ws *WebsocketServerBuilder) BuildHandler() (transport.ServerTransporter, http.Handler) {
+	handler := transport.NewWebsocketHandler(ws.upgrader, ws.server, ws.opts...)
+	return func(ctx context.Context) (transport.ServerTransport, error) {
+		return handler, nil
+	}, handler
+}
+
 // SetTLSConfig sets the tls config.
 //
 // Here's an example:
@@ -184,10 +325,53 @@ func (wc *WebsocketClientBuilder) SetProxy(proxy func(*http.Request) (*url.URL,
 // Build builds and returns a new websocket ClientTransporter
 func (wc *WebsocketClientBuilder) Build() transport.ClientTransporter {
 	return func(ctx context.Context) (*transport.Transport, error) {
-		return transport.NewWebsocketClientTransport(ctx, wc.url, wc.tlsCfg, wc.header, wc.proxy)
+		return transport.NewWebsocketClientTransport(ctx, wc.url, wc.tlsCfg, wc.header, wc.proxy, wc.opts...)
 	}
 }
 
+// SetCompression enables the per-message compression negotiation (permessage-deflate).
+// The level is the compression level of flate, zero means the default compression level.
+func (wc *WebsocketClientBuilder) SetCompression(level int) *WebsocketClientBuilder {
+	wc.opts = append(wc.opts, transport.WithWebsocketCompression(level))
+	return wc
+}
+
+// SetReadLimit sets the max size in bytes of a message read from the server.
+func (wc *WebsocketClientBuilder) SetReadLimit(limit int64) *WebsocketClientBuilder {
+	wc.opts = append(wc.opts, transport.WithWebsocketReadLimit(limit))
+	return wc
+}
+
+// SetWriteBufferPool sets the pool of buffers for write operations.
+func (wc *WebsocketClientBuilder) SetWriteBufferPool(pool websocket.BufferPool) *WebsocketClientBuilder {
+	wc.opts = append(wc.opts, transport.WithWebsocketWriteBufferPool(pool))
+	return wc
+}
+
+// SetPingInterval sends a ping message to the server at the given interval.
+func (wc *WebsocketClientBuilder) SetPingInterval(interval time.Duration) *WebsocketClientBuilder {
+	wc.opts = append(wc.opts, transport.WithWebsocketPingInterval(interval))
+	return wc
+}
+
+// SetPingHandler sets the handler of ping messages received from the server.
+func (wc *WebsocketClientBuilder) SetPingHandler(h func(appData string) error) *WebsocketClientBuilder {
+	wc.opts = append(wc.opts, transport.WithWebsocketPingHandler(h))
+	return wc
+}
+
+// SetPongHandler sets the handler of pong messages received from the server.
+func (wc *WebsocketClientBuilder) SetPongHandler(h func(appData string) error) *WebsocketClientBuilder {
+	wc.opts = append(wc.opts, transport.WithWebsocketPongHandler(h))
+	return wc
+}
+
+// SetSubprotocols sets the requested subprotocols in order of preference, eg: transport.WebsocketSubprotocol.
+func (wc *WebsocketClientBuilder) SetSubprotocols(protocols ...string) *WebsocketClientBuilder {
+	wc.opts = append(wc.opts, transport.WithWebsocketSubprotocols(protocols...))
+	return wc
+}
+
 // SetHostAndPort sets the host and port.
 func (ts *TCPServerBuilder) SetHostAndPort(host string, port int) *TCPServerBuilder {
 	ts.addr = fmt.Sprintf("%s:%d", host, port)
@@ -310,3 +494,43 @@ func UnixServer() *UnixServerBuilder {
 		path: DefaultUnixSockPath,
 	}
 }
+
+// LocalClient creates a new LocalClientBuilder which connects to the local server with given name.
+func LocalClient(name string) *LocalClientBuilder {
+	return &LocalClientBuilder{
+		name: name,
+	}
+}
+
+// LocalServer creates a new LocalServerBuilder.
+// The server can be connected by LocalClient with the same name in current process, no network is used.
+func LocalServer(name string) *LocalServerBuilder {
+	return &LocalServerBuilder{
+		name: name,
+	}
+}
+
+// ConnClient creates a new ConnClientBuilder.
+// The dialer is used to create the connection, and frames are written with the same framing of TCP transport.
+// It can be used to run RSocket over SSH channels, net.Pipe or any other custom connections.
+func ConnClient(dialer func(context.Context) (net.Conn, error)) *ConnClientBuilder {
+	if dialer == nil {
+		panic("dialer cannot be nil!")
+	}
+	return &ConnClientBuilder{
+		dialer: dialer,
+	}
+}
+
+// ConnServer creates a new ConnServerBuilder.
+// Connections accepted from the listener use the same framing of TCP transport.
+// The listener will be closed when the server is stopped, so it can only be served by one server.
+func ConnServer(listener net.Listener) *ConnServerBuilder {
+	if listener == nil {
+		panic("listener cannot be nil!")
+	}
+	return &ConnServerBuilder{
+		listener: listener,
+		served:   atomic.NewBool(false),
+	}
+}
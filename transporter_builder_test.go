@@ -4,13 +4,17 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/core/transport"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,3 +80,76 @@ func TestWebsocketServer(t *testing.T) {
 		assert.NotNil(t, tp)
 	})
 }
+
+func TestLocalClient(t *testing.T) {
+	_, err := rsocket.LocalClient("fake-local").Build()(context.Background())
+	assert.Error(t, err, "should fail without local server")
+}
+
+func TestLocalServer(t *testing.T) {
+	tp, err := rsocket.LocalServer("fake-local").Build()(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, tp)
+}
+
+func TestConnClient(t *testing.T) {
+	tp := rsocket.ConnClient(func(ctx context.Context) (net.Conn, error) {
+		return nil, fakeErr
+	}).Build()
+	_, err := tp(context.Background())
+	assert.Equal(t, fakeErr, err)
+
+	assert.Panics(t, func() {
+		rsocket.ConnClient(nil)
+	})
+}
+
+func TestConnServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	builder := rsocket.ConnServer(l)
+	tp, err := builder.Build()(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, tp)
+
+	// the listener has been served already
+	_, err = builder.Build()(context.Background())
+	assert.Error(t, err)
+
+	assert.Panics(t, func() {
+		rsocket.ConnServer(nil)
+	})
+}
+
+func TestWebsocketServer_BuildHandler(t *testing.T) {
+	tp, handler := rsocket.WebsocketServer().SetHTTPServer(&http.Server{}).BuildHandler()
+	assert.NotNil(t, handler)
+	s, err := tp(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, handler, s)
+}
+
+func TestWebsocketOptions(t *testing.T) {
+	assert.NotPanics(t, func() {
+		rsocket.WebsocketServer().
+			SetCompression(0).
+			SetReadLimit(1024).
+			SetWriteBufferPool(&sync.Pool{}).
+			SetPingInterval(time.Second).
+			SetPingHandler(func(string) error { return nil }).
+			SetPongHandler(func(string) error { return nil }).
+			SetSubprotocols(transport.WebsocketSubprotocol).
+			SetCheckOrigin(transport.SameOrigin).
+			Build()
+		rsocket.WebsocketClient().
+			SetCompression(0).
+			SetReadLimit(1024).
+			SetWriteBufferPool(&sync.Pool{}).
+			SetPingInterval(time.Second).
+			SetPingHandler(func(string) error { return nil }).
+			SetPongHandler(func(string) error { return nil }).
+			SetSubprotocols(transport.WebsocketSubprotocol).
+			Build()
+	})
+}